@@ -0,0 +1,258 @@
+// Package agent defines the players of engine matches, which choose moves from a read-only view of the game,
+// and runs matches between them.
+package agent
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k-yomo/shogi/shogi"
+	"github.com/pkg/errors"
+)
+
+// minThinkingTime is the least time the engines think for a move
+const minThinkingTime = 100 * time.Millisecond
+
+// ErrDeclareWin is returned by ChooseMove to declare the win by entering king (入玉宣言)
+var ErrDeclareWin = errors.New("declare win")
+
+// Agent is a player choosing moves
+type Agent interface {
+	Name() string
+	// ChooseMove returns the move to play for the side to move, or resign true to resign.
+	// ctx is done when the player's time is up.
+	ChooseMove(ctx context.Context, pos Position, timeLeft TimeLeft) (move shogi.Move, resign bool, err error)
+}
+
+// GameHandler is implemented by agents which prepare for each game and learn its result
+type GameHandler interface {
+	// NewGame is called before the first move of each game
+	NewGame(ctx context.Context) error
+	// GameOver is called after each game with the color the agent played
+	GameOver(result shogi.Result, color shogi.Color)
+}
+
+// Position is a read-only view of the game an agent plays
+type Position struct {
+	game *shogi.Game
+}
+
+// NewPosition returns the view of the game
+func NewPosition(game *shogi.Game) Position {
+	return Position{game: game}
+}
+
+// Board returns a copy of the current position
+func (p Position) Board() *shogi.BitboardBoard {
+	return p.game.Board()
+}
+
+func (p Position) SideToMove() shogi.Color {
+	return p.game.SideToMove()
+}
+
+// LegalMoves returns every legal move of the side to move
+func (p Position) LegalMoves() []shogi.Move {
+	return p.game.LegalMoves()
+}
+
+// History returns the moves played from the initial position
+func (p Position) History() []shogi.Move {
+	return p.game.History()
+}
+
+// MoveNumber returns the number of the next move
+func (p Position) MoveNumber() int {
+	return p.game.MoveNumber()
+}
+
+// SFEN returns the current position in SFEN
+func (p Position) SFEN() string {
+	return p.game.SFEN()
+}
+
+// USIPosition returns the game in the format of USI position command, e.g. "startpos moves 7g7f"
+func (p Position) USIPosition() string {
+	return p.game.USIPosition()
+}
+
+// TimeLeft is the time both players have when choosing a move
+type TimeLeft struct {
+	TimeControl shogi.TimeControl
+	// Remaining is the main time and Periods are the byoyomi periods left for each color
+	Remaining [2]time.Duration
+	Periods   [2]int
+}
+
+// timeLeftOf returns the time left on the clock, which is unlimited if it's nil
+func timeLeftOf(clock *shogi.Clock) TimeLeft {
+	if clock == nil {
+		return TimeLeft{}
+	}
+	t := TimeLeft{TimeControl: clock.TimeControl()}
+	for _, c := range []shogi.Color{shogi.Sente, shogi.Gote} {
+		t.Remaining[c] = clock.Remaining(c)
+		t.Periods[c] = clock.Periods(c)
+	}
+	return t
+}
+
+// Limit returns the longest time the color can think before its time is up, zero if the time is unlimited
+func (t TimeLeft) Limit(c shogi.Color) time.Duration {
+	if t.TimeControl.IsUnlimited() {
+		return 0
+	}
+	return t.Remaining[c] + time.Duration(t.Periods[c])*t.TimeControl.Byoyomi
+}
+
+// Random plays a random legal move, and resigns when there is none.
+// It isn't safe for concurrent use.
+type Random struct {
+	rand *rand.Rand
+}
+
+// NewRandom returns the agent choosing moves with the random seed
+func NewRandom(seed int64) *Random {
+	return &Random{rand: rand.New(rand.NewSource(seed))}
+}
+
+func (r *Random) Name() string {
+	return "random"
+}
+
+func (r *Random) ChooseMove(ctx context.Context, pos Position, timeLeft TimeLeft) (shogi.Move, bool, error) {
+	moves := pos.LegalMoves()
+	if len(moves) == 0 {
+		return shogi.Move{}, true, nil
+	}
+	return moves[r.rand.Intn(len(moves))], false, nil
+}
+
+// Engine plays with the built-in engine, and resigns when there is no legal move
+type Engine struct {
+	Depth int
+	// Threads is the number of search workers, see shogi.SearchOptions
+	Threads int
+	// MaxTime is the maximum thinking time per move, no limit but the time left if it's zero
+	MaxTime time.Duration
+}
+
+func (e *Engine) Name() string {
+	depth := e.Depth
+	if depth <= 0 {
+		depth = 4
+	}
+	return "engine(depth " + strconv.Itoa(depth) + ")"
+}
+
+func (e *Engine) ChooseMove(ctx context.Context, pos Position, timeLeft TimeLeft) (shogi.Move, bool, error) {
+	if budget := thinkingBudget(e.MaxTime, pos.SideToMove(), timeLeft); budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, budget)
+		defer cancel()
+	}
+	result, ok := pos.Board().Search(ctx, shogi.SearchOptions{Depth: e.Depth, Threads: e.Threads})
+	if !ok {
+		return shogi.Move{}, true, nil
+	}
+	return result.Move, false, nil
+}
+
+// thinkingBudget returns the time to think for the move of the color with the maximum time, zero if there is no limit
+func thinkingBudget(maxTime time.Duration, c shogi.Color, timeLeft TimeLeft) time.Duration {
+	tc := timeLeft.TimeControl
+	budget := maxTime
+	if !tc.IsUnlimited() {
+		// spend a fraction of the main time, and most of byoyomi keeping a margin for the overhead
+		budget = timeLeft.Remaining[c]/40 + tc.Increment/2
+		if timeLeft.Periods[c] > 0 {
+			budget += tc.Byoyomi * 8 / 10
+		}
+		if maxTime > 0 && budget > maxTime {
+			budget = maxTime
+		}
+	}
+	if budget > 0 && budget < minThinkingTime {
+		budget = minThinkingTime
+	}
+	return budget
+}
+
+// AskFunc asks a person for the move, see Agent.ChooseMove
+type AskFunc func(ctx context.Context, pos Position, timeLeft TimeLeft) (move shogi.Move, resign bool, err error)
+
+// Human is a person playing through a callback such as a UI
+type Human struct {
+	PlayerName string
+	Ask        AskFunc
+}
+
+func (h *Human) Name() string {
+	return h.PlayerName
+}
+
+func (h *Human) ChooseMove(ctx context.Context, pos Position, timeLeft TimeLeft) (shogi.Move, bool, error) {
+	return h.Ask(ctx, pos, timeLeft)
+}
+
+var (
+	seedMu sync.Mutex
+	seeds  = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// Parse returns a new agent from the spec, which is one of "random" or "random:<seed>", "engine" or "engine:<depth>",
+// "mcts" or "mcts:<iterations>" with the guided playouts, and "usi:<path> [args...]" starting the USI engine.
+func Parse(spec string) (Agent, error) {
+	kind, arg := spec, ""
+	if i := strings.Index(spec, ":"); i >= 0 {
+		kind, arg = spec[:i], spec[i+1:]
+	}
+	switch kind {
+	case "random":
+		seedMu.Lock()
+		seed := seeds.Int63()
+		seedMu.Unlock()
+		if arg != "" {
+			var err error
+			if seed, err = strconv.ParseInt(arg, 10, 64); err != nil {
+				return nil, errors.Errorf("invalid seed: %q", spec)
+			}
+		}
+		return NewRandom(seed), nil
+	case "engine":
+		engine := &Engine{}
+		if arg != "" {
+			depth, err := strconv.Atoi(arg)
+			if err != nil || depth <= 0 {
+				return nil, errors.Errorf("invalid depth: %q", spec)
+			}
+			engine.Depth = depth
+		}
+		return engine, nil
+	case "mcts":
+		seedMu.Lock()
+		mcts := NewMCTS(seeds.Int63())
+		seedMu.Unlock()
+		mcts.Playout = PlayoutGuided
+		if arg != "" {
+			iterations, err := strconv.Atoi(arg)
+			if err != nil || iterations <= 0 {
+				return nil, errors.Errorf("invalid iterations: %q", spec)
+			}
+			mcts.Iterations = iterations
+		}
+		return mcts, nil
+	case "usi":
+		fields := strings.Fields(arg)
+		if len(fields) == 0 {
+			return nil, errors.Errorf("usi engine path is missing: %q", spec)
+		}
+		return StartUSIEngine(fields[0], fields[1:]...)
+	default:
+		return nil, errors.Errorf("unknown agent: %q", spec)
+	}
+}
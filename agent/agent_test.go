@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/k-yomo/shogi/shogi"
+)
+
+func TestThinkingBudget(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxTime  time.Duration
+		timeLeft TimeLeft
+		want     time.Duration
+	}{
+		{name: "unlimited", want: 0},
+		{name: "unlimited with the maximum time", maxTime: 3 * time.Second, want: 3 * time.Second},
+		{
+			name:     "main time",
+			timeLeft: TimeLeft{TimeControl: shogi.TimeControl{MainTime: 10 * time.Minute}, Remaining: [2]time.Duration{400 * time.Second, time.Second}},
+			want:     10 * time.Second,
+		},
+		{
+			name: "byoyomi",
+			timeLeft: TimeLeft{
+				TimeControl: shogi.TimeControl{MainTime: time.Minute, Byoyomi: 10 * time.Second},
+				Periods:     [2]int{1, 1},
+			},
+			want: 8 * time.Second,
+		},
+		{
+			name:     "increment",
+			timeLeft: TimeLeft{TimeControl: shogi.TimeControl{MainTime: time.Minute, Increment: 4 * time.Second}, Remaining: [2]time.Duration{40 * time.Second}},
+			want:     3 * time.Second,
+		},
+		{
+			name:     "capped by the maximum time",
+			maxTime:  time.Second,
+			timeLeft: TimeLeft{TimeControl: shogi.TimeControl{MainTime: time.Hour}, Remaining: [2]time.Duration{time.Hour}},
+			want:     time.Second,
+		},
+		{
+			name:     "at least the minimum time",
+			timeLeft: TimeLeft{TimeControl: shogi.TimeControl{MainTime: time.Minute}, Remaining: [2]time.Duration{time.Second}},
+			want:     minThinkingTime,
+		},
+	}
+	for _, tt := range tests {
+		if got := thinkingBudget(tt.maxTime, shogi.Sente, tt.timeLeft); got != tt.want {
+			t.Errorf("%s: thinkingBudget() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
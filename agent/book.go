@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"math/rand"
+
+	"github.com/k-yomo/shogi/book"
+	"github.com/k-yomo/shogi/shogi"
+)
+
+// BookAgent plays the moves of the opening book while the position is in it, and the moves of the agent otherwise.
+// It isn't safe for concurrent use.
+type BookAgent struct {
+	Agent
+	book *book.Book
+	rand *rand.Rand
+}
+
+// WithBook returns the agent playing from the book with the random seed
+func WithBook(a Agent, b *book.Book, seed int64) *BookAgent {
+	return &BookAgent{Agent: a, book: b, rand: rand.New(rand.NewSource(seed))}
+}
+
+func (a *BookAgent) ChooseMove(ctx context.Context, pos Position, timeLeft TimeLeft) (shogi.Move, bool, error) {
+	if move, ok := a.book.Choose(pos.Board(), a.rand); ok {
+		return move, false, nil
+	}
+	return a.Agent.ChooseMove(ctx, pos, timeLeft)
+}
+
+func (a *BookAgent) NewGame(ctx context.Context) error {
+	if h, ok := a.Agent.(GameHandler); ok {
+		return h.NewGame(ctx)
+	}
+	return nil
+}
+
+func (a *BookAgent) GameOver(result shogi.Result, color shogi.Color) {
+	if h, ok := a.Agent.(GameHandler); ok {
+		h.GameOver(result, color)
+	}
+}
+
+func (a *BookAgent) Close() error {
+	if c, ok := a.Agent.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/k-yomo/shogi/book"
+	"github.com/k-yomo/shogi/shogi"
+)
+
+// handlerAgent is the scripted agent which counts the games it's notified of
+type handlerAgent struct {
+	scriptedAgent
+	newGames  int
+	gameOvers int
+}
+
+func (a *handlerAgent) NewGame(ctx context.Context) error {
+	a.newGames++
+	return nil
+}
+
+func (a *handlerAgent) GameOver(result shogi.Result, color shogi.Color) {
+	a.gameOvers++
+}
+
+// gameAfter returns the game after the USI moves
+func gameAfter(t *testing.T, moves ...string) *shogi.Game {
+	t.Helper()
+	g := shogi.NewGame()
+	for _, s := range moves {
+		m, err := g.Board().ParseMove(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := g.ApplyMove(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return g
+}
+
+func TestBookAgent_ChooseMove(t *testing.T) {
+	initial := shogi.NewGame()
+	b := book.New()
+	b.Add(initial.Board(), book.Entry{Move: "2g2f", Count: 1})
+	afterMove := gameAfter(t, "7g7f")
+	// the book has only an illegal move of the position
+	b.Add(afterMove.Board(), book.Entry{Move: "5i5h", Count: 10})
+	outOfBook := gameAfter(t, "2g2f")
+
+	tests := []struct {
+		name string
+		game *shogi.Game
+		want string
+	}{
+		{name: "in the book", game: initial, want: "2g2f"},
+		{name: "only illegal moves in the book", game: afterMove, want: afterMove.LegalMoves()[0].String()},
+		{name: "out of the book", game: outOfBook, want: outOfBook.LegalMoves()[0].String()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := WithBook(&scriptedAgent{name: "first", choose: playFirst}, b, 1)
+			move, resign, err := a.ChooseMove(context.Background(), NewPosition(tt.game), TimeLeft{})
+			if err != nil {
+				t.Fatalf("ChooseMove() error = %v", err)
+			}
+			if resign {
+				t.Fatal("ChooseMove() resigned")
+			}
+			if move.String() != tt.want {
+				t.Errorf("ChooseMove() = %s, want %s", move, tt.want)
+			}
+		})
+	}
+}
+
+func TestBookAgent_Delegate(t *testing.T) {
+	var closed int32
+	h := &handlerAgent{scriptedAgent: scriptedAgent{name: "handler", choose: playFirst, closed: &closed}}
+	a := WithBook(h, book.New(), 1)
+	if a.Name() != "handler" {
+		t.Errorf("Name() = %q, want %q", a.Name(), "handler")
+	}
+	if err := a.NewGame(context.Background()); err != nil {
+		t.Fatalf("NewGame() error = %v", err)
+	}
+	a.GameOver(shogi.Result{Winner: shogi.Sente}, shogi.Sente)
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if h.newGames != 1 || h.gameOvers != 1 || atomic.LoadInt32(&closed) != 1 {
+		t.Errorf("the agent got %d NewGame, %d GameOver and %d Close, want 1 each", h.newGames, h.gameOvers, closed)
+	}
+
+	// the agents without the handlers are fine
+	plain := WithBook(&scriptedAgent{name: "plain", choose: playFirst}, book.New(), 1)
+	if err := plain.NewGame(context.Background()); err != nil {
+		t.Errorf("NewGame() error = %v", err)
+	}
+	plain.GameOver(shogi.Result{}, shogi.Gote)
+}
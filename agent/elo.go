@@ -0,0 +1,160 @@
+package agent
+
+import (
+	"fmt"
+	"math"
+)
+
+// confidenceZ is the z-score of the 95% confidence interval
+const confidenceZ = 1.959964
+
+// Score is the wins, losses and draws of a player against another
+type Score struct {
+	Wins   int
+	Losses int
+	Draws  int
+}
+
+func (s Score) Games() int {
+	return s.Wins + s.Losses + s.Draws
+}
+
+// Ratio returns the points per game counting a draw as half a win, 0.5 if no game is played
+func (s Score) Ratio() float64 {
+	if s.Games() == 0 {
+		return 0.5
+	}
+	return (float64(s.Wins) + float64(s.Draws)/2) / float64(s.Games())
+}
+
+// Elo returns the Elo difference estimated from the score and the margin of its 95% confidence interval.
+// The difference is infinite if the player won or lost every game.
+func (s Score) Elo() (diff, margin float64) {
+	n := float64(s.Games())
+	ratio := s.Ratio()
+	if n == 0 || ratio == 0 || ratio == 1 {
+		return eloOf(ratio), math.Inf(1)
+	}
+	deviation := math.Sqrt(s.variance() / n)
+	low, high := eloOf(ratio-confidenceZ*deviation), eloOf(ratio+confidenceZ*deviation)
+	return eloOf(ratio), (high - low) / 2
+}
+
+// String returns the score with the Elo difference, e.g. "+12 -8 =3 (Elo 55.4 ± 140.2)"
+func (s Score) String() string {
+	diff, margin := s.Elo()
+	return fmt.Sprintf("+%d -%d =%d (Elo %.1f ± %.1f)", s.Wins, s.Losses, s.Draws, diff, margin)
+}
+
+// LLR returns the log-likelihood ratio of the hypothesis that the Elo difference is elo1 against elo0,
+// approximated with the normal distribution of the score
+func (s Score) LLR(elo0, elo1 float64) float64 {
+	if s.Games() == 0 {
+		return 0
+	}
+	wins, losses, draws := float64(s.Wins), float64(s.Losses), float64(s.Draws)
+	if s.Wins == 0 || s.Losses == 0 || s.Draws == 0 {
+		// add half a game to each outcome, or the variance can be zero
+		wins, losses, draws = wins+0.5, losses+0.5, draws+0.5
+	}
+	n := wins + losses + draws
+	ratio := (wins + draws/2) / n
+	variance := pointsVariance(wins, losses, draws, ratio)
+	ratio0, ratio1 := ratioOf(elo0), ratioOf(elo1)
+	return n * (ratio1 - ratio0) * (2*ratio - ratio0 - ratio1) / (2 * variance)
+}
+
+// variance returns the variance of the points of a game
+func (s Score) variance() float64 {
+	return pointsVariance(float64(s.Wins), float64(s.Losses), float64(s.Draws), s.Ratio())
+}
+
+// pointsVariance returns the variance of the points of a game with the mean ratio
+func pointsVariance(wins, losses, draws, ratio float64) float64 {
+	return (wins*(1-ratio)*(1-ratio) + draws*(0.5-ratio)*(0.5-ratio) + losses*ratio*ratio) / (wins + losses + draws)
+}
+
+func (s *Score) add(other Score) {
+	s.Wins += other.Wins
+	s.Losses += other.Losses
+	s.Draws += other.Draws
+}
+
+// reverse returns the score of the opponent
+func (s Score) reverse() Score {
+	return Score{Wins: s.Losses, Losses: s.Wins, Draws: s.Draws}
+}
+
+// eloOf returns the Elo difference expected to score the ratio
+func eloOf(ratio float64) float64 {
+	if ratio <= 0 {
+		return math.Inf(-1)
+	}
+	if ratio >= 1 {
+		return math.Inf(1)
+	}
+	return 400 * math.Log10(ratio/(1-ratio))
+}
+
+// ratioOf returns the ratio expected with the Elo difference
+func ratioOf(elo float64) float64 {
+	return 1 / (1 + math.Pow(10, -elo/400))
+}
+
+// SPRTDecision is the state of the sequential probability ratio test
+type SPRTDecision int
+
+const (
+	// SPRTContinue means more games are needed
+	SPRTContinue SPRTDecision = iota
+	// SPRTAccept accepts the hypothesis H1, the player is stronger by Elo1
+	SPRTAccept
+	// SPRTReject accepts the hypothesis H0, the player isn't stronger than by Elo0
+	SPRTReject
+)
+
+func (d SPRTDecision) String() string {
+	switch d {
+	case SPRTAccept:
+		return "H1 accepted"
+	case SPRTReject:
+		return "H0 accepted"
+	default:
+		return "continue"
+	}
+}
+
+// SPRT is the sequential probability ratio test of H0: the Elo difference is Elo0 against H1: it's Elo1,
+// which stops the match as soon as either is accepted
+type SPRT struct {
+	Elo0 float64
+	Elo1 float64
+	// Alpha and Beta are the probabilities of the false positive and the false negative, 0.05 if they are zero
+	Alpha float64
+	Beta  float64
+}
+
+// Bounds returns the log-likelihood ratios to accept H0 and H1
+func (t SPRT) Bounds() (lower, upper float64) {
+	alpha, beta := t.Alpha, t.Beta
+	if alpha == 0 {
+		alpha = 0.05
+	}
+	if beta == 0 {
+		beta = 0.05
+	}
+	return math.Log(beta / (1 - alpha)), math.Log((1 - beta) / alpha)
+}
+
+// Test returns the decision for the score of the player
+func (t SPRT) Test(s Score) SPRTDecision {
+	lower, upper := t.Bounds()
+	switch llr := s.LLR(t.Elo0, t.Elo1); {
+	case llr >= upper:
+		return SPRTAccept
+	case llr <= lower:
+		return SPRTReject
+	default:
+		return SPRTContinue
+	}
+}
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"math"
+	"testing"
+)
+
+// closeTo reports if the values are equal within the tolerance of the rounding in the tests
+func closeTo(got, want float64) bool {
+	if math.IsInf(want, 0) {
+		return got == want
+	}
+	return math.Abs(got-want) < 0.01
+}
+
+func TestScore_Elo(t *testing.T) {
+	tests := []struct {
+		score      Score
+		wantDiff   float64
+		wantMargin float64
+	}{
+		{score: Score{Wins: 60, Losses: 40}, wantDiff: 70.44, wantMargin: 70.57},
+		{score: Score{Wins: 30, Losses: 20, Draws: 50}, wantDiff: 34.86, wantMargin: 48.47},
+		{score: Score{Wins: 10, Losses: 10}, wantDiff: 0, wantMargin: 163.32},
+		{score: Score{Wins: 40, Losses: 60}, wantDiff: -70.44, wantMargin: 70.57},
+		{score: Score{Wins: 3}, wantDiff: math.Inf(1), wantMargin: math.Inf(1)},
+		{score: Score{Losses: 3}, wantDiff: math.Inf(-1), wantMargin: math.Inf(1)},
+		{score: Score{}, wantDiff: 0, wantMargin: math.Inf(1)},
+	}
+	for _, tt := range tests {
+		diff, margin := tt.score.Elo()
+		if !closeTo(diff, tt.wantDiff) || !closeTo(margin, tt.wantMargin) {
+			t.Errorf("%+v.Elo() = %.2f ± %.2f, want %.2f ± %.2f", tt.score, diff, margin, tt.wantDiff, tt.wantMargin)
+		}
+	}
+	if got, want := (Score{Wins: 60, Losses: 40}).String(), "+60 -40 =0 (Elo 70.4 ± 70.6)"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
+
+func TestScore_LLR(t *testing.T) {
+	tests := []struct {
+		score      Score
+		elo0, elo1 float64
+		want       float64
+	}{
+		// half a game is added to each outcome without draws
+		{score: Score{Wins: 60, Losses: 40}, elo0: 0, elo1: 10, want: 0.56},
+		{score: Score{Wins: 300, Losses: 200, Draws: 500}, elo0: 0, elo1: 10, want: 5.03},
+		{score: Score{Wins: 10, Losses: 40, Draws: 5}, elo0: 0, elo1: 20, want: -2.97},
+		{score: Score{}, elo0: 0, elo1: 10, want: 0},
+	}
+	for _, tt := range tests {
+		if got := tt.score.LLR(tt.elo0, tt.elo1); !closeTo(got, tt.want) {
+			t.Errorf("%+v.LLR(%v, %v) = %.2f, want %.2f", tt.score, tt.elo0, tt.elo1, got, tt.want)
+		}
+	}
+}
+
+func TestSPRT_Bounds(t *testing.T) {
+	lower, upper := SPRT{Elo0: 0, Elo1: 10}.Bounds()
+	if !closeTo(lower, -2.94) || !closeTo(upper, 2.94) {
+		t.Errorf("Bounds() = %.2f, %.2f, want -2.94, 2.94 with the default alpha and beta", lower, upper)
+	}
+	lower, upper = SPRT{Elo0: 0, Elo1: 10, Alpha: 0.05, Beta: 0.1}.Bounds()
+	if !closeTo(lower, -2.25) || !closeTo(upper, 2.89) {
+		t.Errorf("Bounds() = %.2f, %.2f, want -2.25, 2.89", lower, upper)
+	}
+}
+
+func TestSPRT_Test(t *testing.T) {
+	sprt := SPRT{Elo0: 0, Elo1: 10}
+	tests := []struct {
+		score Score
+		want  SPRTDecision
+	}{
+		{score: Score{}, want: SPRTContinue},
+		{score: Score{Wins: 60, Losses: 40}, want: SPRTContinue},
+		{score: Score{Wins: 300, Losses: 200, Draws: 500}, want: SPRTAccept},
+		{score: Score{Wins: 200, Losses: 300, Draws: 500}, want: SPRTReject},
+	}
+	for _, tt := range tests {
+		if got := sprt.Test(tt.score); got != tt.want {
+			t.Errorf("Test(%+v) = %s, want %s", tt.score, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/k-yomo/shogi/shogi"
+	"github.com/pkg/errors"
+)
+
+// GameRecord is a game played by two agents
+type GameRecord struct {
+	Sente Agent
+	Gote  Agent
+	// Game is the finished game with the times spent on the moves
+	Game      *shogi.Game
+	Result    shogi.Result
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// KIF returns the record of the game in KIF
+func (r *GameRecord) KIF() string {
+	return r.Game.KIF(shogi.KIFInfo{
+		SenteName: r.Sente.Name(),
+		GoteName:  r.Gote.Name(),
+		StartTime: r.StartTime,
+		EndTime:   r.EndTime,
+	})
+}
+
+// CSA returns the record of the game in CSA
+func (r *GameRecord) CSA() string {
+	return r.Game.CSA(shogi.CSAInfo{
+		SenteName: r.Sente.Name(),
+		GoteName:  r.Gote.Name(),
+		StartTime: r.StartTime,
+		EndTime:   r.EndTime,
+	})
+}
+
+// senteScore returns the score of the game for sente, where aborted games are draws
+func (r *GameRecord) senteScore() Score {
+	switch {
+	case !r.Result.HasWinner():
+		return Score{Draws: 1}
+	case r.Result.Winner == shogi.Sente:
+		return Score{Wins: 1}
+	default:
+		return Score{Losses: 1}
+	}
+}
+
+// Play plays the game between the agents to the end in the illegal-move-loses mode.
+// The game is drawn as 持将棋 when maxMoves moves are played, no limit if it's zero.
+// An error of an agent aborts the game and is returned with the record of the game.
+func Play(ctx context.Context, sente, gote Agent, game *shogi.Game, tc shogi.TimeControl, maxMoves int) (*GameRecord, error) {
+	record := &GameRecord{Sente: sente, Gote: gote, Game: game, StartTime: time.Now()}
+	agents := [2]Agent{sente, gote}
+	for _, a := range agents {
+		if h, ok := a.(GameHandler); ok {
+			if err := h.NewGame(ctx); err != nil {
+				return nil, errors.Wrapf(err, "%s", a.Name())
+			}
+		}
+	}
+	game.SetIllegalMoveLoses(true)
+	if !tc.IsUnlimited() {
+		game.StartClock(shogi.NewClock(tc, nil))
+	}
+
+	err := play(ctx, agents, game, maxMoves)
+	if err != nil && !game.IsOver() {
+		_ = game.Abort()
+	}
+	record.EndTime = time.Now()
+	record.Result, _ = game.Result()
+	for c, a := range agents {
+		if h, ok := a.(GameHandler); ok {
+			h.GameOver(record.Result, shogi.Color(c))
+		}
+	}
+	return record, err
+}
+
+func play(ctx context.Context, agents [2]Agent, game *shogi.Game, maxMoves int) error {
+	for !game.IsOver() {
+		if maxMoves > 0 && len(game.History()) >= maxMoves {
+			return game.AgreeDraw()
+		}
+		turn := game.SideToMove()
+		agent := agents[turn]
+		timeLeft := timeLeftOf(game.Clock())
+		moveCtx, cancel := ctx, context.CancelFunc(func() {})
+		if limit := timeLeft.Limit(turn); limit > 0 {
+			moveCtx, cancel = context.WithTimeout(ctx, limit)
+		}
+		move, resign, err := agent.ChooseMove(moveCtx, NewPosition(game), timeLeft)
+		cancel()
+		if game.CheckTime() {
+			// the time is up while thinking, whatever the agent answered
+			return nil
+		}
+		switch {
+		case errors.Cause(err) == ErrDeclareWin:
+			// an invalid declaration loses the game
+			_, _ = game.DeclareWin()
+		case err != nil:
+			return errors.Wrapf(err, "%s", agent.Name())
+		case resign:
+			return game.Resign(turn)
+		default:
+			// an illegal move or the time up ends the game
+			_ = game.ApplyMove(move)
+		}
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "play")
+		}
+	}
+	return nil
+}
+
+// Match is a series of games between two agents alternating colors
+type Match struct {
+	// Agents[0] plays sente in the first game, and Agents[1] in the second game
+	Agents [2]Agent
+	Games  int
+	// TimeControl is the time settings of each game, no time limit if it's zero
+	TimeControl shogi.TimeControl
+	// MaxMoves is the number of moves after which the game is drawn, no limit if it's zero
+	MaxMoves int
+	// SFEN is the position the games start from, the initial position if it's empty
+	SFEN string
+	// OnGame is called after each game if it's set
+	OnGame func(record *GameRecord)
+}
+
+// MatchResult is the result of a match. The score is of Agents[0], and the draws include aborted games.
+type MatchResult struct {
+	Score
+	Records []*GameRecord
+}
+
+// Run plays the games of the match. It stops at the first error, and returns the result of the games played so far with it.
+func (m *Match) Run(ctx context.Context) (*MatchResult, error) {
+	result := &MatchResult{}
+	for i := 0; i < m.Games; i++ {
+		game := shogi.NewGame()
+		if m.SFEN != "" {
+			var err error
+			if game, err = shogi.NewGameFromSFEN(m.SFEN); err != nil {
+				return result, errors.Wrap(err, "match")
+			}
+		}
+		// first is the index of the agent playing sente
+		first := i % 2
+		record, err := Play(ctx, m.Agents[first], m.Agents[1-first], game, m.TimeControl, m.MaxMoves)
+		if record != nil {
+			result.add(record, first)
+			if m.OnGame != nil {
+				m.OnGame(record)
+			}
+		}
+		if err != nil {
+			return result, errors.Wrapf(err, "game %d", i+1)
+		}
+	}
+	return result, nil
+}
+
+// add counts the game, where first is the index of the agent which played sente
+func (r *MatchResult) add(record *GameRecord, first int) {
+	r.Records = append(r.Records, record)
+	score := record.senteScore()
+	if first == 1 {
+		score = score.reverse()
+	}
+	r.Score.add(score)
+}
@@ -0,0 +1,197 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k-yomo/shogi/shogi"
+	"github.com/pkg/errors"
+)
+
+// playIllegal plays the king two squares forward
+func playIllegal(pos Position) (shogi.Move, bool, error) {
+	usi := "5i5g"
+	if pos.SideToMove() == shogi.Gote {
+		usi = "5a5c"
+	}
+	move, err := pos.Board().ParseMove(usi)
+	return move, false, err
+}
+
+// declareWin declares the win whatever the position is
+func declareWin(pos Position) (shogi.Move, bool, error) {
+	return shogi.Move{}, false, ErrDeclareWin
+}
+
+var errAgent = errors.New("agent error")
+
+// fail returns the error
+func fail(pos Position) (shogi.Move, bool, error) {
+	return shogi.Move{}, false, errAgent
+}
+
+// seriesAgent plays each game by the function of the game
+type seriesAgent struct {
+	name  string
+	games []func(pos Position) (shogi.Move, bool, error)
+	game  int
+}
+
+func (a *seriesAgent) Name() string {
+	return a.name
+}
+
+func (a *seriesAgent) ChooseMove(ctx context.Context, pos Position, timeLeft TimeLeft) (shogi.Move, bool, error) {
+	return a.games[a.game-1](pos)
+}
+
+func (a *seriesAgent) NewGame(ctx context.Context) error {
+	a.game++
+	return nil
+}
+
+func (a *seriesAgent) GameOver(result shogi.Result, color shogi.Color) {}
+
+func TestPlay(t *testing.T) {
+	tests := []struct {
+		name      string
+		sente     func(pos Position) (shogi.Move, bool, error)
+		gote      func(pos Position) (shogi.Move, bool, error)
+		maxMoves  int
+		want      shogi.Result
+		wantMoves int
+		wantErr   error
+	}{
+		{
+			name:  "sente resigns",
+			sente: resign,
+			gote:  playFirst,
+			want:  shogi.Result{Reason: shogi.ReasonResignation, Winner: shogi.Gote},
+		},
+		{
+			name:      "gote resigns",
+			sente:     playFirst,
+			gote:      resign,
+			want:      shogi.Result{Reason: shogi.ReasonResignation, Winner: shogi.Sente},
+			wantMoves: 1,
+		},
+		{
+			name:      "an illegal move loses",
+			sente:     playFirst,
+			gote:      playIllegal,
+			want:      shogi.Result{Reason: shogi.ReasonIllegalMove, Winner: shogi.Sente},
+			wantMoves: 1,
+		},
+		{
+			name:  "an invalid declaration loses",
+			sente: declareWin,
+			gote:  playFirst,
+			want:  shogi.Result{Reason: shogi.ReasonIllegalMove, Winner: shogi.Gote},
+		},
+		{
+			name:      "drawn at max moves",
+			sente:     playFirst,
+			gote:      playFirst,
+			maxMoves:  4,
+			want:      shogi.Result{Reason: shogi.ReasonJishogi},
+			wantMoves: 4,
+		},
+		{
+			name:      "an error aborts",
+			sente:     playFirst,
+			gote:      fail,
+			want:      shogi.Result{Reason: shogi.ReasonAbort},
+			wantMoves: 1,
+			wantErr:   errAgent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sente := &scriptedAgent{name: "sente", choose: tt.sente}
+			gote := &scriptedAgent{name: "gote", choose: tt.gote}
+			record, err := Play(context.Background(), sente, gote, shogi.NewGame(), shogi.TimeControl{}, tt.maxMoves)
+			if errors.Cause(err) != tt.wantErr {
+				t.Fatalf("Play() error = %v, want %v", err, tt.wantErr)
+			}
+			if record.Result != tt.want {
+				t.Errorf("Play() result = %+v, want %+v", record.Result, tt.want)
+			}
+			if got := len(record.Game.History()); got != tt.wantMoves {
+				t.Errorf("Play() played %d moves, want %d", got, tt.wantMoves)
+			}
+			if !record.Game.IsOver() {
+				t.Error("Play() returned the game in progress")
+			}
+			if record.Sente != sente || record.Gote != gote {
+				t.Error("Play() record has the wrong agents")
+			}
+		})
+	}
+}
+
+func TestMatch_Run(t *testing.T) {
+	a := &seriesAgent{name: "a", games: []func(pos Position) (shogi.Move, bool, error){resign, playFirst, playFirst, playFirst}}
+	b := &seriesAgent{name: "b", games: []func(pos Position) (shogi.Move, bool, error){playFirst, resign, playFirst, playIllegal}}
+	var onGame int
+	m := &Match{
+		Agents:   [2]Agent{a, b},
+		Games:    4,
+		MaxMoves: 2,
+		OnGame:   func(record *GameRecord) { onGame++ },
+	}
+	result, err := m.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	// a resigns in the first game, b in the second, the third is drawn and b plays an illegal move in the fourth
+	if want := (Score{Wins: 2, Losses: 1, Draws: 1}); result.Score != want {
+		t.Errorf("Run() score = %+v, want %+v", result.Score, want)
+	}
+	if len(result.Records) != 4 || onGame != 4 {
+		t.Fatalf("Run() has %d records and called OnGame %d times, want 4", len(result.Records), onGame)
+	}
+	for i, r := range result.Records {
+		wantSente := Agent(a)
+		if i%2 == 1 {
+			wantSente = b
+		}
+		if r.Sente != wantSente {
+			t.Errorf("Records[%d].Sente = %s, want %s", i, r.Sente.Name(), wantSente.Name())
+		}
+	}
+}
+
+func TestMatch_Run_Error(t *testing.T) {
+	a := &seriesAgent{name: "a", games: []func(pos Position) (shogi.Move, bool, error){resign, fail, resign}}
+	b := &seriesAgent{name: "b", games: []func(pos Position) (shogi.Move, bool, error){playFirst, playFirst, playFirst}}
+	m := &Match{Agents: [2]Agent{a, b}, Games: 3}
+	result, err := m.Run(context.Background())
+	if errors.Cause(err) != errAgent || !strings.Contains(err.Error(), "game 2") {
+		t.Fatalf("Run() error = %v, want the error of game 2", err)
+	}
+	// the aborted game is a draw
+	if want := (Score{Losses: 1, Draws: 1}); result.Score != want {
+		t.Errorf("Run() score = %+v, want %+v", result.Score, want)
+	}
+	if len(result.Records) != 2 {
+		t.Errorf("Run() has %d records, want 2", len(result.Records))
+	}
+}
+
+func TestMatch_Run_SFEN(t *testing.T) {
+	a := &scriptedAgent{name: "a", choose: playFirst}
+	b := &scriptedAgent{name: "b", choose: resign}
+	m := &Match{Agents: [2]Agent{a, b}, Games: 2, SFEN: secondOpening}
+	result, err := m.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	// gote moves first from the position, so b resigns in the first game and a plays the first move in the second
+	if want := (Score{Wins: 2}); result.Score != want {
+		t.Errorf("Run() score = %+v, want %+v", result.Score, want)
+	}
+	if got := result.Records[0].Game.History(); len(got) != 0 {
+		t.Errorf("Records[0] history = %v, want none", got)
+	}
+}
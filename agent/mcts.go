@@ -0,0 +1,256 @@
+package agent
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/k-yomo/shogi/shogi"
+)
+
+const (
+	defaultExploration  = math.Sqrt2
+	defaultPlayoutDepth = 40
+	defaultIterations   = 10000
+	// evaluationScale is the score where the playout is regarded to win with the probability of about 73%
+	evaluationScale = 600.0
+	// iterationsPerContextCheck is how often the search checks if it's cancelled
+	iterationsPerContextCheck = 64
+)
+
+// Playout is how the moves of the playouts are chosen
+type Playout int
+
+const (
+	// PlayoutRandom chooses every legal move with the same probability
+	PlayoutRandom Playout = iota
+	// PlayoutGuided prefers captures of valuable pieces and promotions
+	PlayoutGuided
+)
+
+// MCTS plays with Monte Carlo tree search using UCT. The playouts are cut at PlayoutDepth and the position is evaluated.
+// The tree is reused for the next move when the game continues from the searched position.
+// It isn't safe for concurrent use.
+type MCTS struct {
+	// Exploration is the constant of UCT, √2 if it's zero
+	Exploration float64
+	Playout     Playout
+	// PlayoutDepth is the maximum number of moves of a playout, 40 if it's zero
+	PlayoutDepth int
+	// Iterations is the number of playouts per move, no limit but the time if it's zero.
+	// It's 10000 if it's zero without time limit.
+	Iterations int
+	// MaxTime is the maximum thinking time per move, no limit but the iterations and the time left if it's zero
+	MaxTime time.Duration
+	rand    *rand.Rand
+	// root is the node of the last searched position reached by history
+	root    *mctsNode
+	history []shogi.Move
+}
+
+type mctsNode struct {
+	// move is the move leading to the node, and wins are the wins of the player who made it
+	move     shogi.Move
+	visits   int
+	wins     float64
+	children []*mctsNode
+	// untried are the legal moves without the child node yet, which are nil before the node is expanded
+	untried  []shogi.Move
+	expanded bool
+}
+
+// NewMCTS returns the agent with the random seed, whose iterations and time are set by the fields
+func NewMCTS(seed int64) *MCTS {
+	return &MCTS{rand: rand.New(rand.NewSource(seed))}
+}
+
+func (m *MCTS) Name() string {
+	if m.Iterations > 0 {
+		return "mcts(" + strconv.Itoa(m.Iterations) + " iterations)"
+	}
+	return "mcts"
+}
+
+// NewGame discards the tree of the previous game
+func (m *MCTS) NewGame(ctx context.Context) error {
+	m.root, m.history = nil, nil
+	return nil
+}
+
+func (m *MCTS) GameOver(result shogi.Result, color shogi.Color) {
+	m.root, m.history = nil, nil
+}
+
+func (m *MCTS) ChooseMove(ctx context.Context, pos Position, timeLeft TimeLeft) (shogi.Move, bool, error) {
+	iterations := m.Iterations
+	if budget := thinkingBudget(m.MaxTime, pos.SideToMove(), timeLeft); budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, budget)
+		defer cancel()
+	} else if iterations <= 0 {
+		iterations = defaultIterations
+	}
+	board := pos.Board()
+	history := pos.History()
+	root := m.reuse(history)
+	if root == nil {
+		root = &mctsNode{}
+	}
+	m.expand(root, board)
+	if len(root.untried) == 0 && len(root.children) == 0 {
+		return shogi.Move{}, true, nil
+	}
+	// at least one iteration runs so that the root has a child to choose even if the context is already done
+	for i := 0; iterations <= 0 || i < iterations; i++ {
+		if i > 0 && i%iterationsPerContextCheck == 0 && ctx.Err() != nil {
+			break
+		}
+		m.iterate(root, board.Clone())
+	}
+
+	best := root.children[0]
+	for _, child := range root.children {
+		if child.visits > best.visits {
+			best = child
+		}
+	}
+	m.root = best
+	m.history = append(history, best.move)
+	return best.move, false, nil
+}
+
+// reuse returns the node of the current position in the tree of the last search, nil if the game doesn't continue from it
+func (m *MCTS) reuse(history []shogi.Move) *mctsNode {
+	if m.root == nil || len(history) < len(m.history) {
+		return nil
+	}
+	for i, move := range m.history {
+		if history[i] != move {
+			return nil
+		}
+	}
+	node := m.root
+	for _, move := range history[len(m.history):] {
+		next := (*mctsNode)(nil)
+		for _, child := range node.children {
+			if child.move == move {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// iterate selects a leaf with UCT, expands it, plays out from it and propagates the result back
+func (m *MCTS) iterate(root *mctsNode, board *shogi.BitboardBoard) {
+	path := []*mctsNode{root}
+	node := root
+	for len(node.untried) == 0 && len(node.children) > 0 {
+		node = m.selectChild(node)
+		board.DoMove(node.move)
+		path = append(path, node)
+	}
+	if len(node.untried) > 0 {
+		i := m.rand.Intn(len(node.untried))
+		move := node.untried[i]
+		node.untried[i] = node.untried[len(node.untried)-1]
+		node.untried = node.untried[:len(node.untried)-1]
+		child := &mctsNode{move: move}
+		node.children = append(node.children, child)
+		board.DoMove(move)
+		m.expand(child, board)
+		path = append(path, child)
+	}
+
+	// value is the result for the side to move at the leaf, which is the opponent of the player who made the leaf's move
+	value := m.playout(board)
+	for i := len(path) - 1; i >= 0; i-- {
+		path[i].visits++
+		path[i].wins += 1 - value
+		value = 1 - value
+	}
+}
+
+func (m *MCTS) expand(node *mctsNode, board *shogi.BitboardBoard) {
+	if node.expanded {
+		return
+	}
+	node.untried = board.LegalMoves()
+	node.expanded = true
+}
+
+func (m *MCTS) selectChild(node *mctsNode) *mctsNode {
+	c := m.Exploration
+	if c == 0 {
+		c = defaultExploration
+	}
+	logVisits := math.Log(float64(node.visits))
+	var best *mctsNode
+	bestValue := math.Inf(-1)
+	for _, child := range node.children {
+		value := child.wins/float64(child.visits) + c*math.Sqrt(logVisits/float64(child.visits))
+		if value > bestValue {
+			best, bestValue = child, value
+		}
+	}
+	return best
+}
+
+// playout plays the moves from the position, and returns the result for the side to move from 0 for a loss to 1 for a win.
+// The result of a playout cut by the depth is the winning probability estimated from the evaluation.
+func (m *MCTS) playout(board *shogi.BitboardBoard) float64 {
+	turn := board.SideToMove()
+	depth := m.PlayoutDepth
+	if depth <= 0 {
+		depth = defaultPlayoutDepth
+	}
+	for i := 0; i < depth; i++ {
+		moves := board.LegalMoves()
+		if len(moves) == 0 {
+			if board.SideToMove() == turn {
+				return 0
+			}
+			return 1
+		}
+		board.DoMove(m.choosePlayoutMove(moves))
+	}
+	p := 1 / (1 + math.Exp(-float64(board.Evaluate())/evaluationScale))
+	if board.SideToMove() != turn {
+		return 1 - p
+	}
+	return p
+}
+
+func (m *MCTS) choosePlayoutMove(moves []shogi.Move) shogi.Move {
+	if m.Playout == PlayoutRandom {
+		return moves[m.rand.Intn(len(moves))]
+	}
+	weights := make([]int, len(moves))
+	total := 0
+	for i, move := range moves {
+		weight := 1
+		if move.Captured != shogi.KindNone {
+			weight += 4 + int(move.Captured.Demote())
+		}
+		if move.Promote {
+			weight += 4
+		}
+		weights[i] = weight
+		total += weight
+	}
+	n := m.rand.Intn(total)
+	for i, w := range weights {
+		if n < w {
+			return moves[i]
+		}
+		n -= w
+	}
+	return moves[len(moves)-1]
+}
@@ -0,0 +1,26 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k-yomo/shogi/shogi"
+)
+
+func TestMCTS_ChooseMove_CancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	game := shogi.NewGame()
+	m := NewMCTS(1)
+
+	move, resign, err := m.ChooseMove(ctx, NewPosition(game), TimeLeft{})
+	if err != nil {
+		t.Fatalf("ChooseMove() error = %v", err)
+	}
+	if resign {
+		t.Fatal("ChooseMove() resigned in the initial position")
+	}
+	if err := game.Board().ValidateMove(move); err != nil {
+		t.Errorf("ChooseMove() = %s, which is illegal: %v", move, err)
+	}
+}
@@ -0,0 +1,239 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+
+	"github.com/k-yomo/shogi/shogi"
+	"github.com/pkg/errors"
+)
+
+// Format is how the entrants of a tournament are paired
+type Format int
+
+const (
+	// FormatRoundRobin pairs every entrant with every other
+	FormatRoundRobin Format = iota
+	// FormatGauntlet pairs the first entrant with each of the others
+	FormatGauntlet
+)
+
+// Entrant is a participant of a tournament. New returns a new agent for each game since games are played in parallel,
+// and the agent is closed after the game if it's an io.Closer.
+type Entrant struct {
+	Name string
+	New  func() (Agent, error)
+}
+
+// Tournament plays matches among the entrants in parallel
+type Tournament struct {
+	Entrants []Entrant
+	Format   Format
+	// Openings are the positions in SFEN the games start from, each of which is played from both sides in a round.
+	// The initial position is used if it's empty.
+	Openings []string
+	// Rounds is the number of times each pairing plays every opening from both sides, 1 if it's zero
+	Rounds int
+	// Concurrency is the number of games played at the same time, 1 if it's zero
+	Concurrency int
+	// TimeControl is the time settings of each game, no time limit if it's zero
+	TimeControl shogi.TimeControl
+	// MaxMoves is the number of moves after which the game is drawn, no limit if it's zero
+	MaxMoves int
+	// SPRT stops the games of a pairing as soon as the test accepts either hypothesis for the first entrant, if it's set
+	SPRT *SPRT
+	// RecordDir is the directory where the KIF and CSA records of the games are written, which are not written if it's empty
+	RecordDir string
+	// OnGame is called after each game if it's set. The calls are serialized.
+	OnGame func(pairing *Pairing, record *GameRecord)
+}
+
+// Pairing is the result of the games between two entrants. The score is of First.
+type Pairing struct {
+	First  *Entrant
+	Second *Entrant
+	Score
+	// Decision is the decision of the SPRT, which is always SPRTContinue without the test
+	Decision SPRTDecision
+}
+
+// TournamentResult is the result of a tournament
+type TournamentResult struct {
+	Pairings []*Pairing
+}
+
+// Standing returns the total score of the entrant against the others
+func (r *TournamentResult) Standing(e *Entrant) Score {
+	var score Score
+	for _, p := range r.Pairings {
+		switch e {
+		case p.First:
+			score.add(p.Score)
+		case p.Second:
+			score.add(p.Score.reverse())
+		}
+	}
+	return score
+}
+
+// tournamentGame is a game to play in a tournament
+type tournamentGame struct {
+	number  int
+	pairing *Pairing
+	opening string
+	// reversed is whether Second plays sente
+	reversed bool
+}
+
+// Run plays every game of the tournament. It stops at the first error, and returns the result of the games played so far with it.
+func (t *Tournament) Run(ctx context.Context) (*TournamentResult, error) {
+	openings := t.Openings
+	if len(openings) == 0 {
+		openings = []string{shogi.InitialSFEN}
+	}
+	for _, sfen := range openings {
+		if _, err := shogi.NewGameFromSFEN(sfen); err != nil {
+			return nil, errors.Wrap(err, "opening")
+		}
+	}
+	result := &TournamentResult{Pairings: t.pairings()}
+	games := t.schedule(result.Pairings, openings)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	queue := make(chan *tournamentGame)
+	go func() {
+		defer close(queue)
+		for _, g := range games {
+			select {
+			case queue <- g:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	concurrency := t.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for g := range queue {
+				mu.Lock()
+				decided := g.pairing.Decision != SPRTContinue
+				mu.Unlock()
+				if decided {
+					continue
+				}
+				record, err := t.play(ctx, g)
+				mu.Lock()
+				// the games aborted by an error aren't counted
+				if err == nil {
+					err = t.finish(g, record)
+				}
+				if err != nil && firstErr == nil {
+					firstErr = errors.Wrapf(err, "game %d", g.number)
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return result, firstErr
+}
+
+// pairings returns the pairings of the entrants for the format
+func (t *Tournament) pairings() []*Pairing {
+	var pairings []*Pairing
+	for i := range t.Entrants {
+		for j := i + 1; j < len(t.Entrants); j++ {
+			if t.Format == FormatGauntlet && i > 0 {
+				break
+			}
+			pairings = append(pairings, &Pairing{First: &t.Entrants[i], Second: &t.Entrants[j]})
+		}
+	}
+	return pairings
+}
+
+// schedule returns the games in the order to play, which interleaves the pairings so that they progress evenly
+func (t *Tournament) schedule(pairings []*Pairing, openings []string) []*tournamentGame {
+	rounds := t.Rounds
+	if rounds <= 0 {
+		rounds = 1
+	}
+	var games []*tournamentGame
+	for round := 0; round < rounds; round++ {
+		for _, opening := range openings {
+			for _, reversed := range []bool{false, true} {
+				for _, p := range pairings {
+					games = append(games, &tournamentGame{number: len(games) + 1, pairing: p, opening: opening, reversed: reversed})
+				}
+			}
+		}
+	}
+	return games
+}
+
+// play plays the game with new agents of the entrants
+func (t *Tournament) play(ctx context.Context, g *tournamentGame) (*GameRecord, error) {
+	entrants := [2]*Entrant{g.pairing.First, g.pairing.Second}
+	if g.reversed {
+		entrants[0], entrants[1] = entrants[1], entrants[0]
+	}
+	var agents [2]Agent
+	for i, e := range entrants {
+		a, err := e.New()
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s", e.Name)
+		}
+		if c, ok := a.(io.Closer); ok {
+			defer c.Close()
+		}
+		agents[i] = a
+	}
+	game, err := shogi.NewGameFromSFEN(g.opening)
+	if err != nil {
+		return nil, err
+	}
+	return Play(ctx, agents[0], agents[1], game, t.TimeControl, t.MaxMoves)
+}
+
+// finish counts the game and writes its records, which must be called with the lock held
+func (t *Tournament) finish(g *tournamentGame, record *GameRecord) error {
+	score := record.senteScore()
+	if g.reversed {
+		score = score.reverse()
+	}
+	p := g.pairing
+	p.Score.add(score)
+	if t.SPRT != nil && p.Decision == SPRTContinue {
+		p.Decision = t.SPRT.Test(p.Score)
+	}
+	if t.RecordDir != "" {
+		name := filepath.Join(t.RecordDir, fmt.Sprintf("%04d", g.number))
+		if err := ioutil.WriteFile(name+".kif", []byte(record.KIF()), 0644); err != nil {
+			return errors.Wrap(err, "write record")
+		}
+		if err := ioutil.WriteFile(name+".csa", []byte(record.CSA()), 0644); err != nil {
+			return errors.Wrap(err, "write record")
+		}
+	}
+	if t.OnGame != nil {
+		t.OnGame(p, record)
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package agent
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/k-yomo/shogi/shogi"
+)
+
+// scriptedAgent chooses moves by the function, and counts how many times it's closed
+type scriptedAgent struct {
+	name   string
+	choose func(pos Position) (move shogi.Move, resign bool, err error)
+	closed *int32
+}
+
+func (a *scriptedAgent) Name() string {
+	return a.name
+}
+
+func (a *scriptedAgent) ChooseMove(ctx context.Context, pos Position, timeLeft TimeLeft) (shogi.Move, bool, error) {
+	return a.choose(pos)
+}
+
+func (a *scriptedAgent) Close() error {
+	if a.closed != nil {
+		atomic.AddInt32(a.closed, 1)
+	}
+	return nil
+}
+
+// playFirst plays the first legal move
+func playFirst(pos Position) (shogi.Move, bool, error) {
+	return pos.LegalMoves()[0], false, nil
+}
+
+// resign resigns at once
+func resign(pos Position) (shogi.Move, bool, error) {
+	return shogi.Move{}, true, nil
+}
+
+// scriptedEntrant returns the entrant of the scripted agents, which are counted when they are closed
+func scriptedEntrant(name string, choose func(pos Position) (shogi.Move, bool, error), closed *int32) Entrant {
+	return Entrant{Name: name, New: func() (Agent, error) {
+		return &scriptedAgent{name: name, choose: choose, closed: closed}, nil
+	}}
+}
+
+// secondOpening is a position after 7g7f, which gote starts from
+const secondOpening = "lnsgkgsnl/1r5b1/ppppppppp/9/9/2P6/PP1PPPPPP/1B5R1/LNSGKGSNL w - 2"
+
+func TestTournament_Run(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		// wantPairings are the names of the entrants of the pairings and the score of the first
+		wantPairings []string
+		wantScores   []Score
+	}{
+		{
+			name:         "round robin",
+			format:       FormatRoundRobin,
+			wantPairings: []string{"player-resigner1", "player-resigner2", "resigner1-resigner2"},
+			// the resigners lose every game they play sente
+			wantScores: []Score{{Wins: 8}, {Wins: 8}, {Wins: 4, Losses: 4}},
+		},
+		{
+			name:         "gauntlet",
+			format:       FormatGauntlet,
+			wantPairings: []string{"player-resigner1", "player-resigner2"},
+			wantScores:   []Score{{Wins: 8}, {Wins: 8}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var closed int32
+			// sente counts the games each entrant played sente by the pairing and the opening
+			var mu sync.Mutex
+			sente := map[string]map[string]int{}
+			games := 0
+			tournament := &Tournament{
+				Entrants: []Entrant{
+					scriptedEntrant("player", playFirst, &closed),
+					scriptedEntrant("resigner1", resign, &closed),
+					scriptedEntrant("resigner2", resign, &closed),
+				},
+				Format:      tt.format,
+				Openings:    []string{shogi.InitialSFEN, secondOpening},
+				Rounds:      2,
+				Concurrency: 3,
+				OnGame: func(p *Pairing, record *GameRecord) {
+					mu.Lock()
+					defer mu.Unlock()
+					games++
+					key := p.First.Name + "-" + p.Second.Name + " " + record.Game.InitialBoard().SFEN(1)
+					if sente[key] == nil {
+						sente[key] = map[string]int{}
+					}
+					sente[key][record.Sente.Name()]++
+				},
+			}
+			result, err := tournament.Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(result.Pairings) != len(tt.wantPairings) {
+				t.Fatalf("pairings = %d, want %d", len(result.Pairings), len(tt.wantPairings))
+			}
+			for i, p := range result.Pairings {
+				if got := p.First.Name + "-" + p.Second.Name; got != tt.wantPairings[i] {
+					t.Errorf("pairing %d = %s, want %s", i, got, tt.wantPairings[i])
+				}
+				if p.Score != tt.wantScores[i] || p.Decision != SPRTContinue {
+					t.Errorf("pairing %s = %+v, want %+v", tt.wantPairings[i], p.Score, tt.wantScores[i])
+				}
+				// every opening is played twice from each side in the two rounds
+				for _, opening := range []string{shogi.InitialSFEN, secondOpening} {
+					board, _, err := shogi.ParseSFEN(opening)
+					if err != nil {
+						t.Fatal(err)
+					}
+					counts := sente[tt.wantPairings[i]+" "+board.SFEN(1)]
+					if counts[p.First.Name] != 2 || counts[p.Second.Name] != 2 {
+						t.Errorf("sente of %s from %s = %v, want 2 games for each", tt.wantPairings[i], opening, counts)
+					}
+				}
+			}
+			wantGames := 8 * len(tt.wantPairings)
+			if games != wantGames {
+				t.Errorf("games = %d, want %d", games, wantGames)
+			}
+			if got := result.Standing(&tournament.Entrants[0]); got != (Score{Wins: 16}) {
+				t.Errorf("standing of player = %+v, want 16 wins", got)
+			}
+			if int(closed) != 2*wantGames {
+				t.Errorf("agents closed = %d, want %d", closed, 2*wantGames)
+			}
+		})
+	}
+}
+
+func TestTournament_Run_SPRT(t *testing.T) {
+	sprt := &SPRT{Elo0: 0, Elo1: 100}
+	// the test accepts H1 as soon as the player has won enough games
+	wins := 1
+	for sprt.Test(Score{Wins: wins}) != SPRTAccept {
+		wins++
+	}
+
+	games := 0
+	tournament := &Tournament{
+		Entrants: []Entrant{scriptedEntrant("player", playFirst, nil), scriptedEntrant("resigner", resign, nil)},
+		Rounds:   50,
+		SPRT:     sprt,
+		OnGame: func(p *Pairing, record *GameRecord) {
+			games++
+		},
+	}
+	result, err := tournament.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := result.Pairings[0]
+	if p.Decision != SPRTAccept || p.Score != (Score{Wins: wins}) || games != wins {
+		t.Errorf("pairing = %+v after %d games, want H1 accepted after %d wins", p, games, wins)
+	}
+	if wins >= 100 {
+		t.Errorf("the test needs %d wins, want fewer than the games of the tournament", wins)
+	}
+}
+
+func TestTournament_Run_Records(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tournament")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tournament := &Tournament{
+		Entrants:  []Entrant{scriptedEntrant("player", playFirst, nil), scriptedEntrant("resigner", resign, nil)},
+		RecordDir: dir,
+	}
+	if _, err := tournament.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 4 {
+		t.Fatalf("records = %v, want the KIF and CSA records of 2 games", files)
+	}
+
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{file: "0001.kif", want: []string{"先手：player", "後手：resigner", "   2 投了"}},
+		{file: "0001.csa", want: []string{"N+player", "N-resigner", "%TORYO"}},
+		{file: "0002.kif", want: []string{"先手：resigner", "後手：player", "   1 投了"}},
+		{file: "0002.csa", want: []string{"N+resigner", "N-player", "%TORYO"}},
+	}
+	for _, tt := range tests {
+		content, err := ioutil.ReadFile(filepath.Join(dir, tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(string(content), w) {
+				t.Errorf("%s doesn't contain %q:\n%s", tt.file, w, content)
+			}
+		}
+	}
+}
+
+func TestTournament_Run_Error(t *testing.T) {
+	tournament := &Tournament{
+		Entrants: []Entrant{scriptedEntrant("player", playFirst, nil), scriptedEntrant("resigner", resign, nil)},
+		Openings: []string{"9/9/9 b - 1"},
+	}
+	if _, err := tournament.Run(context.Background()); err == nil {
+		t.Error("Run() with an invalid opening error = nil")
+	}
+}
@@ -0,0 +1,250 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/k-yomo/shogi/shogi"
+	"github.com/pkg/errors"
+)
+
+const (
+	// usiTimeout is how long the engine may take to answer usi and isready, and to send bestmove after stop
+	usiTimeout = 30 * time.Second
+	// defaultUSIMoveTime is the thinking time per move given to the engine without time limit
+	defaultUSIMoveTime = time.Second
+)
+
+// USIEngine is an external engine speaking the USI protocol. It plays one game at a time.
+type USIEngine struct {
+	// MoveTime is the thinking time per move given to the engine without time limit, 1 second if it's zero
+	MoveTime time.Duration
+	// Logger logs the messages exchanged with the engine, which are discarded if it's nil
+	Logger *log.Logger
+	name   string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	// lines are the lines the engine writes, which is closed when it exits
+	lines chan string
+	// ready is whether isready has been answered since the last setoption
+	ready bool
+}
+
+// StartUSIEngine starts the engine and waits for usiok
+func StartUSIEngine(path string, args ...string) (*USIEngine, error) {
+	cmd := exec.Command(path, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "start usi engine")
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "start usi engine")
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrap(err, "start usi engine")
+	}
+	e := &USIEngine{name: path, cmd: cmd, stdin: stdin, lines: make(chan string, 64)}
+	go func() {
+		defer close(e.lines)
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			e.lines <- strings.TrimRight(scanner.Text(), "\r")
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), usiTimeout)
+	defer cancel()
+	if err := e.send("usi"); err != nil {
+		e.Close()
+		return nil, err
+	}
+	for {
+		line, err := e.readLine(ctx)
+		if err != nil {
+			e.Close()
+			return nil, err
+		}
+		if strings.HasPrefix(line, "id name ") {
+			e.name = strings.TrimPrefix(line, "id name ")
+		}
+		if line == "usiok" {
+			return e, nil
+		}
+	}
+}
+
+// Name returns the name the engine sent with id name
+func (e *USIEngine) Name() string {
+	return e.name
+}
+
+// SetOption sets the option of the engine, which takes effect from the next move
+func (e *USIEngine) SetOption(name, value string) error {
+	e.ready = false
+	return e.send(fmt.Sprintf("setoption name %s value %s", name, value))
+}
+
+// NewGame waits for the engine to be ready and sends usinewgame
+func (e *USIEngine) NewGame(ctx context.Context) error {
+	if err := e.waitReady(ctx); err != nil {
+		return err
+	}
+	return e.send("usinewgame")
+}
+
+// GameOver sends gameover with the result for the color the engine played
+func (e *USIEngine) GameOver(result shogi.Result, color shogi.Color) {
+	switch {
+	case !result.HasWinner():
+		_ = e.send("gameover draw")
+	case result.Winner == color:
+		_ = e.send("gameover win")
+	default:
+		_ = e.send("gameover lose")
+	}
+}
+
+func (e *USIEngine) ChooseMove(ctx context.Context, pos Position, timeLeft TimeLeft) (shogi.Move, bool, error) {
+	if err := e.waitReady(ctx); err != nil {
+		return shogi.Move{}, false, err
+	}
+	if err := e.send("position " + pos.USIPosition()); err != nil {
+		return shogi.Move{}, false, err
+	}
+	if err := e.send(e.goCommand(timeLeft)); err != nil {
+		return shogi.Move{}, false, err
+	}
+
+	stopped := false
+	// after stop, the engine has usiTimeout to send bestmove regardless of ctx
+	readCtx := ctx
+	for {
+		line, err := e.readLine(readCtx)
+		if err != nil && !stopped && ctx.Err() != nil {
+			if err := e.send("stop"); err != nil {
+				return shogi.Move{}, false, err
+			}
+			stopped = true
+			var cancel context.CancelFunc
+			readCtx, cancel = context.WithTimeout(context.Background(), usiTimeout)
+			defer cancel()
+			continue
+		}
+		if err != nil {
+			return shogi.Move{}, false, err
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != "bestmove" {
+			continue
+		}
+		if len(fields) < 2 {
+			return shogi.Move{}, false, errors.New("usi engine sent bestmove without a move")
+		}
+		switch fields[1] {
+		case "resign":
+			return shogi.Move{}, true, nil
+		case "win":
+			return shogi.Move{}, false, ErrDeclareWin
+		default:
+			move, err := pos.Board().ParseMove(fields[1])
+			if err != nil {
+				return shogi.Move{}, false, errors.Wrap(err, "usi engine sent an invalid bestmove")
+			}
+			return move, false, nil
+		}
+	}
+}
+
+// goCommand returns the go command for the time left in milliseconds
+func (e *USIEngine) goCommand(timeLeft TimeLeft) string {
+	tc := timeLeft.TimeControl
+	if tc.IsUnlimited() {
+		moveTime := e.MoveTime
+		if moveTime <= 0 {
+			moveTime = defaultUSIMoveTime
+		}
+		return fmt.Sprintf("go btime 0 wtime 0 byoyomi %d", moveTime.Milliseconds())
+	}
+	command := fmt.Sprintf("go btime %d wtime %d",
+		timeLeft.Remaining[shogi.Sente].Milliseconds(), timeLeft.Remaining[shogi.Gote].Milliseconds())
+	// USI can't send both byoyomi and increment
+	if tc.Byoyomi > 0 {
+		return command + fmt.Sprintf(" byoyomi %d", tc.Byoyomi.Milliseconds())
+	}
+	return command + fmt.Sprintf(" binc %d winc %d", tc.Increment.Milliseconds(), tc.Increment.Milliseconds())
+}
+
+func (e *USIEngine) waitReady(ctx context.Context) error {
+	if e.ready {
+		return nil
+	}
+	if err := e.send("isready"); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, usiTimeout)
+	defer cancel()
+	for {
+		line, err := e.readLine(ctx)
+		if err != nil {
+			return err
+		}
+		if line == "readyok" {
+			e.ready = true
+			return nil
+		}
+	}
+}
+
+// Close sends quit and waits for the engine to exit, which is killed if it doesn't exit in time
+func (e *USIEngine) Close() error {
+	_ = e.send("quit")
+	e.stdin.Close()
+	done := make(chan error, 1)
+	go func() {
+		// drain the output so the engine isn't blocked on writing
+		for range e.lines {
+		}
+		done <- e.cmd.Wait()
+	}()
+	select {
+	case err := <-done:
+		return errors.Wrap(err, "usi engine")
+	case <-time.After(usiTimeout):
+		e.cmd.Process.Kill()
+		return errors.New("usi engine didn't quit")
+	}
+}
+
+func (e *USIEngine) send(line string) error {
+	e.logf("> %s", line)
+	if _, err := io.WriteString(e.stdin, line+"\n"); err != nil {
+		return errors.Wrap(err, "send to usi engine")
+	}
+	return nil
+}
+
+func (e *USIEngine) readLine(ctx context.Context) (string, error) {
+	select {
+	case line, ok := <-e.lines:
+		if !ok {
+			return "", errors.New("usi engine exited")
+		}
+		e.logf("< %s", line)
+		return line, nil
+	case <-ctx.Done():
+		return "", errors.Wrap(ctx.Err(), "read from usi engine")
+	}
+}
+
+func (e *USIEngine) logf(format string, args ...interface{}) {
+	if e.Logger != nil {
+		e.Logger.Printf(format, args...)
+	}
+}
@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k-yomo/shogi/shogi"
+)
+
+type nopWriteCloser struct{}
+
+func (nopWriteCloser) Write(p []byte) (int, error) { return len(p), nil }
+func (nopWriteCloser) Close() error                { return nil }
+
+// newScriptedUSIEngine returns the ready engine writing the lines, whose input is discarded
+func newScriptedUSIEngine(lines ...string) *USIEngine {
+	e := &USIEngine{stdin: nopWriteCloser{}, lines: make(chan string, len(lines)), ready: true}
+	for _, line := range lines {
+		e.lines <- line
+	}
+	close(e.lines)
+	return e
+}
+
+func TestUSIEngine_ChooseMove(t *testing.T) {
+	tests := []struct {
+		name       string
+		lines      []string
+		wantMove   string
+		wantResign bool
+		wantErr    error
+		wantAnyErr bool
+	}{
+		{name: "move", lines: []string{"info depth 1 score cp 50 pv 7g7f", "bestmove 7g7f ponder 3c3d"}, wantMove: "7g7f"},
+		{name: "resign", lines: []string{"bestmove resign"}, wantResign: true},
+		{name: "win", lines: []string{"bestmove win"}, wantErr: ErrDeclareWin},
+		{name: "bestmove without a move", lines: []string{"bestmove"}, wantAnyErr: true},
+		{name: "bestmove with a trailing space", lines: []string{"bestmove "}, wantAnyErr: true},
+		{name: "invalid move", lines: []string{"bestmove 7z7f"}, wantAnyErr: true},
+		{name: "exited", lines: []string{"info string bye"}, wantAnyErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			e := newScriptedUSIEngine(tt.lines...)
+			move, resign, err := e.ChooseMove(context.Background(), NewPosition(shogi.NewGame()), TimeLeft{})
+			if tt.wantAnyErr || tt.wantErr != nil {
+				if err == nil || (tt.wantErr != nil && err != tt.wantErr) {
+					t.Errorf("ChooseMove() error = %v, want an error %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ChooseMove() error = %v", err)
+			}
+			if resign != tt.wantResign {
+				t.Errorf("ChooseMove() resign = %v, want %v", resign, tt.wantResign)
+			}
+			if !resign && move.String() != tt.wantMove {
+				t.Errorf("ChooseMove() = %s, want %s", move.String(), tt.wantMove)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+// Package book implements opening books, which are read and written in the text format of YaneuraOu.
+package book
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/k-yomo/shogi/shogi"
+	"github.com/pkg/errors"
+)
+
+// header is the first line of the books in the YaneuraOu format
+const header = "#YANEURAOU-DB2016 1.00"
+
+// defaultMaxPly is the number of moves added from each game when BuildOptions.MaxPly is zero
+const defaultMaxPly = 30
+
+// Entry is a candidate move of a position in the book
+type Entry struct {
+	// Move is in USI notation, e.g. "7g7f"
+	Move string
+	// Ponder is the expected reply in USI notation, which is empty if there is none
+	Ponder string
+	// Score is the evaluation from the side to move's perspective, and Depth is the depth of the search which gave it
+	Score int
+	Depth int
+	// Count is the weight of the move when it's chosen at random, usually the number of times it was played
+	Count int
+}
+
+// Book is an opening book keyed by position, whose entries are ordered by Count
+type Book struct {
+	positions map[string][]Entry
+}
+
+func New() *Book {
+	return &Book{positions: map[string][]Entry{}}
+}
+
+// Load reads the book in the YaneuraOu format, where each "sfen <position>" line is followed by the lines of
+// "<move> <ponder> <score> <depth> <count>" for the position. The ponder is "none" if there is none.
+func Load(r io.Reader) (*Book, error) {
+	b := New()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var key string
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if strings.HasPrefix(line, "sfen ") {
+			board, _, err := shogi.ParseSFEN(line)
+			if err != nil {
+				return nil, errors.Wrapf(err, "line %d", lineNumber)
+			}
+			key = keyOf(board)
+			continue
+		}
+		if key == "" {
+			return nil, errors.Errorf("line %d: move before sfen: %q", lineNumber, line)
+		}
+		entry, err := parseEntry(line)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", lineNumber)
+		}
+		b.add(key, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "read book")
+	}
+	return b, nil
+}
+
+// LoadFile reads the book file in the YaneuraOu format
+func LoadFile(path string) (*Book, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "open book")
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+func parseEntry(line string) (Entry, error) {
+	fields := strings.Fields(line)
+	entry := Entry{Move: fields[0]}
+	if len(fields) > 1 && fields[1] != "none" {
+		entry.Ponder = fields[1]
+	}
+	for i, n := range []*int{&entry.Score, &entry.Depth, &entry.Count} {
+		if len(fields) <= i+2 {
+			break
+		}
+		v, err := strconv.Atoi(fields[i+2])
+		if err != nil {
+			return Entry{}, errors.Errorf("invalid book move: %q", line)
+		}
+		*n = v
+	}
+	return entry, nil
+}
+
+// Write writes the book in the YaneuraOu format with the positions sorted
+func (b *Book) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, header)
+	keys := make([]string, 0, len(b.positions))
+	for key := range b.positions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		// the ply isn't a part of the key, and YaneuraOu ignores it
+		fmt.Fprintf(bw, "sfen %s 1\n", key)
+		for _, e := range b.positions[key] {
+			ponder := e.Ponder
+			if ponder == "" {
+				ponder = "none"
+			}
+			fmt.Fprintf(bw, "%s %s %d %d %d\n", e.Move, ponder, e.Score, e.Depth, e.Count)
+		}
+	}
+	return errors.Wrap(bw.Flush(), "write book")
+}
+
+// Len returns the number of positions in the book
+func (b *Book) Len() int {
+	return len(b.positions)
+}
+
+// Entries returns the candidate moves of the position ordered by Count, which are nil if it isn't in the book
+func (b *Book) Entries(board *shogi.BitboardBoard) []Entry {
+	return append([]Entry(nil), b.positions[keyOf(board)]...)
+}
+
+// Add adds the entry to the position. The count is added to the existing entry of the move, whose score and depth are
+// replaced if the entry has the deeper depth.
+func (b *Book) Add(board *shogi.BitboardBoard, entry Entry) {
+	b.add(keyOf(board), entry)
+}
+
+func (b *Book) add(key string, entry Entry) {
+	entries := b.positions[key]
+	found := false
+	for i := range entries {
+		e := &entries[i]
+		if e.Move != entry.Move {
+			continue
+		}
+		e.Count += entry.Count
+		if entry.Depth > e.Depth {
+			e.Score, e.Depth = entry.Score, entry.Depth
+		}
+		if e.Ponder == "" {
+			e.Ponder = entry.Ponder
+		}
+		found = true
+		break
+	}
+	if !found {
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Count > entries[j].Count
+	})
+	b.positions[key] = entries
+}
+
+// Choose chooses a legal move of the position at random weighted by Count, where a count below zero weighs nothing,
+// or uniformly if no move has a positive count. ok is false if the position has no legal move in the book.
+func (b *Book) Choose(board *shogi.BitboardBoard, rnd *rand.Rand) (move shogi.Move, ok bool) {
+	var moves []shogi.Move
+	var weights []int
+	total := 0
+	for _, e := range b.positions[keyOf(board)] {
+		m, err := board.ParseMove(e.Move)
+		if err != nil || board.ValidateMove(m) != nil {
+			continue
+		}
+		weight := e.Count
+		if weight < 0 {
+			weight = 0
+		}
+		moves = append(moves, m)
+		weights = append(weights, weight)
+		total += weight
+	}
+	if len(moves) == 0 {
+		return shogi.Move{}, false
+	}
+	if total == 0 {
+		return moves[rnd.Intn(len(moves))], true
+	}
+	n := rnd.Intn(total)
+	for i, w := range weights {
+		if n < w {
+			return moves[i], true
+		}
+		n -= w
+	}
+	return moves[len(moves)-1], true
+}
+
+// BuildOptions configures adding games to the book
+type BuildOptions struct {
+	// MaxPly is the number of moves added from the start of each game, 30 if it's zero
+	MaxPly int
+	// WinnerOnly adds only the moves of the winner, skipping the games without a winner
+	WinnerOnly bool
+}
+
+// AddGame adds the moves of the game with count 1, such as the ones parsed from KIF or CSA records
+func (b *Book) AddGame(game *shogi.Game, opts BuildOptions) {
+	maxPly := opts.MaxPly
+	if maxPly <= 0 {
+		maxPly = defaultMaxPly
+	}
+	result, over := game.Result()
+	if opts.WinnerOnly && (!over || !result.HasWinner()) {
+		return
+	}
+	board := game.InitialBoard()
+	history := game.History()
+	for i, m := range history {
+		if i >= maxPly {
+			break
+		}
+		if !opts.WinnerOnly || board.SideToMove() == result.Winner {
+			entry := Entry{Move: m.String(), Count: 1}
+			if i+1 < len(history) {
+				entry.Ponder = history[i+1].String()
+			}
+			b.Add(board, entry)
+		}
+		board.DoMove(m)
+	}
+}
+
+// keyOf returns the key of the position, which is its SFEN without the move number
+func keyOf(board *shogi.BitboardBoard) string {
+	sfen := board.SFEN(1)
+	return sfen[:strings.LastIndexByte(sfen, ' ')]
+}
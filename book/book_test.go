@@ -0,0 +1,262 @@
+package book
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/k-yomo/shogi/shogi"
+)
+
+// initialKey is the key of the initial position in the book
+const initialKey = "lnsgkgsnl/1r5b1/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL b -"
+
+// board returns the board after the moves in USI from the initial position
+func board(t *testing.T, moves ...string) *shogi.BitboardBoard {
+	t.Helper()
+	b := shogi.NewBitboardBoard()
+	for _, usi := range moves {
+		m, err := b.ParseMove(usi)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.DoMove(m)
+	}
+	return b
+}
+
+func TestLoad_Write(t *testing.T) {
+	input := strings.Join([]string{
+		"#YANEURAOU-DB2016 1.00",
+		"// a comment",
+		"sfen lnsgkgsnl/1r5b1/ppppppppp/9/9/2P6/PP1PPPPPP/1B5R1/LNSGKGSNL w - 2",
+		"3c3d 2g2f 10 8 3",
+		"8c8d none -5 6 5",
+		"",
+		"sfen " + shogi.InitialSFEN,
+		"2g2f 8c8d 30 10 2",
+		"7g7f 3c3d 40 12 7",
+		"5g5f",
+	}, "\n")
+	b, err := Load(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", b.Len())
+	}
+	want := []Entry{
+		{Move: "7g7f", Ponder: "3c3d", Score: 40, Depth: 12, Count: 7},
+		{Move: "2g2f", Ponder: "8c8d", Score: 30, Depth: 10, Count: 2},
+		{Move: "5g5f"},
+	}
+	if got := b.Entries(board(t)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Entries() = %+v, want %+v", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := b.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	// the positions are sorted, the entries are ordered by count and the ply is always 1
+	written := strings.Join([]string{
+		"#YANEURAOU-DB2016 1.00",
+		"sfen lnsgkgsnl/1r5b1/ppppppppp/9/9/2P6/PP1PPPPPP/1B5R1/LNSGKGSNL w - 1",
+		"8c8d none -5 6 5",
+		"3c3d 2g2f 10 8 3",
+		"sfen " + initialKey + " 1",
+		"7g7f 3c3d 40 12 7",
+		"2g2f 8c8d 30 10 2",
+		"5g5f none 0 0 0",
+		"",
+	}, "\n")
+	if buf.String() != written {
+		t.Errorf("Write() = \n%s\nwant\n%s", buf.String(), written)
+	}
+
+	reloaded, err := Load(strings.NewReader(written))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rewritten bytes.Buffer
+	if err := reloaded.Write(&rewritten); err != nil {
+		t.Fatal(err)
+	}
+	if rewritten.String() != written {
+		t.Errorf("Write() after Load() = \n%s\nwant\n%s", rewritten.String(), written)
+	}
+}
+
+func TestLoad_Error(t *testing.T) {
+	for name, input := range map[string]string{
+		"move before sfen": "7g7f none 0 0 1",
+		"invalid sfen":     "sfen 9/9/9 b - 1\n7g7f",
+		"invalid number":   "sfen " + shogi.InitialSFEN + "\n7g7f none x 0 1",
+	} {
+		if _, err := Load(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: Load() error = nil", name)
+		}
+	}
+}
+
+func TestBook_Add(t *testing.T) {
+	b := New()
+	initial := board(t)
+	b.Add(initial, Entry{Move: "2g2f", Score: 10, Depth: 4, Count: 1})
+	b.Add(initial, Entry{Move: "7g7f", Ponder: "3c3d", Score: 20, Depth: 8, Count: 1})
+	// the deeper entry replaces the score, and the shallower one only adds the count
+	b.Add(initial, Entry{Move: "2g2f", Ponder: "8c8d", Score: 30, Depth: 10, Count: 1})
+	b.Add(initial, Entry{Move: "2g2f", Score: -100, Depth: 2, Count: 1})
+	want := []Entry{
+		{Move: "2g2f", Ponder: "8c8d", Score: 30, Depth: 10, Count: 3},
+		{Move: "7g7f", Ponder: "3c3d", Score: 20, Depth: 8, Count: 1},
+	}
+	if got := b.Entries(initial); !reflect.DeepEqual(got, want) {
+		t.Errorf("Entries() = %+v, want %+v", got, want)
+	}
+	if got := b.Entries(board(t, "7g7f")); got != nil {
+		t.Errorf("Entries() of another position = %+v, want nil", got)
+	}
+
+	// the positions reached by different orders of moves share the entries
+	b.Add(board(t, "7g7f", "3c3d", "2g2f"), Entry{Move: "8c8d", Count: 1})
+	if got := b.Entries(board(t, "2g2f", "3c3d", "7g7f")); len(got) != 1 || got[0].Move != "8c8d" {
+		t.Errorf("Entries() of the transposition = %+v, want 8c8d", got)
+	}
+}
+
+func TestBook_Choose(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []Entry
+		// want is the ratio of the times each move is chosen
+		want map[string]float64
+	}{
+		{
+			name:    "weighted",
+			entries: []Entry{{Move: "7g7f", Count: 3}, {Move: "2g2f", Count: 1}},
+			want:    map[string]float64{"7g7f": 0.75, "2g2f": 0.25},
+		},
+		{
+			name:    "illegal moves are skipped",
+			entries: []Entry{{Move: "5e5d", Count: 100}, {Move: "7g7f", Count: 1}},
+			want:    map[string]float64{"7g7f": 1},
+		},
+		{
+			name:    "negative count weighs nothing",
+			entries: []Entry{{Move: "7g7f", Count: -5}, {Move: "2g2f", Count: 1}},
+			want:    map[string]float64{"2g2f": 1},
+		},
+		{
+			name:    "uniform without positive counts",
+			entries: []Entry{{Move: "7g7f", Count: -5}, {Move: "2g2f", Count: 0}},
+			want:    map[string]float64{"7g7f": 0.5, "2g2f": 0.5},
+		},
+	}
+	const draws = 4000
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New()
+			initial := board(t)
+			for _, e := range tt.entries {
+				b.Add(initial, e)
+			}
+			rnd := rand.New(rand.NewSource(1))
+			counts := map[string]int{}
+			for i := 0; i < draws; i++ {
+				m, ok := b.Choose(initial, rnd)
+				if !ok {
+					t.Fatal("Choose() found no move")
+				}
+				counts[m.String()]++
+			}
+			for move, count := range counts {
+				ratio := float64(count) / draws
+				if want := tt.want[move]; ratio < want-0.05 || ratio > want+0.05 {
+					t.Errorf("%s is chosen %.3f of the time, want %.2f", move, ratio, want)
+				}
+			}
+		})
+	}
+
+	b := New()
+	b.Add(board(t), Entry{Move: "5e5d", Count: 1})
+	for name, position := range map[string]*shogi.BitboardBoard{"no legal move": board(t), "not in the book": board(t, "7g7f")} {
+		if m, ok := b.Choose(position, rand.New(rand.NewSource(1))); ok {
+			t.Errorf("%s: Choose() = %s, want none", name, m)
+		}
+	}
+}
+
+func TestBook_AddGame(t *testing.T) {
+	game := shogi.NewGame()
+	for _, usi := range []string{"7g7f", "3c3d", "2g2f", "4c4d"} {
+		m, err := game.Board().ParseMove(usi)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := game.ApplyMove(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := game.Resign(shogi.Sente); err != nil {
+		t.Fatal(err)
+	}
+	inProgress := shogi.NewGame()
+	m, _ := inProgress.Board().ParseMove("7g7f")
+	if err := inProgress.ApplyMove(m); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		game  *shogi.Game
+		opts  BuildOptions
+		moves []string
+		// want are the entries of the positions after the moves, which are played from the initial position
+		want [][]Entry
+	}{
+		{
+			name: "every move",
+			game: game,
+			want: [][]Entry{
+				{{Move: "7g7f", Ponder: "3c3d", Count: 1}},
+				{{Move: "3c3d", Ponder: "2g2f", Count: 1}},
+				{{Move: "2g2f", Ponder: "4c4d", Count: 1}},
+				{{Move: "4c4d", Count: 1}},
+			},
+		},
+		{
+			name: "max ply",
+			game: game,
+			opts: BuildOptions{MaxPly: 2},
+			want: [][]Entry{{{Move: "7g7f", Ponder: "3c3d", Count: 1}}, {{Move: "3c3d", Ponder: "2g2f", Count: 1}}, nil, nil},
+		},
+		{
+			name: "winner only",
+			game: game,
+			opts: BuildOptions{WinnerOnly: true},
+			want: [][]Entry{nil, {{Move: "3c3d", Ponder: "2g2f", Count: 1}}, nil, {{Move: "4c4d", Count: 1}}},
+		},
+		{
+			name: "winner only skips the game in progress",
+			game: inProgress,
+			opts: BuildOptions{WinnerOnly: true},
+			want: [][]Entry{nil},
+		},
+	}
+	moves := []string{"7g7f", "3c3d", "2g2f", "4c4d"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New()
+			b.AddGame(tt.game, tt.opts)
+			for i, want := range tt.want {
+				if got := b.Entries(board(t, moves[:i]...)); !reflect.DeepEqual(got, want) {
+					t.Errorf("Entries() after %d moves = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+// Command book builds an opening book in the YaneuraOu format from KIF and CSA records.
+// The records are given as arguments, and the files ending with .csa are read as CSA and the others as KIF.
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/k-yomo/shogi/book"
+	"github.com/k-yomo/shogi/shogi"
+)
+
+func main() {
+	output := flag.String("o", "book.db", "file to write the book to")
+	merge := flag.String("merge", "", "book to add the records to")
+	maxPly := flag.Int("max-ply", 30, "number of moves added from the start of each game")
+	winnerOnly := flag.Bool("winner-only", false, "add only the moves of the winners")
+	flag.Parse()
+
+	b := book.New()
+	if *merge != "" {
+		var err error
+		if b, err = book.LoadFile(*merge); err != nil {
+			log.Fatal(err)
+		}
+	}
+	opts := book.BuildOptions{MaxPly: *maxPly, WinnerOnly: *winnerOnly}
+	games := 0
+	for _, path := range flag.Args() {
+		game, err := readRecord(path)
+		if err != nil {
+			// a broken record in a collection isn't fatal
+			log.Printf("%s: %v", path, err)
+			continue
+		}
+		b.AddGame(game, opts)
+		games++
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := b.Write(f); err != nil {
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("added %d games, %d positions", games, b.Len())
+}
+
+func readRecord(path string) (*shogi.Game, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".csa") {
+		return shogi.ParseCSA(string(data))
+	}
+	return shogi.ParseKIF(string(data))
+}
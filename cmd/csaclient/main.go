@@ -0,0 +1,44 @@
+// Command csaclient plays games on a CSA server with the built-in engine.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/k-yomo/shogi/csa"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:4081", "address of the CSA server")
+	name := flag.String("name", "shogi", "login name")
+	password := flag.String("password", "shogi", "login password")
+	depth := flag.Int("depth", 4, "maximum search depth")
+	threads := flag.Int("threads", 1, "number of search threads")
+	maxTime := flag.Duration("max-time", 10*time.Second, "maximum thinking time per move")
+	games := flag.Int("games", 1, "number of games to play")
+	verbose := flag.Bool("v", false, "log the messages exchanged with the server")
+	flag.Parse()
+
+	chooser := &csa.EngineChooser{Depth: *depth, Threads: *threads, MaxTime: *maxTime}
+	for i := 0; i < *games; i++ {
+		c, err := csa.Dial(*addr, *name, *password)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *verbose {
+			c.Logger = log.New(os.Stderr, "", log.LstdFlags)
+		}
+		result, err := c.PlayGame(context.Background(), chooser)
+		if err != nil {
+			c.Close()
+			log.Fatal(err)
+		}
+		log.Printf("%s: %s %s", result.Summary.GameID, result.Result, result.Reason)
+		if err := c.Logout(); err != nil {
+			log.Print(err)
+		}
+	}
+}
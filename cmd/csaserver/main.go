@@ -0,0 +1,41 @@
+// Command csaserver runs a local CSA server for engine-vs-engine matches.
+// Clients are paired in the order they log in, and the CSA records are written to the record directory.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/k-yomo/shogi/csa"
+)
+
+func main() {
+	addr := flag.String("addr", ":4081", "address to listen on")
+	totalTime := flag.Duration("total", 10*time.Minute, "main time of each player")
+	byoyomi := flag.Duration("byoyomi", 10*time.Second, "time for each move after the main time runs out")
+	increment := flag.Duration("increment", 0, "time added after each move (Fischer)")
+	maxMoves := flag.Int("max-moves", 256, "number of moves after which the game is drawn")
+	records := flag.String("records", "", "directory to write the CSA records to")
+	flag.Parse()
+
+	if *records != "" {
+		if err := os.MkdirAll(*records, 0755); err != nil {
+			log.Fatal(err)
+		}
+	}
+	s := &csa.Server{
+		Addr: *addr,
+		TimeControl: csa.TimeControl{
+			TotalTime: totalTime.Truncate(time.Second),
+			Byoyomi:   byoyomi.Truncate(time.Second),
+			Increment: increment.Truncate(time.Second),
+		},
+		MaxMoves:  *maxMoves,
+		RecordDir: *records,
+		Logger:    log.New(os.Stderr, "", log.LstdFlags),
+	}
+	log.Printf("listening on %s", *addr)
+	log.Fatal(s.ListenAndServe())
+}
@@ -0,0 +1,90 @@
+// Command match plays games between two agents alternating colors and reports the result.
+// An agent is "random", "engine:<depth>", "mcts" or "usi:<path>", and the KIF records are written to the record directory.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/k-yomo/shogi/agent"
+	"github.com/k-yomo/shogi/book"
+	"github.com/k-yomo/shogi/shogi"
+)
+
+func main() {
+	first := flag.String("a", "engine:4", "first agent, which plays sente in odd games")
+	second := flag.String("b", "random", "second agent")
+	games := flag.Int("games", 2, "number of games to play")
+	mainTime := flag.Duration("total", 0, "main time of each player, no time limit if every time setting is zero")
+	byoyomi := flag.Duration("byoyomi", time.Second, "time for each move after the main time runs out")
+	increment := flag.Duration("increment", 0, "time added after each move (Fischer)")
+	maxMoves := flag.Int("max-moves", 256, "number of moves after which the game is drawn")
+	sfen := flag.String("sfen", "", "position the games start from")
+	records := flag.String("records", "", "directory to write the KIF records to")
+	bookPath := flag.String("book", "", "opening book in the YaneuraOu format used by both agents")
+	flag.Parse()
+
+	if *records != "" {
+		if err := os.MkdirAll(*records, 0755); err != nil {
+			log.Fatal(err)
+		}
+	}
+	var openingBook *book.Book
+	if *bookPath != "" {
+		var err error
+		if openingBook, err = book.LoadFile(*bookPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	var agents [2]agent.Agent
+	for i, spec := range []string{*first, *second} {
+		a, err := agent.Parse(spec)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if c, ok := a.(io.Closer); ok {
+			defer c.Close()
+		}
+		if openingBook != nil {
+			a = agent.WithBook(a, openingBook, time.Now().UnixNano()+int64(i))
+		}
+		agents[i] = a
+	}
+
+	m := &agent.Match{
+		Agents: agents,
+		Games:  *games,
+		TimeControl: shogi.TimeControl{
+			MainTime:  *mainTime,
+			Byoyomi:   *byoyomi,
+			Increment: *increment,
+		},
+		MaxMoves: *maxMoves,
+		SFEN:     *sfen,
+	}
+	played := 0
+	m.OnGame = func(record *agent.GameRecord) {
+		played++
+		n := len(record.Game.History())
+		log.Printf("game %d, %s vs %s: %s in %d moves", played, record.Sente.Name(), record.Gote.Name(), record.Result, n)
+		if *records == "" {
+			return
+		}
+		path := filepath.Join(*records, fmt.Sprintf("%03d.kif", played))
+		if err := ioutil.WriteFile(path, []byte(record.KIF()), 0644); err != nil {
+			log.Print(err)
+		}
+	}
+	result, err := m.Run(context.Background())
+	fmt.Printf("%s vs %s: %s\n", agents[0].Name(), agents[1].Name(), result)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,92 @@
+// Command perft counts the leaf nodes of the legal move tree to verify move generation.
+//
+// Usage:
+//
+//	perft -depth 3 [-sfen SFEN] [-divide]
+//	perft -verify shogi/testdata/perft.txt [-max-nodes N]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/k-yomo/shogi/shogi"
+	"github.com/pkg/errors"
+)
+
+func main() {
+	sfen := flag.String("sfen", shogi.InitialSFEN, "position to count from")
+	depth := flag.Int("depth", 3, "depth of the move tree")
+	divide := flag.Bool("divide", false, "list counts per root move")
+	verify := flag.String("verify", "", "file of known perft results to verify against")
+	maxNodes := flag.Uint64("max-nodes", 1000000, "skip known results with more nodes than this when verifying")
+	flag.Parse()
+
+	var err error
+	if *verify != "" {
+		err = verifyFile(*verify, *maxNodes)
+	} else {
+		err = run(*sfen, *depth, *divide)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(sfen string, depth int, divide bool) error {
+	game, err := shogi.NewGameFromSFEN(sfen)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	var nodes uint64
+	if divide {
+		for _, d := range game.PerftDivide(depth) {
+			fmt.Printf("%s: %d\n", d.Move, d.Nodes)
+			nodes += d.Nodes
+		}
+	} else {
+		nodes = game.Perft(depth)
+	}
+	elapsed := time.Since(start)
+	fmt.Printf("nodes: %d, time: %v, nps: %.0f\n", nodes, elapsed, float64(nodes)/elapsed.Seconds())
+	return nil
+}
+
+// verifyFile checks the known results in the file, see shogi.ReadPerftResults for the format
+func verifyFile(path string, maxNodes uint64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	results, err := shogi.ReadPerftResults(f)
+	if err != nil {
+		return errors.Wrap(err, path)
+	}
+
+	failed := false
+	for _, r := range results {
+		if r.Nodes > maxNodes {
+			continue
+		}
+		game, err := shogi.NewGameFromSFEN(r.SFEN)
+		if err != nil {
+			return errors.Wrapf(err, "%s:%d", path, r.Line)
+		}
+		actual := game.Perft(r.Depth)
+		status := "ok"
+		if actual != r.Nodes {
+			status = "FAIL"
+			failed = true
+		}
+		fmt.Printf("%-4s %s depth %d: expected %d, got %d\n", status, r.SFEN, r.Depth, r.Nodes, actual)
+	}
+	if failed {
+		return errors.New("perft results don't match")
+	}
+	return nil
+}
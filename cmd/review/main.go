@@ -0,0 +1,97 @@
+// Command review analyzes every move of a game record, reports the inaccuracies, mistakes and blunders of both players
+// and writes the record annotated with the better alternatives as KIF comments.
+// The record is given as an argument, and the file ending with .csa is read as CSA and the others as KIF.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/k-yomo/shogi/review"
+	"github.com/k-yomo/shogi/shogi"
+)
+
+func main() {
+	output := flag.String("o", "", "file to write the annotated KIF record to, the standard output if it's empty")
+	depth := flag.Int("depth", 4, "search depth of the analysis of each move")
+	moveTime := flag.Duration("time", 10*time.Second, "maximum time of analyzing each move")
+	inaccuracy := flag.Int("inaccuracy", review.DefaultThresholds.Inaccuracy, "least score loss of an inaccuracy")
+	mistake := flag.Int("mistake", review.DefaultThresholds.Mistake, "least score loss of a mistake")
+	blunder := flag.Int("blunder", review.DefaultThresholds.Blunder, "least score loss of a blunder")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		log.Fatal("usage: review [flags] <record>")
+	}
+	path := flag.Arg(0)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var game *shogi.Game
+	isCSA := strings.EqualFold(filepath.Ext(path), ".csa")
+	if isCSA {
+		game, err = shogi.ParseCSA(string(data))
+	} else {
+		game, err = shogi.ParseKIF(string(data))
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	opts := review.Options{
+		Depth:      *depth,
+		MoveTime:   *moveTime,
+		Thresholds: review.Thresholds{Inaccuracy: *inaccuracy, Mistake: *mistake, Blunder: *blunder},
+		OnMove: func(m review.MoveReview) {
+			if m.Class >= review.ClassInaccuracy {
+				log.Printf("%d %s: %s (%d), best %s (%d)", m.Ply+1, m.Move, m.Class, m.Score, m.Best, m.BestScore)
+			}
+		},
+	}
+	r, err := review.Game(context.Background(), game, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, c := range []shogi.Color{shogi.Sente, shogi.Gote} {
+		log.Printf("%s: %d inaccuracies, %d mistakes, %d blunders", c,
+			r.Count(c, review.ClassInaccuracy), r.Count(c, review.ClassMistake), r.Count(c, review.ClassBlunder))
+	}
+
+	info := shogi.KIFInfo{}
+	info.SenteName, info.GoteName = playerNames(string(data), isCSA)
+	kif := r.KIF(game, info)
+	if *output == "" {
+		fmt.Print(kif)
+		return
+	}
+	if err := ioutil.WriteFile(*output, []byte(kif), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// playerNames returns the names of the players written in the header of the record
+func playerNames(record string, isCSA bool) (sente, gote string) {
+	sentePrefix, gotePrefix := "先手：", "後手："
+	if isCSA {
+		sentePrefix, gotePrefix = "N+", "N-"
+	}
+	scanner := bufio.NewScanner(strings.NewReader(record))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, sentePrefix):
+			sente = strings.TrimPrefix(line, sentePrefix)
+		case strings.HasPrefix(line, gotePrefix):
+			gote = strings.TrimPrefix(line, gotePrefix)
+		}
+	}
+	return sente, gote
+}
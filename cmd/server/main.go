@@ -0,0 +1,18 @@
+// Command server serves the HTTP/JSON game API of the server package, keeping games in memory.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/k-yomo/shogi/server"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	flag.Parse()
+
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, server.New(server.NewMemoryStore())))
+}
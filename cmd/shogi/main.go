@@ -0,0 +1,400 @@
+// Command shogi plays shogi interactively on the command line.
+//
+// Moves can be typed in Japanese notation (７六歩, 同歩, ５五角打, ５八金右), USI (7g7f, P*5e, 8h2b+)
+// or as a pair of squares (77 76, 7776+). Type "help" for the other commands.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k-yomo/shogi/render"
+	"github.com/k-yomo/shogi/shogi"
+	"github.com/pkg/errors"
+)
+
+const helpText = `commands:
+  <move>       play a move, e.g. ７六歩, 同歩, ５五角打, 7g7f, P*5e, 77 76, 2822+
+  moves        list legal moves
+  hint [n]     show the n best moves with their scores and expected sequences (3 by default)
+  show <sq>    highlight the squares the piece on the square can move to, e.g. show 77
+  undo         take back the last move (and the engine's reply)
+  resign       resign the game
+  declare      declare the win by the entering king rule (27 points), which loses if the conditions aren't met
+  save <file>  save the game record in KIF, including the result if the game is over
+  load <file>  load a game saved in KIF or as a USI position
+  help         show this help
+  quit         quit without finishing the game`
+
+var (
+	usiMovePattern      = regexp.MustCompile(`^([1-9][a-i][1-9][a-i]\+?|[PLNSGBR]\*[1-9][a-i])$`)
+	squarePairPattern   = regexp.MustCompile(`^([1-9])([1-9])[\s-]*([1-9])([1-9])(\+|=|成|不成)?$`)
+	squarePattern       = regexp.MustCompile(`^([1-9])([1-9])$`)
+	sourceSquarePattern = regexp.MustCompile(`\(\d\d\)$`)
+	fullWidthReplacer   = strings.NewReplacer("１", "1", "２", "2", "３", "3", "４", "4", "５", "5", "６", "6", "７", "7", "８", "8", "９", "9", "＋", "+", "＊", "*")
+)
+
+type cli struct {
+	in   *bufio.Scanner
+	out  io.Writer
+	game *shogi.Game
+	// engines is whether the color is played by the built-in engine
+	engines     [2]bool
+	searchDepth int
+	searchTime  time.Duration
+	renderOpts  render.TerminalOptions
+	// resultShown is whether the result of the finished game has been printed, which is reset when the game is resumed
+	resultShown bool
+}
+
+func main() {
+	sente := flag.String("sente", "human", "who plays sente: human or engine")
+	gote := flag.String("gote", "engine", "who plays gote: human or engine")
+	depth := flag.Int("depth", 4, "search depth of the engine")
+	searchTime := flag.Duration("time", 10*time.Second, "maximum thinking time of the engine per move")
+	load := flag.String("load", "", "saved game to resume")
+	flag.Parse()
+
+	c := &cli{
+		in:          bufio.NewScanner(os.Stdin),
+		out:         os.Stdout,
+		game:        shogi.NewGame(),
+		searchDepth: *depth,
+		searchTime:  *searchTime,
+		renderOpts:  render.TerminalOptionsFor(os.Stdout),
+	}
+	for i, player := range []string{*sente, *gote} {
+		switch player {
+		case "human":
+		case "engine":
+			c.engines[i] = true
+		default:
+			fmt.Fprintf(os.Stderr, "unknown player %q, it must be human or engine\n", player)
+			os.Exit(2)
+		}
+	}
+	if *load != "" {
+		if err := c.load(*load); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	c.run()
+}
+
+func (c *cli) run() {
+	c.printSituation()
+	for {
+		if result, ok := c.game.Result(); ok {
+			if !c.resultShown {
+				fmt.Fprintf(c.out, "まで%d手で%s\n", c.game.MoveNumber()-1, result)
+				c.resultShown = true
+			}
+			if c.engines[shogi.Sente] && c.engines[shogi.Gote] {
+				return
+			}
+		} else {
+			c.resultShown = false
+			if c.engines[c.game.SideToMove()] {
+				c.playEngineMove()
+				continue
+			}
+		}
+
+		input, ok := c.prompt(fmt.Sprintf("%s> ", c.game.CurrentPlayerName()))
+		if !ok {
+			return
+		}
+		if input == "" {
+			continue
+		}
+		fields := strings.Fields(input)
+		switch fields[0] {
+		case "help":
+			fmt.Fprintln(c.out, helpText)
+		case "moves":
+			c.printLegalMoves()
+		case "hint":
+			if err := c.printHint(fields[1:]); err != nil {
+				fmt.Fprintln(c.out, err)
+			}
+		case "show":
+			pos, err := parseSquare(fields[1:])
+			if err != nil {
+				fmt.Fprintln(c.out, err)
+				continue
+			}
+			opts := c.renderOpts
+			opts.Selected = pos
+			fmt.Fprint(c.out, render.Terminal(c.game, opts))
+		case "undo":
+			if err := c.undo(); err != nil {
+				fmt.Fprintln(c.out, err)
+				continue
+			}
+			c.printSituation()
+		case "resign":
+			if err := c.game.Resign(c.game.SideToMove()); err != nil {
+				fmt.Fprintln(c.out, err)
+				continue
+			}
+			result, _ := c.game.Result()
+			fmt.Fprintf(c.out, "まで%d手で%s\n", c.game.MoveNumber()-1, result)
+			return
+		case "declare":
+			if _, err := c.game.DeclareWin(); err != nil {
+				fmt.Fprintln(c.out, err)
+			}
+		case "save":
+			if len(fields) != 2 {
+				fmt.Fprintln(c.out, "usage: save <file>")
+				continue
+			}
+			if err := ioutil.WriteFile(fields[1], []byte(c.game.KIF(c.kifInfo())), 0644); err != nil {
+				fmt.Fprintln(c.out, err)
+			}
+		case "load":
+			if len(fields) != 2 {
+				fmt.Fprintln(c.out, "usage: load <file>")
+				continue
+			}
+			if err := c.load(fields[1]); err != nil {
+				fmt.Fprintln(c.out, err)
+				continue
+			}
+			c.printSituation()
+		case "quit", "exit":
+			return
+		default:
+			if err := c.playHumanMove(input); err != nil {
+				fmt.Fprintln(c.out, err)
+				continue
+			}
+			c.printSituation()
+		}
+	}
+}
+
+func (c *cli) prompt(message string) (string, bool) {
+	fmt.Fprint(c.out, message)
+	if !c.in.Scan() {
+		return "", false
+	}
+	return strings.TrimSpace(c.in.Text()), true
+}
+
+func (c *cli) printSituation() {
+	fmt.Fprint(c.out, render.Terminal(c.game, c.renderOpts))
+}
+
+// parseSquare parses the square typed as two digits, e.g. 77 or ７七
+func parseSquare(fields []string) (*shogi.Position, error) {
+	if len(fields) != 1 {
+		return nil, errors.New("usage: show <square>")
+	}
+	m := squarePattern.FindStringSubmatch(fullWidthReplacer.Replace(fields[0]))
+	if m == nil {
+		return nil, errors.Errorf("invalid square: %s", fields[0])
+	}
+	return &shogi.Position{X: shogi.Axis(m[1][0] - '0'), Y: shogi.Axis(m[2][0] - '0')}, nil
+}
+
+func (c *cli) printLegalMoves() {
+	board := c.game.Board()
+	var last *shogi.Move
+	if m, ok := c.game.LastMove(); ok {
+		last = &m
+	}
+	var names []string
+	for _, m := range c.game.LegalMoves() {
+		names = append(names, board.JapaneseMove(m, last))
+	}
+	fmt.Fprintln(c.out, strings.Join(names, " "))
+}
+
+func (c *cli) playHumanMove(input string) error {
+	move, promotionSpecified, err := c.parseMove(input)
+	if err != nil {
+		return err
+	}
+	if !promotionSpecified && !move.Drop {
+		alternative := move
+		alternative.Promote = !move.Promote
+		if c.game.Board().ValidateMove(alternative) == nil && c.game.Board().ValidateMove(move) == nil {
+			answer, ok := c.prompt("成りますか? [y/n] ")
+			if !ok {
+				return errors.New("promotion is not answered")
+			}
+			move.Promote = answer == "y" || answer == "yes" || answer == "成"
+		}
+	}
+	return c.game.ApplyMove(move)
+}
+
+// parseMove parses the move typed in USI, square pair or Japanese notation.
+// promotionSpecified is false if the input doesn't tell whether the piece is promoted.
+func (c *cli) parseMove(input string) (move shogi.Move, promotionSpecified bool, err error) {
+	board := c.game.Board()
+	normalized := fullWidthReplacer.Replace(input)
+	if usiMovePattern.MatchString(normalized) {
+		move, err := board.ParseMove(normalized)
+		return move, true, err
+	}
+	if m := squarePairPattern.FindStringSubmatch(normalized); m != nil {
+		from := shogi.NewSquare(shogi.Axis(m[1][0]-'0'), shogi.Axis(m[2][0]-'0'))
+		to := shogi.NewSquare(shogi.Axis(m[3][0]-'0'), shogi.Axis(m[4][0]-'0'))
+		promote := m[5] == "+" || m[5] == "成"
+		move := board.NewMove(from, to, promote)
+		if m[5] == "" && board.ValidateMove(move) != nil {
+			// the piece must be promoted at the destination
+			if promoted := board.NewMove(from, to, true); board.ValidateMove(promoted) == nil {
+				return promoted, true, nil
+			}
+		}
+		return move, m[5] != "", nil
+	}
+	var last *shogi.Move
+	if m, ok := c.game.LastMove(); ok {
+		last = &m
+	}
+	move, err = board.ParseJapaneseMove(input, last)
+	trimmed := sourceSquarePattern.ReplaceAllString(input, "")
+	return move, strings.HasSuffix(trimmed, "成") || strings.HasSuffix(trimmed, "生"), err
+}
+
+// playEngineMove plays the move of the engine. The engine resigns if it can't play a move, so that run doesn't ask it
+// for the move again forever.
+func (c *cli) playEngineMove() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.searchTime)
+	defer cancel()
+	board := c.game.Board()
+	result, ok := c.game.Search(ctx, shogi.SearchOptions{Depth: c.searchDepth})
+	if !ok {
+		fmt.Fprintln(c.out, "engine found no legal move")
+		c.resignEngine()
+		return
+	}
+	var last *shogi.Move
+	if m, ok := c.game.LastMove(); ok {
+		last = &m
+	}
+	notation := board.SideToMove().Marker() + board.JapaneseMove(result.Move, last)
+	if err := c.game.ApplyMove(result.Move); err != nil {
+		fmt.Fprintf(c.out, "engine played an illegal move %s: %v\n", result.Move, err)
+		c.resignEngine()
+		return
+	}
+	fmt.Fprintf(c.out, "%s (評価値 %d, 深さ %d)\n", notation, result.Score, result.Depth)
+	c.printSituation()
+}
+
+// resignEngine resigns the game for the engine to move
+func (c *cli) resignEngine() {
+	if err := c.game.Resign(c.game.SideToMove()); err != nil {
+		fmt.Fprintln(c.out, err)
+	}
+}
+
+// printHint prints the best moves found by the engine within its thinking time, and the best move of each depth meanwhile
+func (c *cli) printHint(args []string) error {
+	multiPV := 3
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 {
+			return errors.New("usage: hint [n]")
+		}
+		multiPV = n
+	}
+	if _, over := c.game.Result(); over {
+		return errors.New("the game is over")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.searchTime)
+	defer cancel()
+	board := c.game.Board()
+	var last *shogi.Move
+	if m, ok := c.game.LastMove(); ok {
+		last = &m
+	}
+	analysis, ok := c.game.Analyze(ctx, shogi.AnalyzeOptions{
+		Depth:   c.searchDepth,
+		MultiPV: multiPV,
+		OnUpdate: func(a shogi.Analysis) {
+			if best, ok := a.Best(); ok {
+				fmt.Fprintf(c.out, "深さ %d: %s (%d)\n", a.Depth, board.JapaneseMove(best.Move, last), best.Score)
+			}
+		},
+	})
+	if !ok {
+		return errors.New("no legal move")
+	}
+	for i, candidate := range analysis.Candidates {
+		fmt.Fprintf(c.out, "%d. %s (評価値 %d) %s\n", i+1, board.JapaneseMove(candidate.Move, last), candidate.Score, japanesePV(board, candidate.PV, last))
+	}
+	return nil
+}
+
+// japanesePV returns the moves of the principal variation from the board in Japanese notation
+func japanesePV(board *shogi.BitboardBoard, pv []shogi.Move, last *shogi.Move) string {
+	board = board.Clone()
+	names := make([]string, len(pv))
+	for i, m := range pv {
+		names[i] = board.SideToMove().Marker() + board.JapaneseMove(m, last)
+		board.DoMove(m)
+		last = &pv[i]
+	}
+	return strings.Join(names, "")
+}
+
+// undo takes back the last move, and the engine's move before it so that the human is to move again
+func (c *cli) undo() error {
+	if err := c.game.Undo(); err != nil {
+		return err
+	}
+	if c.engines[c.game.SideToMove()] && !c.engines[c.game.SideToMove().Opponent()] {
+		if err := c.game.Undo(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kifInfo returns the information of the saved KIF record, where the players are named after who plays them
+func (c *cli) kifInfo() shogi.KIFInfo {
+	names := [2]string{"human", "human"}
+	for i, engine := range c.engines {
+		if engine {
+			names[i] = "engine"
+		}
+	}
+	return shogi.KIFInfo{SenteName: names[shogi.Sente], GoteName: names[shogi.Gote]}
+}
+
+// load replaces the game with the saved one. The game is saved in KIF with its result, and the USI position written
+// by the older versions is also accepted, which is resumed from its last position as it doesn't keep the result.
+func (c *cli) load(path string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var game *shogi.Game
+	if position := strings.TrimSpace(string(content)); strings.HasPrefix(position, "position") || strings.HasPrefix(position, "startpos") || strings.HasPrefix(position, "sfen") {
+		game, err = shogi.NewGameFromUSIPosition(position)
+	} else {
+		game, err = shogi.ParseKIF(string(content))
+	}
+	if err != nil {
+		return errors.Wrapf(err, "load %s", path)
+	}
+	c.game = game
+	c.resultShown = false
+	return nil
+}
@@ -0,0 +1,54 @@
+// Command svg writes a position as an SVG image to stdout.
+//
+// Usage:
+//
+//	svg [-sfen SFEN | -position "startpos moves 7g7f" | -kif game.kif] [-size 40] [-theme default|mono] [-highlight]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/k-yomo/shogi/render"
+	"github.com/k-yomo/shogi/shogi"
+)
+
+func main() {
+	sfen := flag.String("sfen", "", "position in SFEN")
+	position := flag.String("position", "", "position in the format of USI position command")
+	kif := flag.String("kif", "", "KIF file to draw the final position of")
+	size := flag.Int("size", 40, "size of a square in pixels")
+	theme := flag.String("theme", "default", "color theme: default or mono")
+	highlight := flag.Bool("highlight", false, "highlight the last move")
+	flag.Parse()
+
+	game, err := loadGame(*sfen, *position, *kif)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	opts := render.SVGOptions{SquareSize: *size, HighlightLastMove: *highlight}
+	if *theme == "mono" {
+		opts.Theme = &render.MonochromeTheme
+	}
+	fmt.Print(render.GameSVG(game, opts))
+}
+
+func loadGame(sfen, position, kif string) (*shogi.Game, error) {
+	switch {
+	case sfen != "":
+		return shogi.NewGameFromSFEN(sfen)
+	case position != "":
+		return shogi.NewGameFromUSIPosition(position)
+	case kif != "":
+		content, err := ioutil.ReadFile(kif)
+		if err != nil {
+			return nil, err
+		}
+		return shogi.ParseKIF(string(content))
+	default:
+		return shogi.NewGame(), nil
+	}
+}
@@ -0,0 +1,135 @@
+// Command tournament plays a round-robin or gauntlet tournament among agents in parallel and reports the Elo differences.
+// The agents are given as arguments such as "engine:3 engine:4 usi:/path/to/engine", see agent.Parse.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/k-yomo/shogi/agent"
+	"github.com/k-yomo/shogi/book"
+	"github.com/k-yomo/shogi/shogi"
+)
+
+func main() {
+	gauntlet := flag.Bool("gauntlet", false, "pair the first agent with each of the others instead of round-robin")
+	openings := flag.String("openings", "", "file of the opening positions in SFEN, one per line")
+	rounds := flag.Int("rounds", 1, "number of times each pairing plays every opening from both sides")
+	concurrency := flag.Int("concurrency", runtime.NumCPU(), "number of games played at the same time")
+	mainTime := flag.Duration("total", 0, "main time of each player, no time limit if every time setting is zero")
+	byoyomi := flag.Duration("byoyomi", time.Second, "time for each move after the main time runs out")
+	increment := flag.Duration("increment", 0, "time added after each move (Fischer)")
+	maxMoves := flag.Int("max-moves", 256, "number of moves after which the game is drawn")
+	sprt := flag.String("sprt", "", "stop a pairing by SPRT with the Elo bounds of H0 and H1, e.g. 0,10")
+	alpha := flag.Float64("alpha", 0.05, "false positive rate of SPRT")
+	beta := flag.Float64("beta", 0.05, "false negative rate of SPRT")
+	records := flag.String("records", "", "directory to write the KIF and CSA records to")
+	bookPath := flag.String("book", "", "opening book in the YaneuraOu format used by every agent")
+	flag.Parse()
+	rand.Seed(time.Now().UnixNano())
+
+	if flag.NArg() < 2 {
+		log.Fatal("at least two agents are required")
+	}
+	t := &agent.Tournament{
+		Rounds:      *rounds,
+		Concurrency: *concurrency,
+		TimeControl: shogi.TimeControl{
+			MainTime:  *mainTime,
+			Byoyomi:   *byoyomi,
+			Increment: *increment,
+		},
+		MaxMoves:  *maxMoves,
+		RecordDir: *records,
+	}
+	if *gauntlet {
+		t.Format = agent.FormatGauntlet
+	}
+	var openingBook *book.Book
+	if *bookPath != "" {
+		var err error
+		if openingBook, err = book.LoadFile(*bookPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, spec := range flag.Args() {
+		spec := spec
+		t.Entrants = append(t.Entrants, agent.Entrant{Name: spec, New: func() (agent.Agent, error) {
+			a, err := agent.Parse(spec)
+			if err != nil || openingBook == nil {
+				return a, err
+			}
+			return agent.WithBook(a, openingBook, rand.Int63()), nil
+		}})
+	}
+	if *openings != "" {
+		sfens, err := readOpenings(*openings)
+		if err != nil {
+			log.Fatal(err)
+		}
+		t.Openings = sfens
+	}
+	if *sprt != "" {
+		var elo0, elo1 float64
+		if _, err := fmt.Sscanf(*sprt, "%g,%g", &elo0, &elo1); err != nil {
+			log.Fatalf("invalid sprt bounds: %q", *sprt)
+		}
+		t.SPRT = &agent.SPRT{Elo0: elo0, Elo1: elo1, Alpha: *alpha, Beta: *beta}
+	}
+	if *records != "" {
+		if err := os.MkdirAll(*records, 0755); err != nil {
+			log.Fatal(err)
+		}
+	}
+	t.OnGame = func(p *agent.Pairing, record *agent.GameRecord) {
+		log.Printf("%s vs %s: %s, %s - %s: %s", record.Sente.Name(), record.Gote.Name(), record.Result, p.First.Name, p.Second.Name, p.Score)
+		if t.SPRT != nil {
+			lower, upper := t.SPRT.Bounds()
+			log.Printf("LLR %.2f (%.2f, %.2f) %s", p.LLR(t.SPRT.Elo0, t.SPRT.Elo1), lower, upper, p.Decision)
+		}
+	}
+
+	result, err := t.Run(context.Background())
+	if result != nil {
+		for _, p := range result.Pairings {
+			fmt.Printf("%s - %s: %s\n", p.First.Name, p.Second.Name, p.Score)
+			if t.SPRT != nil {
+				fmt.Printf("  SPRT: %s\n", p.Decision)
+			}
+		}
+		for i := range t.Entrants {
+			e := &t.Entrants[i]
+			fmt.Printf("%s: %s\n", e.Name, result.Standing(e))
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readOpenings reads the SFEN lines of the file, skipping empty lines and comments starting with #
+func readOpenings(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var sfens []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sfens = append(sfens, strings.TrimPrefix(line, "sfen "))
+	}
+	return sfens, scanner.Err()
+}
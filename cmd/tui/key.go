@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+)
+
+type key int
+
+const (
+	keyRune key = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyEnter
+	keyEscape
+	keyPageUp
+	keyPageDown
+	keyInterrupt
+	keyUnknown
+)
+
+// escapeSequences are the sequences sent by terminals for the special keys, following the escape character
+var escapeSequences = map[string]key{
+	"[A": keyUp, "[B": keyDown, "[C": keyRight, "[D": keyLeft,
+	"OA": keyUp, "OB": keyDown, "OC": keyRight, "OD": keyLeft,
+	"[5~": keyPageUp, "[6~": keyPageDown,
+}
+
+// readKey reads a key press from the terminal in raw mode. r is the typed character for keyRune.
+func readKey(in *bufio.Reader) (k key, r rune, err error) {
+	r, _, err = in.ReadRune()
+	if err != nil {
+		return keyUnknown, 0, err
+	}
+	switch r {
+	case '\r', '\n':
+		return keyEnter, r, nil
+	case 3:
+		return keyInterrupt, r, nil
+	case 0x1b:
+	default:
+		return keyRune, r, nil
+	}
+
+	// a lone escape is the escape key, while the special keys send the whole sequence at once
+	var seq []byte
+	for in.Buffered() > 0 {
+		b, err := in.ReadByte()
+		if err != nil {
+			return keyUnknown, 0, err
+		}
+		seq = append(seq, b)
+		if k, ok := escapeSequences[string(seq)]; ok {
+			return k, 0, nil
+		}
+		if len(seq) >= 4 {
+			break
+		}
+	}
+	if len(seq) == 0 {
+		return keyEscape, r, nil
+	}
+	return keyUnknown, 0, nil
+}
@@ -0,0 +1,75 @@
+// Command tui plays shogi in a full-screen terminal UI.
+//
+// Move the cursor over the board and the hands with the arrow keys or hjkl, select a piece with Enter or Space,
+// and choose one of the highlighted destinations. It's playable hot-seat or against the built-in engine.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/k-yomo/shogi/render"
+	"github.com/k-yomo/shogi/shogi"
+)
+
+func main() {
+	sente := flag.String("sente", "human", "who plays sente: human or engine")
+	gote := flag.String("gote", "engine", "who plays gote: human or engine")
+	depth := flag.Int("depth", 4, "search depth of the engine")
+	searchTime := flag.Duration("time", 10*time.Second, "maximum thinking time of the engine per move")
+	sfen := flag.String("sfen", "", "starting position in SFEN")
+	flag.Parse()
+
+	game := shogi.NewGame()
+	if *sfen != "" {
+		var err error
+		if game, err = shogi.NewGameFromSFEN(*sfen); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	u := newUI(os.Stdout, game)
+	u.searchDepth = *depth
+	u.searchTime = *searchTime
+	for i, player := range []string{*sente, *gote} {
+		switch player {
+		case "human":
+		case "engine":
+			u.engines[i] = true
+		default:
+			fmt.Fprintf(os.Stderr, "unknown player %q, it must be human or engine\n", player)
+			os.Exit(2)
+		}
+	}
+
+	if !render.IsTerminal(os.Stdin) || !render.IsTerminal(os.Stdout) {
+		fmt.Fprintln(os.Stderr, "tui must be run in a terminal, use cmd/shogi for pipes")
+		os.Exit(1)
+	}
+	term, err := makeRaw(os.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	// use the alternate screen and hide the cursor while playing
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		term.restore()
+	}()
+
+	u.checkGameOver()
+	in := bufio.NewReader(os.Stdin)
+	for !u.quit {
+		u.playEngineMoves()
+		u.draw()
+		k, r, err := readKey(in)
+		if err != nil {
+			return
+		}
+		u.handleKey(k, r)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package main
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+type rawTerminal struct{}
+
+func makeRaw(f *os.File) (*rawTerminal, error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform, use cmd/shogi instead")
+}
+
+func (t *rawTerminal) restore() error {
+	return nil
+}
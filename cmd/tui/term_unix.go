@@ -0,0 +1,46 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// rawTerminal switches the terminal into raw mode, where keys are read one by one without being echoed
+type rawTerminal struct {
+	fd       uintptr
+	original syscall.Termios
+}
+
+func makeRaw(f *os.File) (*rawTerminal, error) {
+	t := &rawTerminal{fd: f.Fd()}
+	if err := ioctlTermios(t.fd, ioctlGetTermios, &t.original); err != nil {
+		return nil, err
+	}
+	raw := t.original
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctlTermios(t.fd, ioctlSetTermios, &raw); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// restore puts the terminal back into the mode before makeRaw
+func (t *rawTerminal) restore() error {
+	return ioctlTermios(t.fd, ioctlSetTermios, &t.original)
+}
+
+func ioctlTermios(fd uintptr, request uintptr, termios *syscall.Termios) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(unsafe.Pointer(termios))); errno != 0 {
+		return errno
+	}
+	return nil
+}
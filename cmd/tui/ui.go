@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/k-yomo/shogi/render"
+	"github.com/k-yomo/shogi/shogi"
+)
+
+const (
+	moveListColumn = 56
+	moveListRows   = 14
+	helpText       = "←↑↓→/hjkl: 移動  Enter/Space: 選択  Esc: 取消  u: 待った  PgUp/PgDn/[/]: 棋譜  r: 投了  q: 終了"
+)
+
+// promotionDialog asks whether the piece is promoted when both moves are legal
+type promotionDialog struct {
+	move    shogi.Move
+	promote bool
+}
+
+type ui struct {
+	out  io.Writer
+	game *shogi.Game
+	// engines is whether the color is played by the built-in engine
+	engines     [2]bool
+	searchDepth int
+	searchTime  time.Duration
+
+	// cursor is the square the cursor is on, or nil if it's on the hand of handCursor
+	cursor     *shogi.Position
+	handCursor render.HandPiece
+	// selected is the square of the selected piece, or nil
+	selected *shogi.Position
+	// selectedHand is the selected piece in hand, or nil
+	selectedHand *render.HandPiece
+	promotion    *promotionDialog
+	// scroll is the number of lines the move list is scrolled up from the latest move
+	scroll  int
+	message string
+	over    bool
+	quit    bool
+}
+
+func newUI(out io.Writer, game *shogi.Game) *ui {
+	u := &ui{out: out, game: game}
+	u.resetCursor()
+	game.Subscribe(func(e shogi.Event) {
+		switch e.Type {
+		case shogi.EventGameOver:
+			u.over = true
+			u.message = fmt.Sprintf("まで%d手で%s", u.game.MoveNumber()-1, e.Result)
+		case shogi.EventUndo:
+			u.over = false
+		}
+	}, shogi.EventGameOver, shogi.EventUndo)
+	return u
+}
+
+// resetCursor puts the cursor on the king of the side to move
+func (u *ui) resetCursor() {
+	b := u.game.Board()
+	pos := &shogi.Position{X: 5, Y: 9}
+	if sq := b.KingSquare(b.SideToMove()); sq != shogi.NoSquare {
+		pos = sq.Position()
+	}
+	u.cursor = pos
+}
+
+func (u *ui) cancelSelection() {
+	u.selected = nil
+	u.selectedHand = nil
+	u.promotion = nil
+}
+
+func (u *ui) handleKey(k key, r rune) {
+	u.message = ""
+	if k == keyInterrupt || (k == keyRune && r == 'q') {
+		u.quit = true
+		return
+	}
+	if u.promotion != nil {
+		u.handlePromotionKey(k, r)
+		return
+	}
+	switch {
+	case k == keyUp || (k == keyRune && r == 'k'):
+		u.moveCursor(0, -1)
+	case k == keyDown || (k == keyRune && r == 'j'):
+		u.moveCursor(0, 1)
+	case k == keyLeft || (k == keyRune && r == 'h'):
+		u.moveCursor(1, 0)
+	case k == keyRight || (k == keyRune && r == 'l'):
+		u.moveCursor(-1, 0)
+	case k == keyEnter || (k == keyRune && r == ' '):
+		u.choose()
+	case k == keyEscape:
+		u.cancelSelection()
+	case k == keyPageUp || (k == keyRune && r == '['):
+		u.scrollMoveList(moveListRows / 2)
+	case k == keyPageDown || (k == keyRune && r == ']'):
+		u.scrollMoveList(-moveListRows / 2)
+	case k == keyRune && r == 'u':
+		u.undo()
+	case k == keyRune && r == 'r':
+		if !u.over {
+			u.game.Resign(u.game.SideToMove())
+		}
+	}
+}
+
+func (u *ui) handlePromotionKey(k key, r rune) {
+	switch {
+	case k == keyLeft || k == keyRight || k == keyUp || k == keyDown || (k == keyRune && strings.ContainsRune("hjkl", r)):
+		u.promotion.promote = !u.promotion.promote
+	case k == keyRune && r == 'y':
+		u.promotion.promote = true
+		u.play(u.promotion.move, true)
+	case k == keyRune && r == 'n':
+		u.play(u.promotion.move, false)
+	case k == keyEnter || (k == keyRune && r == ' '):
+		u.play(u.promotion.move, u.promotion.promote)
+	case k == keyEscape:
+		u.promotion = nil
+	}
+}
+
+// moveCursor moves the cursor by the difference of the files and ranks.
+// Moving out of the board upward or downward enters the hand on that side if it isn't empty.
+func (u *ui) moveCursor(dx, dy shogi.Axis) {
+	b := u.game.Board()
+	if u.cursor == nil {
+		hand := handKinds(b, u.handCursor.Color)
+		i := indexOf(hand, u.handCursor.Kind)
+		switch {
+		case dx != 0 && len(hand) > 0:
+			// the hand is listed from left to right, the opposite of files
+			i = (i - int(dx) + len(hand)) % len(hand)
+			u.handCursor.Kind = hand[i]
+		case dy < 0 && u.handCursor.Color == shogi.Sente:
+			u.cursor = &shogi.Position{X: 5, Y: 9}
+		case dy > 0 && u.handCursor.Color == shogi.Gote:
+			u.cursor = &shogi.Position{X: 5, Y: 1}
+		}
+		return
+	}
+
+	next := shogi.Position{X: u.cursor.X + dx, Y: u.cursor.Y + dy}
+	switch {
+	case next.X < 1 || next.X > 9:
+		return
+	case next.Y < 1 || next.Y > 9:
+		c := shogi.Gote
+		if next.Y > 9 {
+			c = shogi.Sente
+		}
+		if hand := handKinds(b, c); len(hand) > 0 {
+			u.cursor = nil
+			u.handCursor = render.HandPiece{Color: c, Kind: hand[0]}
+		}
+		return
+	}
+	u.cursor = &next
+}
+
+// choose selects the piece under the cursor, or moves the selected piece to the square under the cursor
+func (u *ui) choose() {
+	if u.over {
+		u.message = "対局は終了しました。u で待った、q で終了します"
+		return
+	}
+	b := u.game.Board()
+	if u.cursor == nil {
+		if u.handCursor.Color != b.SideToMove() {
+			u.message = "相手の持駒は選べません"
+			return
+		}
+		hp := u.handCursor
+		u.selected, u.selectedHand = nil, &hp
+		return
+	}
+
+	to := shogi.SquareOf(u.cursor)
+	if u.selected != nil || u.selectedHand != nil {
+		var candidates []shogi.Move
+		for _, m := range b.LegalMoves() {
+			if m.To != to {
+				continue
+			}
+			if (u.selectedHand != nil && m.Drop && m.Piece == u.selectedHand.Kind) ||
+				(u.selected != nil && !m.Drop && m.From == shogi.SquareOf(u.selected)) {
+				candidates = append(candidates, m)
+			}
+		}
+		switch len(candidates) {
+		case 1:
+			u.play(candidates[0], candidates[0].Promote)
+			return
+		case 2:
+			u.promotion = &promotionDialog{move: candidates[0], promote: true}
+			return
+		}
+	}
+
+	if kind, c := b.PieceAt(to); kind != shogi.KindNone && c == b.SideToMove() {
+		if len(b.PieceMovablePosition(u.cursor)) == 0 {
+			u.message = "その駒は動かせません"
+		}
+		pos := *u.cursor
+		u.selected, u.selectedHand = &pos, nil
+		return
+	}
+	u.cancelSelection()
+}
+
+func (u *ui) play(m shogi.Move, promote bool) {
+	m.Promote = promote
+	u.cancelSelection()
+	if err := u.game.ApplyMove(m); err != nil {
+		u.message = err.Error()
+		return
+	}
+	u.scroll = 0
+	u.cursor = m.To.Position()
+}
+
+// checkGameOver shows the result if the game has already ended, the later endings are notified by the game events
+func (u *ui) checkGameOver() {
+	if result, ok := u.game.Result(); ok {
+		u.over = true
+		u.message = fmt.Sprintf("まで%d手で%s", u.game.MoveNumber()-1, result)
+	}
+}
+
+// undo takes back the last move, and the engine's move before it so that the human is to move again
+func (u *ui) undo() {
+	u.cancelSelection()
+	if err := u.game.Undo(); err != nil {
+		u.message = err.Error()
+		return
+	}
+	if u.engines[u.game.SideToMove()] && !u.engines[u.game.SideToMove().Opponent()] {
+		if err := u.game.Undo(); err != nil {
+			u.message = err.Error()
+		}
+	}
+	u.scroll = 0
+	u.resetCursor()
+}
+
+func (u *ui) scrollMoveList(lines int) {
+	maxScroll := len(u.game.History()) - moveListRows
+	u.scroll += lines
+	if u.scroll > maxScroll {
+		u.scroll = maxScroll
+	}
+	if u.scroll < 0 {
+		u.scroll = 0
+	}
+}
+
+// playEngineMoves lets the engine play while it's the engine's turn
+func (u *ui) playEngineMoves() {
+	for !u.over && u.engines[u.game.SideToMove()] {
+		u.message = "考え中..."
+		u.draw()
+		ctx, cancel := context.WithTimeout(context.Background(), u.searchTime)
+		result, ok := u.game.Search(ctx, shogi.SearchOptions{Depth: u.searchDepth})
+		cancel()
+		if !ok {
+			u.message = "エンジンが指し手を見つけられませんでした"
+			u.over = true
+			return
+		}
+		u.message = ""
+		u.play(result.Move, result.Move.Promote)
+		if !u.over {
+			u.resetCursor()
+		}
+	}
+}
+
+func (u *ui) draw() {
+	var sb strings.Builder
+	sb.WriteString("\x1b[H\x1b[2J")
+	opts := render.TerminalOptions{
+		Color:             true,
+		HighlightLastMove: true,
+		Selected:          u.selected,
+		SelectedHand:      u.selectedHand,
+		Cursor:            u.cursor,
+	}
+	if u.cursor == nil {
+		hp := u.handCursor
+		opts.HandCursor = &hp
+	}
+	sb.WriteString(render.Terminal(u.game, opts))
+
+	sb.WriteString(fmt.Sprintf("\x1b[1;%dH棋譜", moveListColumn))
+	moves := u.game.JapaneseMoves()
+	first := len(moves) - moveListRows - u.scroll
+	if first < 0 {
+		first = 0
+	}
+	for row := 0; row < moveListRows && first+row < len(moves); row++ {
+		i := first + row
+		// the last move was played by the opponent of the side to move, and the sides alternate before it
+		player := u.game.SideToMove().Opponent()
+		if (len(moves)-1-i)%2 == 1 {
+			player = u.game.SideToMove()
+		}
+		marker := player.Marker()
+		sb.WriteString(fmt.Sprintf("\x1b[%d;%dH%4d %s%s", row+2, moveListColumn, u.game.MoveNumber()-len(moves)+i, marker, moves[i]))
+	}
+	if u.scroll > 0 {
+		sb.WriteString(fmt.Sprintf("\x1b[%d;%dH(↓ %d)", moveListRows+2, moveListColumn, u.scroll))
+	}
+
+	sb.WriteString("\x1b[18;1H")
+	if u.promotion != nil {
+		promote, notPromote := "成", "不成"
+		if u.promotion.promote {
+			promote = "\x1b[7m" + promote + "\x1b[0m"
+		} else {
+			notPromote = "\x1b[7m" + notPromote + "\x1b[0m"
+		}
+		sb.WriteString(fmt.Sprintf("成りますか?  %s  %s", promote, notPromote))
+	} else {
+		sb.WriteString(u.message)
+	}
+	sb.WriteString("\x1b[20;1H" + helpText)
+	fmt.Fprint(u.out, sb.String())
+}
+
+// handKinds returns the kinds the color has in hand
+func handKinds(b *shogi.BitboardBoard, c shogi.Color) []shogi.PieceKind {
+	var kinds []shogi.PieceKind
+	for _, kind := range shogi.HandKinds {
+		if b.Hand(c, kind) > 0 {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+func indexOf(kinds []shogi.PieceKind, kind shogi.PieceKind) int {
+	for i, k := range kinds {
+		if k == kind {
+			return i
+		}
+	}
+	return 0
+}
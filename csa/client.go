@@ -0,0 +1,269 @@
+package csa
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k-yomo/shogi/agent"
+	"github.com/k-yomo/shogi/shogi"
+	"github.com/pkg/errors"
+)
+
+// TimeLeft is the time the player has when choosing a move
+type TimeLeft struct {
+	// Remaining is the main time left
+	Remaining time.Duration
+	Byoyomi   time.Duration
+	Increment time.Duration
+}
+
+// MoveChooser chooses the moves of the client's player
+type MoveChooser interface {
+	// ChooseMove returns the move to play for the side to move of the game, or resign true to resign.
+	// The game must not be modified.
+	ChooseMove(ctx context.Context, game *shogi.Game, timeLeft TimeLeft) (move shogi.Move, resign bool, err error)
+}
+
+// MoveChooserFunc is a function used as MoveChooser
+type MoveChooserFunc func(ctx context.Context, game *shogi.Game, timeLeft TimeLeft) (shogi.Move, bool, error)
+
+func (f MoveChooserFunc) ChooseMove(ctx context.Context, game *shogi.Game, timeLeft TimeLeft) (shogi.Move, bool, error) {
+	return f(ctx, game, timeLeft)
+}
+
+// EngineChooser chooses moves with the built-in engine as agent.Engine does, and resigns when there is no legal move
+type EngineChooser struct {
+	Depth int
+	// Threads is the number of search workers, see shogi.SearchOptions
+	Threads int
+	// MaxTime is the maximum thinking time per move, no limit but the time left if it's zero
+	MaxTime time.Duration
+}
+
+func (e *EngineChooser) ChooseMove(ctx context.Context, game *shogi.Game, timeLeft TimeLeft) (shogi.Move, bool, error) {
+	engine := &agent.Engine{Depth: e.Depth, Threads: e.Threads, MaxTime: e.MaxTime}
+	return engine.ChooseMove(ctx, agent.NewPosition(game), timeLeft.agentTimeLeft(game.SideToMove()))
+}
+
+// agentTimeLeft returns the time left of the color for agent, where byoyomi is a single period.
+// The main time of the time control isn't known, and the remaining one stands for it.
+func (t TimeLeft) agentTimeLeft(c shogi.Color) agent.TimeLeft {
+	left := agent.TimeLeft{TimeControl: shogi.TimeControl{MainTime: t.Remaining, Byoyomi: t.Byoyomi, Increment: t.Increment}}
+	left.Remaining[c] = t.Remaining
+	if t.Byoyomi > 0 {
+		left.Periods[c] = 1
+	}
+	return left
+}
+
+// GameResult is the result of a game played by the client
+type GameResult struct {
+	Summary *GameSummary
+	// Result is WIN, LOSE, DRAW or CENSORED
+	Result string
+	// Reason is how the game ended such as RESIGN, TIME_UP and ILLEGAL_MOVE, which can be empty
+	Reason string
+}
+
+// Client is a client of a CSA server
+type Client struct {
+	// Logger logs the messages exchanged with the server, which are discarded if it's nil
+	Logger *log.Logger
+	conn   net.Conn
+	r      *bufio.Reader
+}
+
+// Dial connects to the CSA server and logs in
+func Dial(addr, name, password string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "connect to csa server")
+	}
+	c := &Client{conn: conn, r: bufio.NewReader(conn)}
+	if err := c.login(name, password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) login(name, password string) error {
+	if err := c.send(fmt.Sprintf("LOGIN %s %s", name, password)); err != nil {
+		return err
+	}
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	if line != fmt.Sprintf("LOGIN:%s OK", name) {
+		return errors.Errorf("login failed: %s", line)
+	}
+	return nil
+}
+
+// Logout logs out and closes the connection
+func (c *Client) Logout() error {
+	defer c.conn.Close()
+	if err := c.send("LOGOUT"); err != nil {
+		return err
+	}
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+		if line == "LOGOUT:completed" {
+			return nil
+		}
+	}
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// PlayGame waits for a game, agrees to it and plays it to the end with the moves chosen by the chooser
+func (c *Client) PlayGame(ctx context.Context, chooser MoveChooser) (*GameResult, error) {
+	summary, err := c.waitGameSummary()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.send("AGREE " + summary.GameID); err != nil {
+		return nil, err
+	}
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, "REJECT:") {
+			return nil, errors.Errorf("game is rejected: %s", line)
+		}
+		if line == "START:"+summary.GameID {
+			break
+		}
+	}
+
+	game := summary.Game
+	result := &GameResult{Summary: summary}
+	timeLeft := TimeLeft{
+		Remaining: summary.TimeControl.TotalTime,
+		Byoyomi:   summary.TimeControl.Byoyomi,
+		Increment: summary.TimeControl.Increment,
+	}
+	// waiting is whether the client has sent its move and waits for the server to accept it
+	waiting := false
+	for {
+		if game.SideToMove() == summary.YourTurn && !waiting {
+			move, resign, err := chooser.ChooseMove(ctx, game, timeLeft)
+			if err != nil {
+				return nil, errors.Wrap(err, "choose move")
+			}
+			message := "%TORYO"
+			if !resign {
+				message = game.Board().CSAMove(move)
+			}
+			if err := c.send(message); err != nil {
+				return nil, err
+			}
+			waiting = true
+		}
+
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case line == "":
+		case line[0] == '+' || line[0] == '-':
+			mover := game.SideToMove()
+			consumed, err := c.applyMove(game, line)
+			if err != nil {
+				return nil, err
+			}
+			if mover == summary.YourTurn {
+				timeLeft.Remaining -= consumed
+				if timeLeft.Remaining < 0 {
+					timeLeft.Remaining = 0
+				}
+				timeLeft.Remaining += timeLeft.Increment
+			}
+			waiting = false
+		case line == "#WIN" || line == "#LOSE" || line == "#DRAW" || line == "#CENSORED":
+			result.Result = line[1:]
+			return result, nil
+		case line[0] == '#':
+			result.Reason = line[1:]
+		}
+	}
+}
+
+// applyMove plays the move sent by the server, e.g. "+7776FU,T3", and returns the time consumed by the move
+func (c *Client) applyMove(game *shogi.Game, line string) (time.Duration, error) {
+	fields := strings.Split(line, ",")
+	move, err := game.Board().ParseCSAMove(fields[0])
+	if err != nil {
+		return 0, err
+	}
+	var consumed time.Duration
+	for _, field := range fields[1:] {
+		if strings.HasPrefix(field, "T") {
+			seconds, err := strconv.Atoi(field[1:])
+			if err != nil {
+				return 0, errors.Errorf("invalid time: %q", line)
+			}
+			consumed = time.Duration(seconds) * time.Second
+		}
+	}
+	if err := game.ApplyMoveWithTime(move, consumed); err != nil {
+		return 0, errors.Wrapf(err, "server sent an illegal move %s", fields[0])
+	}
+	return consumed, nil
+}
+
+func (c *Client) waitGameSummary() (*GameSummary, error) {
+	var lines []string
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "BEGIN Game_Summary" {
+			lines = nil
+		}
+		lines = append(lines, line)
+		if line == "END Game_Summary" {
+			return ParseGameSummary(lines)
+		}
+	}
+}
+
+func (c *Client) send(line string) error {
+	c.logf("> %s", line)
+	if _, err := fmt.Fprint(c.conn, line+"\n"); err != nil {
+		return errors.Wrap(err, "send to csa server")
+	}
+	return nil
+}
+
+func (c *Client) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", errors.Wrap(err, "read from csa server")
+	}
+	line = strings.TrimRight(line, "\r\n")
+	c.logf("< %s", line)
+	return line, nil
+}
+
+func (c *Client) logf(format string, args ...interface{}) {
+	if c.Logger != nil {
+		c.Logger.Printf(format, args...)
+	}
+}
@@ -0,0 +1,307 @@
+package csa
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k-yomo/shogi/shogi"
+)
+
+// fakeServer is a CSA server accepting one connection, which exchanges the messages scripted by the test
+type fakeServer struct {
+	t        *testing.T
+	listener net.Listener
+	conn     net.Conn
+	r        *bufio.Reader
+	done     chan struct{}
+}
+
+// startFakeServer listens on a local port and runs the script with the first connection in another goroutine
+func startFakeServer(t *testing.T, script func(s *fakeServer)) *fakeServer {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeServer{t: t, listener: l, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		conn, err := l.Accept()
+		if err != nil {
+			t.Errorf("accept: %v", err)
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+		s.conn, s.r = conn, bufio.NewReader(conn)
+		script(s)
+	}()
+	return s
+}
+
+func (s *fakeServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+// wait waits for the script to finish and stops listening
+func (s *fakeServer) wait() {
+	<-s.done
+	s.listener.Close()
+}
+
+func (s *fakeServer) send(lines ...string) {
+	for _, line := range lines {
+		fmt.Fprint(s.conn, line+"\n")
+	}
+}
+
+// expect reads a line and reports an error if it's not the expected one
+func (s *fakeServer) expect(want string) {
+	line, err := s.r.ReadString('\n')
+	if err != nil {
+		s.t.Errorf("read %q: %v", want, err)
+		return
+	}
+	if got := strings.TrimRight(line, "\r\n"); got != want {
+		s.t.Errorf("client sent %q, want %q", got, want)
+	}
+}
+
+// summaryLines is the Game_Summary where the client plays gote after ▲７六歩 with 600 seconds and the increment of 10 seconds
+var summaryLines = []string{
+	"BEGIN Game_Summary",
+	"Protocol_Version:1.2",
+	"Protocol_Mode:Server",
+	"Format:Shogi 1.0",
+	"Game_ID:test-1",
+	"Name+:alice",
+	"Name-:bob",
+	"Your_Turn:-",
+	"To_Move:+",
+	"Max_Moves:256",
+	"BEGIN Time",
+	"Time_Unit:1sec",
+	"Total_Time:600",
+	"Byoyomi:0",
+	"Increment:10",
+	"END Time",
+	"BEGIN Position",
+	"PI",
+	"+",
+	"+7776FU,T12",
+	"END Position",
+	"END Game_Summary",
+}
+
+// scriptedChooser plays the moves in USI in order and resigns after them, recording the time left given for each move
+type scriptedChooser struct {
+	moves     []string
+	timeLefts []TimeLeft
+}
+
+func (c *scriptedChooser) ChooseMove(ctx context.Context, game *shogi.Game, timeLeft TimeLeft) (shogi.Move, bool, error) {
+	c.timeLefts = append(c.timeLefts, timeLeft)
+	if len(c.moves) == 0 {
+		return shogi.Move{}, true, nil
+	}
+	move, err := game.Board().ParseMove(c.moves[0])
+	c.moves = c.moves[1:]
+	return move, false, err
+}
+
+func TestClient_PlayGame(t *testing.T) {
+	tests := []struct {
+		name string
+		// script plays the game after START, where the client plays 3c3d and resigns at the next move
+		script     func(s *fakeServer)
+		moves      []string
+		wantResult string
+		wantReason string
+		// wantTimeLefts are the main times given to the chooser
+		wantTimeLefts []time.Duration
+	}{
+		{
+			name: "resign",
+			script: func(s *fakeServer) {
+				s.expect("-3334FU")
+				s.send("-3334FU,T5", "+2726FU,T3")
+				s.expect("%TORYO")
+				s.send("%TORYO,T2", "#RESIGN", "#LOSE")
+			},
+			moves:         []string{"3c3d"},
+			wantResult:    "LOSE",
+			wantReason:    "RESIGN",
+			wantTimeLefts: []time.Duration{600 * time.Second, 605 * time.Second},
+		},
+		{
+			name: "opponent resigns",
+			script: func(s *fakeServer) {
+				s.expect("-3334FU")
+				s.send("-3334FU,T20", "%TORYO,T1", "#RESIGN", "#WIN")
+			},
+			moves:         []string{"3c3d"},
+			wantResult:    "WIN",
+			wantReason:    "RESIGN",
+			wantTimeLefts: []time.Duration{600 * time.Second},
+		},
+		{
+			name: "time up",
+			script: func(s *fakeServer) {
+				s.expect("-3334FU")
+				s.send("-3334FU,T700", "+2726FU,T3")
+				s.expect("-8384FU")
+				s.send("#TIME_UP", "#LOSE")
+			},
+			moves:      []string{"3c3d", "8c8d"},
+			wantResult: "LOSE",
+			wantReason: "TIME_UP",
+			// the main time doesn't go below zero, and the increment is added after the move
+			wantTimeLefts: []time.Duration{600 * time.Second, 10 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			server := startFakeServer(t, func(s *fakeServer) {
+				s.expect("LOGIN bob pass")
+				s.send("LOGIN:bob OK")
+				s.send(summaryLines...)
+				s.expect("AGREE test-1")
+				s.send("START:test-1")
+				tt.script(s)
+			})
+			defer server.wait()
+
+			client, err := Dial(server.addr(), "bob", "pass")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+			chooser := &scriptedChooser{moves: tt.moves}
+			result, err := client.PlayGame(context.Background(), chooser)
+			if err != nil {
+				t.Fatalf("PlayGame() error = %v", err)
+			}
+
+			if result.Result != tt.wantResult || result.Reason != tt.wantReason {
+				t.Errorf("PlayGame() = %s %s, want %s %s", result.Result, result.Reason, tt.wantResult, tt.wantReason)
+			}
+			summary := result.Summary
+			if summary.GameID != "test-1" || summary.SenteName != "alice" || summary.GoteName != "bob" || summary.YourTurn != shogi.Gote {
+				t.Errorf("summary = %+v", summary)
+			}
+			wantTC := TimeControl{TotalTime: 600 * time.Second, Increment: 10 * time.Second}
+			if summary.TimeControl != wantTC {
+				t.Errorf("TimeControl = %+v, want %+v", summary.TimeControl, wantTC)
+			}
+			if len(chooser.timeLefts) != len(tt.wantTimeLefts) {
+				t.Fatalf("ChooseMove is called %d times, want %d", len(chooser.timeLefts), len(tt.wantTimeLefts))
+			}
+			for i, tl := range chooser.timeLefts {
+				want := TimeLeft{Remaining: tt.wantTimeLefts[i], Increment: 10 * time.Second}
+				if tl != want {
+					t.Errorf("time left of move %d = %+v, want %+v", i+1, tl, want)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_PlayGame_Position(t *testing.T) {
+	var sfens []string
+	server := startFakeServer(t, func(s *fakeServer) {
+		s.expect("LOGIN bob pass")
+		s.send("LOGIN:bob OK")
+		s.send(summaryLines...)
+		s.expect("AGREE test-1")
+		s.send("START:test-1")
+		s.expect("-3334FU")
+		s.send("-3334FU,T5", "+8822UM,T3")
+		s.expect("%TORYO")
+		s.send("#RESIGN", "#LOSE")
+	})
+	defer server.wait()
+
+	client, err := Dial(server.addr(), "bob", "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	moves := []string{"3c3d"}
+	chooser := MoveChooserFunc(func(ctx context.Context, game *shogi.Game, timeLeft TimeLeft) (shogi.Move, bool, error) {
+		sfens = append(sfens, game.SFEN())
+		if len(moves) == 0 {
+			return shogi.Move{}, true, nil
+		}
+		move, err := game.Board().ParseMove(moves[0])
+		moves = moves[1:]
+		return move, false, err
+	})
+	if _, err := client.PlayGame(context.Background(), chooser); err != nil {
+		t.Fatalf("PlayGame() error = %v", err)
+	}
+
+	want := []string{
+		"lnsgkgsnl/1r5b1/ppppppppp/9/9/2P6/PP1PPPPPP/1B5R1/LNSGKGSNL w - 2",
+		"lnsgkgsnl/1r5+B1/pppppp1pp/6p2/9/2P6/PP1PPPPPP/7R1/LNSGKGSNL w B 4",
+	}
+	if strings.Join(sfens, "\n") != strings.Join(want, "\n") {
+		t.Errorf("positions given to the chooser = %q, want %q", sfens, want)
+	}
+}
+
+func TestParseGameSummary(t *testing.T) {
+	lines := append([]string(nil), summaryLines...)
+	for i, line := range lines {
+		switch line {
+		case "Time_Unit:1sec":
+			lines[i] = "Time_Unit:1min"
+		case "Total_Time:600":
+			lines[i] = "Total_Time:10"
+		case "Increment:10":
+			lines[i] = "Byoyomi:1"
+		}
+	}
+	summary, err := ParseGameSummary(lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := TimeControl{TotalTime: 10 * time.Minute, Byoyomi: time.Minute}
+	if summary.TimeControl != want {
+		t.Errorf("TimeControl = %+v, want %+v", summary.TimeControl, want)
+	}
+	if summary.MaxMoves != 256 {
+		t.Errorf("MaxMoves = %d, want 256", summary.MaxMoves)
+	}
+	if got := len(summary.Game.History()); got != 1 {
+		t.Errorf("moves in the position = %d, want 1", got)
+	}
+
+	// the summary written by the server is read back
+	parsed, err := ParseGameSummary(strings.Split(strings.TrimSuffix(summary.String(), "\n"), "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.GameID != summary.GameID || parsed.TimeControl != summary.TimeControl || parsed.Game.SFEN() != summary.Game.SFEN() {
+		t.Errorf("ParseGameSummary(String()) = %+v, want %+v", parsed, summary)
+	}
+}
+
+func TestTimeLeft_agentTimeLeft(t *testing.T) {
+	timeLeft := TimeLeft{Remaining: 5 * time.Minute, Byoyomi: 10 * time.Second}
+	got := timeLeft.agentTimeLeft(shogi.Gote)
+	if got.TimeControl.IsUnlimited() || got.TimeControl.Byoyomi != 10*time.Second {
+		t.Errorf("TimeControl = %+v, want limited with the byoyomi of 10s", got.TimeControl)
+	}
+	if got.Remaining[shogi.Gote] != 5*time.Minute || got.Periods[shogi.Gote] != 1 {
+		t.Errorf("time left of gote = %s and %d periods, want 5m and 1 period", got.Remaining[shogi.Gote], got.Periods[shogi.Gote])
+	}
+	if limit := got.Limit(shogi.Gote); limit != 5*time.Minute+10*time.Second {
+		t.Errorf("Limit() = %s, want 5m10s", limit)
+	}
+}
@@ -0,0 +1,421 @@
+package csa
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k-yomo/shogi/shogi"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultAddr     = ":4081"
+	defaultMaxMoves = 256
+	// loginTimeout and logoutTimeout are how long the server waits for LOGIN and LOGOUT
+	loginTimeout  = time.Minute
+	logoutTimeout = 10 * time.Second
+)
+
+// agreeTimeout is how long the server waits for the reply to Game_Summary, which is a variable for the tests
+var agreeTimeout = time.Minute
+
+// Server is a CSA server which pairs logged-in clients in the order they log in, and plays the games between them.
+// The first client of a pair plays sente. Passwords aren't checked.
+type Server struct {
+	// Addr is the TCP address to listen on, ":4081" if it's empty
+	Addr string
+	// TimeControl is the time settings of the games, the zero value means no time limit
+	TimeControl TimeControl
+	// MaxMoves is the number of moves after which the game is drawn, 256 if it's zero
+	MaxMoves int
+	// RecordDir is the directory where the CSA records of the games are written, which are not written if it's empty
+	RecordDir string
+	// Logger logs the games and errors, which are discarded if it's nil
+	Logger *log.Logger
+
+	mu        sync.Mutex
+	listener  net.Listener
+	waiting   *client
+	gameCount int
+}
+
+type client struct {
+	name string
+	conn net.Conn
+	// lines receives the lines sent by the client, and it's closed when the connection is closed
+	lines     chan string
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// gameEnd is how the game ended
+type gameEnd struct {
+	// messages are sent to both players before the result
+	messages []string
+	// terminator is written at the end of the record
+	terminator string
+	// winner is nil for a draw
+	winner *client
+}
+
+func (s *Server) ListenAndServe() error {
+	addr := s.Addr
+	if addr == "" {
+		addr = defaultAddr
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrap(err, "listen")
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on the listener until it's closed
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	s.listener = l
+	s.mu.Unlock()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// Close stops accepting connections. The games being played continue until they end.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.waiting != nil {
+		s.waiting.close()
+		s.waiting = nil
+	}
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+func (s *Server) handle(conn net.Conn) {
+	c := &client{conn: conn, lines: make(chan string), done: make(chan struct{})}
+	go c.readLines()
+
+	var line string
+	select {
+	case l, ok := <-c.lines:
+		if !ok {
+			return
+		}
+		line = l
+	case <-time.After(loginTimeout):
+		c.close()
+		return
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 3 || fields[0] != "LOGIN" {
+		c.send("LOGIN:incorrect")
+		c.close()
+		return
+	}
+	c.name = fields[1]
+	c.send(fmt.Sprintf("LOGIN:%s OK", c.name))
+	s.logf("%s logged in from %s", c.name, conn.RemoteAddr())
+
+	s.mu.Lock()
+	opponent := s.waiting
+	if opponent != nil && !opponent.alive() {
+		s.logf("%s disconnected while waiting", opponent.name)
+		opponent.close()
+		opponent = nil
+	}
+	if opponent == nil {
+		s.waiting = c
+	} else {
+		s.waiting = nil
+		s.gameCount++
+	}
+	gameCount := s.gameCount
+	s.mu.Unlock()
+	if opponent != nil {
+		s.runGame(gameCount, opponent, c)
+	}
+}
+
+func (s *Server) runGame(n int, sente, gote *client) {
+	startTime := time.Now()
+	gameID := fmt.Sprintf("local-%d+%s+%s+%s", n, sente.name, gote.name, startTime.Format("20060102150405"))
+	game := shogi.NewGame()
+	maxMoves := s.MaxMoves
+	if maxMoves == 0 {
+		maxMoves = defaultMaxMoves
+	}
+	clients := [2]*client{sente, gote}
+	for _, c := range []shogi.Color{shogi.Sente, shogi.Gote} {
+		summary := &GameSummary{
+			GameID:      gameID,
+			SenteName:   sente.name,
+			GoteName:    gote.name,
+			YourTurn:    c,
+			MaxMoves:    maxMoves,
+			TimeControl: s.TimeControl,
+			Game:        game,
+		}
+		clients[c].send(strings.TrimSuffix(summary.String(), "\n"))
+	}
+
+	for _, c := range clients {
+		if !c.agrees() {
+			s.logf("%s rejected %s", c.name, gameID)
+			for _, other := range clients {
+				other.send(fmt.Sprintf("REJECT:%s by %s", gameID, c.name))
+				other.close()
+			}
+			return
+		}
+	}
+	for _, c := range clients {
+		c.send("START:" + gameID)
+	}
+	s.logf("%s started", gameID)
+
+	end := s.play(game, clients, maxMoves)
+	for _, c := range clients {
+		c.send(end.messages...)
+		switch end.winner {
+		case nil:
+			c.send("#DRAW")
+		case c:
+			c.send("#WIN")
+		default:
+			c.send("#LOSE")
+		}
+	}
+	s.logf("%s ended with %s", gameID, end.terminator)
+
+	if s.RecordDir != "" {
+		record := game.CSA(shogi.CSAInfo{
+			SenteName:  sente.name,
+			GoteName:   gote.name,
+			StartTime:  startTime,
+			EndTime:    time.Now(),
+			Terminator: end.terminator,
+		})
+		path := filepath.Join(s.RecordDir, gameID+".csa")
+		if err := ioutil.WriteFile(path, []byte(record), 0644); err != nil {
+			s.logf("failed to write the record of %s: %v", gameID, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, c := range clients {
+		wg.Add(1)
+		go func(c *client) {
+			defer wg.Done()
+			c.logout()
+		}(c)
+	}
+	wg.Wait()
+}
+
+// play exchanges moves until the game ends, and returns how it ended. The times consumed by the moves are recorded in the game.
+func (s *Server) play(game *shogi.Game, clients [2]*client, maxMoves int) *gameEnd {
+	remaining := [2]time.Duration{s.TimeControl.TotalTime, s.TimeControl.TotalTime}
+	for {
+		side := game.SideToMove()
+		c, other := clients[side], clients[side.Opponent()]
+		turnStarted := time.Now()
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if s.TimeControl != (TimeControl{}) {
+			// consumed time is truncated to seconds, so the time is up when a whole second passes over the limit
+			timer = time.NewTimer(remaining[side] + s.TimeControl.Byoyomi + time.Second)
+			timeout = timer.C
+		}
+		end, consumed := s.waitMove(game, c, other, turnStarted, remaining[side], timeout)
+		if timer != nil {
+			timer.Stop()
+		}
+		if end != nil {
+			return end
+		}
+
+		if consumed > remaining[side] {
+			remaining[side] = 0
+		} else {
+			remaining[side] -= consumed
+		}
+		remaining[side] += s.TimeControl.Increment
+		if result, ok := game.Result(); ok {
+			switch result.Reason {
+			case shogi.ReasonSennichite:
+				return &gameEnd{messages: []string{"#SENNICHITE"}, terminator: "%SENNICHITE"}
+			case shogi.ReasonPerpetualCheck:
+				return &gameEnd{
+					messages:   []string{"#OUTE_SENNICHITE"},
+					terminator: "%" + sign(result.Winner.Opponent()) + "ILLEGAL_ACTION",
+					winner:     clients[result.Winner],
+				}
+			}
+		}
+		if len(game.History()) >= maxMoves {
+			return &gameEnd{messages: []string{"#MAX_MOVES", "#CENSORED"}, terminator: "%HIKIWAKE"}
+		}
+	}
+}
+
+// waitMove waits for the move of c, the side to move, and plays it on the game.
+// It returns how the game ended instead if the move ended it.
+func (s *Server) waitMove(game *shogi.Game, c, other *client, turnStarted time.Time, remaining time.Duration, timeout <-chan time.Time) (end *gameEnd, consumed time.Duration) {
+	abnormal := func(winner *client) *gameEnd {
+		return &gameEnd{messages: []string{"#ABNORMAL"}, terminator: "%CHUDAN", winner: winner}
+	}
+	for {
+		select {
+		case line, ok := <-c.lines:
+			if !ok {
+				return abnormal(other), 0
+			}
+			if line == "" {
+				// empty lines are keep-alive
+				continue
+			}
+			consumed = time.Since(turnStarted) / time.Second * time.Second
+			if consumed > remaining+s.TimeControl.Byoyomi {
+				return &gameEnd{messages: []string{"#TIME_UP"}, terminator: "%TIME_UP", winner: other}, 0
+			}
+			// a comment may follow the move after a comma
+			if i := strings.Index(line, ","); i >= 0 {
+				line = line[:i]
+			}
+			if line == "%TORYO" {
+				message := fmt.Sprintf("%%TORYO,T%d", int(consumed/time.Second))
+				return &gameEnd{messages: []string{message, "#RESIGN"}, terminator: "%TORYO", winner: other}, consumed
+			}
+			if line == "%KACHI" {
+				result, _ := game.DeclareWin()
+				if result.Reason == shogi.ReasonDeclaration {
+					return &gameEnd{messages: []string{"%KACHI", "#JISHOGI"}, terminator: "%KACHI", winner: c}, consumed
+				}
+				s.logf("invalid declaration by %s", c.name)
+				return &gameEnd{messages: []string{"#ILLEGAL_MOVE"}, terminator: "%ILLEGAL_MOVE", winner: other}, 0
+			}
+			move, err := game.Board().ParseCSAMove(line)
+			if err == nil {
+				err = game.ApplyMoveWithTime(move, consumed)
+			}
+			if err != nil {
+				s.logf("illegal move %q by %s: %v", line, c.name, err)
+				return &gameEnd{messages: []string{"#ILLEGAL_MOVE"}, terminator: "%ILLEGAL_MOVE", winner: other}, 0
+			}
+			message := fmt.Sprintf("%s,T%d", line, int(consumed/time.Second))
+			c.send(message)
+			other.send(message)
+			return nil, consumed
+		case _, ok := <-other.lines:
+			// the opponent can't do anything but wait during the turn
+			if !ok {
+				return abnormal(c), 0
+			}
+		case <-timeout:
+			return &gameEnd{messages: []string{"#TIME_UP"}, terminator: "%TIME_UP", winner: other}, 0
+		}
+	}
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.Logger != nil {
+		s.Logger.Printf(format, args...)
+	}
+}
+
+func (c *client) readLines() {
+	defer close(c.lines)
+	scanner := bufio.NewScanner(c.conn)
+	for scanner.Scan() {
+		select {
+		case c.lines <- strings.TrimRight(scanner.Text(), "\r"):
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *client) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// send writes the lines to the client. Errors are ignored since a broken connection is found when reading.
+func (c *client) send(lines ...string) {
+	for _, line := range lines {
+		fmt.Fprint(c.conn, line+"\n")
+	}
+}
+
+// alive reports if the client waiting for the opponent is still connected, discarding the keep-alive lines it sent
+func (c *client) alive() bool {
+	for {
+		select {
+		case _, ok := <-c.lines:
+			if !ok {
+				return false
+			}
+		default:
+			return true
+		}
+	}
+}
+
+// agrees waits for the reply to Game_Summary, and reports if the client agreed in agreeTimeout
+func (c *client) agrees() bool {
+	timeout := time.After(agreeTimeout)
+	for {
+		select {
+		case line, ok := <-c.lines:
+			if !ok {
+				return false
+			}
+			switch fields := strings.Fields(line); {
+			case len(fields) == 0:
+				continue
+			case fields[0] == "AGREE":
+				return true
+			default:
+				return false
+			}
+		case <-timeout:
+			return false
+		}
+	}
+}
+
+// logout waits for LOGOUT after the game, and closes the connection
+func (c *client) logout() {
+	defer c.close()
+	timeout := time.After(logoutTimeout)
+	for {
+		select {
+		case line, ok := <-c.lines:
+			if !ok {
+				return
+			}
+			if line == "LOGOUT" {
+				c.send("LOGOUT:completed")
+				return
+			}
+		case <-timeout:
+			return
+		}
+	}
+}
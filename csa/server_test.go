@@ -0,0 +1,230 @@
+package csa
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k-yomo/shogi/shogi"
+)
+
+// startServer serves on a local port until the test ends
+func startServer(t *testing.T, s *Server) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(l)
+	return l.Addr().String()
+}
+
+func TestServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "csa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tc := TimeControl{TotalTime: time.Minute, Byoyomi: 10 * time.Second}
+	server := &Server{TimeControl: tc, RecordDir: dir}
+	addr := startServer(t, server)
+	defer server.Close()
+
+	// sente plays ▲７六歩 and gote resigns
+	chooser := MoveChooserFunc(func(ctx context.Context, game *shogi.Game, timeLeft TimeLeft) (shogi.Move, bool, error) {
+		if game.SideToMove() == shogi.Gote || len(game.History()) > 0 {
+			return shogi.Move{}, true, nil
+		}
+		move, err := game.Board().ParseMove("7g7f")
+		return move, false, err
+	})
+	var results [2]*GameResult
+	var wg sync.WaitGroup
+	for _, name := range []string{"alice", "bob"} {
+		client, err := Dial(addr, name, "pass")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(client *Client) {
+			defer wg.Done()
+			result, err := client.PlayGame(context.Background(), chooser)
+			if err != nil {
+				t.Errorf("PlayGame() error = %v", err)
+				client.Close()
+				return
+			}
+			results[result.Summary.YourTurn] = result
+			if err := client.Logout(); err != nil {
+				t.Errorf("Logout() error = %v", err)
+			}
+		}(client)
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+
+	for c, want := range map[shogi.Color]string{shogi.Sente: "WIN", shogi.Gote: "LOSE"} {
+		result := results[c]
+		if result == nil {
+			t.Fatalf("no result for %s", c)
+		}
+		if result.Result != want || result.Reason != "RESIGN" {
+			t.Errorf("result of %s = %s %s, want %s RESIGN", c, result.Result, result.Reason, want)
+		}
+		if result.Summary.TimeControl != tc {
+			t.Errorf("TimeControl = %+v, want %+v", result.Summary.TimeControl, tc)
+		}
+	}
+
+	records, err := filepath.Glob(filepath.Join(dir, "*.csa"))
+	if err != nil || len(records) != 1 {
+		t.Fatalf("records = %v, %v, want one record", records, err)
+	}
+	data, err := ioutil.ReadFile(records[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	game, err := shogi.ParseCSA(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(game.History()); got != 1 {
+		t.Errorf("moves in the record = %d, want 1", got)
+	}
+	if result, ok := game.Result(); !ok || result.Reason != shogi.ReasonResignation || result.Winner != shogi.Sente {
+		t.Errorf("result in the record = %v, %v, want the resignation of gote", result, ok)
+	}
+}
+
+func TestServer_IllegalMove(t *testing.T) {
+	server := &Server{}
+	addr := startServer(t, server)
+	defer server.Close()
+
+	// sente tries to move the pawn two squares
+	chooser := MoveChooserFunc(func(ctx context.Context, game *shogi.Game, timeLeft TimeLeft) (shogi.Move, bool, error) {
+		return shogi.Move{From: shogi.NewSquare(7, 7), To: shogi.NewSquare(7, 5), Piece: shogi.KindPawn}, false, nil
+	})
+	var results [2]*GameResult
+	var wg sync.WaitGroup
+	for _, name := range []string{"alice", "bob"} {
+		client, err := Dial(addr, name, "pass")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(client *Client) {
+			defer wg.Done()
+			defer client.Close()
+			result, err := client.PlayGame(context.Background(), chooser)
+			if err != nil {
+				t.Errorf("PlayGame() error = %v", err)
+				return
+			}
+			results[result.Summary.YourTurn] = result
+		}(client)
+	}
+	wg.Wait()
+
+	for c, want := range map[shogi.Color]string{shogi.Sente: "LOSE", shogi.Gote: "WIN"} {
+		if result := results[c]; result == nil || result.Result != want || result.Reason != "ILLEGAL_MOVE" {
+			t.Errorf("result of %s = %+v, want %s ILLEGAL_MOVE", c, result, want)
+		}
+	}
+}
+
+func TestServer_WaitingClientDisconnected(t *testing.T) {
+	server := &Server{}
+	addr := startServer(t, server)
+	defer server.Close()
+
+	gone, err := Dial(addr, "carol", "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gone.Close()
+	// the server finds the connection closed while carol is waiting
+	time.Sleep(100 * time.Millisecond)
+
+	chooser := MoveChooserFunc(func(ctx context.Context, game *shogi.Game, timeLeft TimeLeft) (shogi.Move, bool, error) {
+		return shogi.Move{}, true, nil
+	})
+	var summaries [2]*GameSummary
+	var wg sync.WaitGroup
+	for i, name := range []string{"alice", "bob"} {
+		client, err := Dial(addr, name, "pass")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(i int, client *Client) {
+			defer wg.Done()
+			defer client.Close()
+			result, err := client.PlayGame(context.Background(), chooser)
+			if err != nil {
+				t.Errorf("PlayGame() error = %v", err)
+				return
+			}
+			summaries[i] = result.Summary
+		}(i, client)
+		// alice logs in first to play sente
+		time.Sleep(50 * time.Millisecond)
+	}
+	wg.Wait()
+
+	for _, summary := range summaries {
+		if summary != nil && (summary.SenteName != "alice" || summary.GoteName != "bob") {
+			t.Errorf("players = %s and %s, want alice and bob", summary.SenteName, summary.GoteName)
+		}
+	}
+}
+
+func TestServer_AgreeTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { agreeTimeout = timeout }(agreeTimeout)
+	agreeTimeout = 100 * time.Millisecond
+	server := &Server{}
+	addr := startServer(t, server)
+	defer server.Close()
+
+	// alice agrees, and bob never replies to Game_Summary
+	var wg sync.WaitGroup
+	for _, name := range []string{"alice", "bob"} {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		fmt.Fprintf(conn, "LOGIN %s pass\n", name)
+		wg.Add(1)
+		go func(name string, conn net.Conn) {
+			defer wg.Done()
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				line := scanner.Text()
+				if strings.HasPrefix(line, "Game_ID:") && name == "alice" {
+					fmt.Fprintf(conn, "AGREE %s\n", strings.TrimPrefix(line, "Game_ID:"))
+				}
+				if strings.HasPrefix(line, "REJECT:") {
+					if !strings.HasSuffix(line, " by bob") {
+						t.Errorf("%s received %q, want the rejection by bob", name, line)
+					}
+					return
+				}
+			}
+			t.Errorf("%s didn't receive REJECT: %v", name, scanner.Err())
+		}(name, conn)
+		time.Sleep(50 * time.Millisecond)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,177 @@
+// Package csa implements the CSA server protocol used in computer shogi matches such as floodgate.
+package csa
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k-yomo/shogi/shogi"
+	"github.com/pkg/errors"
+)
+
+// TimeControl is the time settings of a game in the CSA protocol, which are whole seconds
+type TimeControl struct {
+	// TotalTime is the main time of each player
+	TotalTime time.Duration
+	// Byoyomi is the time given for each move after the main time runs out
+	Byoyomi time.Duration
+	// Increment is the time added after each move (Fischer)
+	Increment time.Duration
+}
+
+// GameSummary is the game information sent by the server before the game starts
+type GameSummary struct {
+	GameID    string
+	SenteName string
+	GoteName  string
+	// YourTurn is the color of the player the summary is sent to
+	YourTurn    shogi.Color
+	MaxMoves    int
+	TimeControl TimeControl
+	// Game is the game to play, which starts from the position and the moves in the summary
+	Game *shogi.Game
+}
+
+// String returns the summary as the Game_Summary message
+func (s *GameSummary) String() string {
+	var sb strings.Builder
+	line := func(format string, args ...interface{}) {
+		sb.WriteString(fmt.Sprintf(format, args...) + "\n")
+	}
+	line("BEGIN Game_Summary")
+	line("Protocol_Version:1.2")
+	line("Protocol_Mode:Server")
+	line("Format:Shogi 1.0")
+	line("Game_ID:%s", s.GameID)
+	line("Name+:%s", s.SenteName)
+	line("Name-:%s", s.GoteName)
+	line("Your_Turn:%s", sign(s.YourTurn))
+	line("Rematch_On_Draw:NO")
+	line("To_Move:%s", sign(s.Game.SideToMove()))
+	if s.MaxMoves > 0 {
+		line("Max_Moves:%d", s.MaxMoves)
+	}
+	line("BEGIN Time")
+	line("Time_Unit:1sec")
+	line("Total_Time:%d", int(s.TimeControl.TotalTime/time.Second))
+	line("Byoyomi:%d", int(s.TimeControl.Byoyomi/time.Second))
+	if s.TimeControl.Increment > 0 {
+		line("Increment:%d", int(s.TimeControl.Increment/time.Second))
+	}
+	line("END Time")
+	line("BEGIN Position")
+	board := s.Game.InitialBoard()
+	sb.WriteString(board.CSAPosition())
+	for _, m := range s.Game.History() {
+		line("%s", board.CSAMove(m))
+		board.DoMove(m)
+	}
+	line("END Position")
+	line("END Game_Summary")
+	return sb.String()
+}
+
+func sign(c shogi.Color) string {
+	if c == shogi.Sente {
+		return "+"
+	}
+	return "-"
+}
+
+// ParseGameSummary parses the lines of the Game_Summary message, from BEGIN Game_Summary to END Game_Summary
+func ParseGameSummary(lines []string) (*GameSummary, error) {
+	s := &GameSummary{}
+	var position []string
+	var timeUnit time.Duration = time.Second
+	var totalTime, byoyomi, increment int
+	section := ""
+	for _, line := range lines {
+		switch line {
+		case "BEGIN Game_Summary", "END Game_Summary", "BEGIN Time", "END Time":
+			section = strings.TrimPrefix(strings.TrimPrefix(line, "BEGIN "), "END ")
+			continue
+		case "BEGIN Position":
+			section = "Position"
+			continue
+		case "END Position":
+			section = ""
+			continue
+		}
+		if section == "Position" {
+			position = append(position, line)
+			continue
+		}
+
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		key, value := line[:i], line[i+1:]
+		var err error
+		switch key {
+		case "Game_ID":
+			s.GameID = value
+		case "Name+":
+			s.SenteName = value
+		case "Name-":
+			s.GoteName = value
+		case "Your_Turn":
+			s.YourTurn, err = parseSign(value)
+		case "Max_Moves":
+			s.MaxMoves, err = strconv.Atoi(value)
+		case "Time_Unit":
+			timeUnit, err = parseTimeUnit(value)
+		case "Total_Time":
+			totalTime, err = strconv.Atoi(value)
+		case "Byoyomi":
+			byoyomi, err = strconv.Atoi(value)
+		case "Increment":
+			increment, err = strconv.Atoi(value)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse game summary: %q", line)
+		}
+	}
+	s.TimeControl = TimeControl{
+		TotalTime: time.Duration(totalTime) * timeUnit,
+		Byoyomi:   time.Duration(byoyomi) * timeUnit,
+		Increment: time.Duration(increment) * timeUnit,
+	}
+	game, err := shogi.ParseCSA(strings.Join(position, "\n"))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse game summary")
+	}
+	s.Game = game
+	return s, nil
+}
+
+func parseSign(s string) (shogi.Color, error) {
+	switch s {
+	case "+":
+		return shogi.Sente, nil
+	case "-":
+		return shogi.Gote, nil
+	default:
+		return shogi.Sente, errors.Errorf("invalid sign: %q", s)
+	}
+}
+
+// parseTimeUnit parses Time_Unit such as 1sec, 1min and 1msec
+func parseTimeUnit(s string) (time.Duration, error) {
+	// msec must be checked before sec
+	for _, u := range []struct {
+		suffix string
+		unit   time.Duration
+	}{{"msec", time.Millisecond}, {"sec", time.Second}, {"min", time.Minute}} {
+		if strings.HasSuffix(s, u.suffix) {
+			n, err := strconv.Atoi(strings.TrimSuffix(s, u.suffix))
+			if err != nil {
+				return 0, errors.Errorf("invalid time unit: %q", s)
+			}
+			return time.Duration(n) * u.unit, nil
+		}
+	}
+	return 0, errors.Errorf("invalid time unit: %q", s)
+}
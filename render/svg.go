@@ -0,0 +1,301 @@
+// Package render draws shogi positions for people, as SVG images or colored terminal text.
+package render
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/k-yomo/shogi/shogi"
+)
+
+// Theme is the set of colors and the font of an SVG diagram. Colors are any SVG color value, and the values are
+// escaped for XML when written.
+type Theme struct {
+	Background     string
+	Board          string
+	Line           string
+	Text           string
+	PieceFill      string
+	PieceStroke    string
+	PieceText      string
+	PromotedText   string
+	Highlight      string
+	Arrow          string
+	FontFamily     string
+	CoordinateFont string
+}
+
+// DefaultTheme looks like a wooden board
+var DefaultTheme = Theme{
+	Background:     "#ffffff",
+	Board:          "#f3d28b",
+	Line:           "#333333",
+	Text:           "#333333",
+	PieceFill:      "#fbe6b8",
+	PieceStroke:    "#7a5c2e",
+	PieceText:      "#000000",
+	PromotedText:   "#cc0000",
+	Highlight:      "#f7a35c",
+	Arrow:          "#2060c0",
+	FontFamily:     "serif",
+	CoordinateFont: "sans-serif",
+}
+
+// MonochromeTheme is suitable for printing
+var MonochromeTheme = Theme{
+	Background:     "#ffffff",
+	Board:          "#ffffff",
+	Line:           "#000000",
+	Text:           "#000000",
+	PieceFill:      "#ffffff",
+	PieceStroke:    "#000000",
+	PieceText:      "#000000",
+	PromotedText:   "#000000",
+	Highlight:      "#d0d0d0",
+	Arrow:          "#606060",
+	FontFamily:     "serif",
+	CoordinateFont: "sans-serif",
+}
+
+// attrEscaper escapes the strings written into the attribute values
+var attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&#39;")
+
+// escape escapes the colors and the fonts to be written into the attribute values
+func (t *Theme) escape() {
+	for _, s := range []*string{
+		&t.Background, &t.Board, &t.Line, &t.Text, &t.PieceFill, &t.PieceStroke, &t.PieceText, &t.PromotedText,
+		&t.Highlight, &t.Arrow, &t.FontFamily, &t.CoordinateFont,
+	} {
+		*s = attrEscaper.Replace(*s)
+	}
+}
+
+// Arrow is an arrow drawn from the center of a square to another
+type Arrow struct {
+	From shogi.Square
+	To   shogi.Square
+	// Color overrides the theme's arrow color if it's not empty
+	Color string
+}
+
+// SVGOptions configures an SVG diagram
+type SVGOptions struct {
+	// SquareSize is the width of a square in pixels. It defaults to 40.
+	SquareSize int
+	// Theme defaults to DefaultTheme
+	Theme *Theme
+	// HighlightLastMove highlights the squares the last move is from and to
+	HighlightLastMove bool
+	Arrows            []Arrow
+}
+
+const defaultSquareSize = 40
+
+// GameSVG draws the current position of the game as an SVG image
+func GameSVG(g *shogi.Game, opts SVGOptions) string {
+	var lastMove *shogi.Move
+	if m, ok := g.LastMove(); ok {
+		lastMove = &m
+	}
+	return BoardSVG(g.Board(), lastMove, opts)
+}
+
+// BoardSVG draws the position as an SVG image. lastMove is highlighted if it's not nil and HighlightLastMove is set.
+// The output only depends on the arguments, so it can be compared with a saved snapshot.
+func BoardSVG(b *shogi.BitboardBoard, lastMove *shogi.Move, opts SVGOptions) string {
+	d := newSVGDiagram(opts)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s">`+"\n",
+		num(d.width), num(d.height), num(d.width), num(d.height))
+	fmt.Fprintf(&sb, `<rect width="%s" height="%s" fill="%s"/>`+"\n", num(d.width), num(d.height), d.theme.Background)
+
+	d.writeBoard(&sb)
+	if opts.HighlightLastMove && lastMove != nil {
+		if !lastMove.Drop {
+			d.writeHighlight(&sb, lastMove.From)
+		}
+		d.writeHighlight(&sb, lastMove.To)
+	}
+	d.writeGrid(&sb)
+	for sq := shogi.Square(0); sq < shogi.NumSquares; sq++ {
+		if kind, color := b.PieceAt(sq); kind != shogi.KindNone {
+			x, y := d.squareCenter(sq)
+			d.writePiece(&sb, x, y, kind, color)
+		}
+	}
+	d.writeHand(&sb, b, shogi.Gote)
+	d.writeHand(&sb, b, shogi.Sente)
+	for i, arrow := range opts.Arrows {
+		d.writeArrow(&sb, i, arrow)
+	}
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+type svgDiagram struct {
+	theme Theme
+	// s is the size of a square
+	s float64
+	// boardX and boardY are the top left corner of the board
+	boardX, boardY float64
+	handWidth      float64
+	width, height  float64
+}
+
+func newSVGDiagram(opts SVGOptions) *svgDiagram {
+	size := opts.SquareSize
+	if size <= 0 {
+		size = defaultSquareSize
+	}
+	theme := DefaultTheme
+	if opts.Theme != nil {
+		theme = *opts.Theme
+	}
+	theme.escape()
+	s := float64(size)
+	d := &svgDiagram{theme: theme, s: s, handWidth: s * 1.5}
+	coordinateWidth := s * 0.6
+	d.boardX = d.handWidth + s*0.25
+	d.boardY = coordinateWidth
+	d.width = d.boardX + 9*s + coordinateWidth + s*0.25 + d.handWidth
+	d.height = d.boardY + 9*s + s*0.25
+	return d
+}
+
+// squareCenter returns the center of the square. File 9 is on the left and rank 1 is at the top.
+func (d *svgDiagram) squareCenter(sq shogi.Square) (x, y float64) {
+	return d.boardX + float64(9-int(sq.X()))*d.s + d.s/2, d.boardY + float64(sq.Y()-1)*d.s + d.s/2
+}
+
+func (d *svgDiagram) writeBoard(sb *strings.Builder) {
+	fmt.Fprintf(sb, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`+"\n",
+		num(d.boardX), num(d.boardY), num(9*d.s), num(9*d.s), d.theme.Board)
+}
+
+func (d *svgDiagram) writeHighlight(sb *strings.Builder, sq shogi.Square) {
+	x, y := d.squareCenter(sq)
+	fmt.Fprintf(sb, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s" fill-opacity="0.6"/>`+"\n",
+		num(x-d.s/2), num(y-d.s/2), num(d.s), num(d.s), d.theme.Highlight)
+}
+
+func (d *svgDiagram) writeGrid(sb *strings.Builder) {
+	fmt.Fprintf(sb, `<g stroke="%s" stroke-width="1">`+"\n", d.theme.Line)
+	for i := 0; i <= 9; i++ {
+		offset := float64(i) * d.s
+		fmt.Fprintf(sb, `<line x1="%s" y1="%s" x2="%s" y2="%s"/>`+"\n",
+			num(d.boardX+offset), num(d.boardY), num(d.boardX+offset), num(d.boardY+9*d.s))
+		fmt.Fprintf(sb, `<line x1="%s" y1="%s" x2="%s" y2="%s"/>`+"\n",
+			num(d.boardX), num(d.boardY+offset), num(d.boardX+9*d.s), num(d.boardY+offset))
+	}
+	sb.WriteString("</g>\n")
+
+	// star points
+	for _, p := range [][2]float64{{3, 3}, {6, 3}, {3, 6}, {6, 6}} {
+		fmt.Fprintf(sb, `<circle cx="%s" cy="%s" r="%s" fill="%s"/>`+"\n",
+			num(d.boardX+p[0]*d.s), num(d.boardY+p[1]*d.s), num(d.s/16), d.theme.Line)
+	}
+
+	fontSize := d.s * 0.35
+	fmt.Fprintf(sb, `<g font-family="%s" font-size="%s" fill="%s" text-anchor="middle">`+"\n",
+		d.theme.CoordinateFont, num(fontSize), d.theme.Text)
+	for x := shogi.Axis(1); x <= 9; x++ {
+		cx, _ := d.squareCenter(shogi.NewSquare(x, 1))
+		fmt.Fprintf(sb, `<text x="%s" y="%s">%d</text>`+"\n", num(cx), num(d.boardY-fontSize*0.6), x)
+	}
+	for y := shogi.Axis(1); y <= 9; y++ {
+		_, cy := d.squareCenter(shogi.NewSquare(1, y))
+		fmt.Fprintf(sb, `<text x="%s" y="%s">%s</text>`+"\n",
+			num(d.boardX+9*d.s+fontSize), num(cy+fontSize*0.35), kanjiNumerals[y])
+	}
+	sb.WriteString("</g>\n")
+}
+
+// writePiece draws a pentagon pointing to the opponent with the piece's name. Gote's pieces are rotated.
+func (d *svgDiagram) writePiece(sb *strings.Builder, cx, cy float64, kind shogi.PieceKind, color shogi.Color) {
+	w, h := d.s*0.8, d.s*0.88
+	points := [][2]float64{
+		{cx, cy - h/2},
+		{cx + w*0.36, cy - h*0.34},
+		{cx + w/2, cy + h/2},
+		{cx - w/2, cy + h/2},
+		{cx - w*0.36, cy - h*0.34},
+	}
+	pointStrs := make([]string, len(points))
+	for i, p := range points {
+		pointStrs[i] = num(p[0]) + "," + num(p[1])
+	}
+	transform := ""
+	if color == shogi.Gote {
+		transform = fmt.Sprintf(` transform="rotate(180 %s %s)"`, num(cx), num(cy))
+	}
+	textColor := d.theme.PieceText
+	if kind.IsPromoted() {
+		textColor = d.theme.PromotedText
+	}
+	fmt.Fprintf(sb, `<g%s><polygon points="%s" fill="%s" stroke="%s" stroke-width="1"/>`,
+		transform, strings.Join(pointStrs, " "), d.theme.PieceFill, d.theme.PieceStroke)
+	fmt.Fprintf(sb, `<text x="%s" y="%s" font-family="%s" font-size="%s" fill="%s" text-anchor="middle">%s</text></g>`+"\n",
+		num(cx), num(cy+d.s*0.22), d.theme.FontFamily, num(d.s*0.55), textColor, pieceName(kind, color))
+}
+
+// writeHand draws the pieces in hand, gote's on the top left and sente's on the bottom right of the board.
+// The pieces point to the opponent like the ones on the board.
+func (d *svgDiagram) writeHand(sb *strings.Builder, b *shogi.BitboardBoard, color shogi.Color) {
+	cx := d.handWidth / 2
+	labelY := d.boardY + d.s*0.4
+	pieceY := d.boardY + d.s
+	step := d.s
+	if color == shogi.Sente {
+		cx = d.width - d.handWidth/2
+		labelY = d.boardY + 9*d.s - d.s*0.1
+		pieceY = d.boardY + 8*d.s
+		step = -d.s
+	}
+	fmt.Fprintf(sb, `<text x="%s" y="%s" font-family="%s" font-size="%s" fill="%s" text-anchor="middle">%s%s</text>`+"\n",
+		num(cx), num(labelY), d.theme.FontFamily, num(d.s*0.4), d.theme.Text, color.Marker(), color)
+	for _, kind := range shogi.HandKinds {
+		n := b.Hand(color, kind)
+		if n == 0 {
+			continue
+		}
+		d.writePiece(sb, cx-d.s*0.2, pieceY, kind, color)
+		if n > 1 {
+			fmt.Fprintf(sb, `<text x="%s" y="%s" font-family="%s" font-size="%s" fill="%s">%d</text>`+"\n",
+				num(cx+d.s*0.3), num(pieceY+d.s*0.15), d.theme.CoordinateFont, num(d.s*0.35), d.theme.Text, n)
+		}
+		pieceY += step
+	}
+}
+
+func (d *svgDiagram) writeArrow(sb *strings.Builder, i int, arrow Arrow) {
+	if !arrow.From.IsValid() || !arrow.To.IsValid() {
+		return
+	}
+	color := d.theme.Arrow
+	if arrow.Color != "" {
+		color = attrEscaper.Replace(arrow.Color)
+	}
+	x1, y1 := d.squareCenter(arrow.From)
+	x2, y2 := d.squareCenter(arrow.To)
+	fmt.Fprintf(sb, `<defs><marker id="arrowhead-%d" viewBox="0 0 10 10" refX="8" refY="5" markerWidth="4" markerHeight="4" orient="auto"><path d="M0,0 L10,5 L0,10 z" fill="%s"/></marker></defs>`+"\n",
+		i, color)
+	fmt.Fprintf(sb, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s" stroke-width="%s" stroke-opacity="0.8" marker-end="url(#arrowhead-%d)"/>`+"\n",
+		num(x1), num(y1), num(x2), num(y2), color, num(d.s/8), i)
+}
+
+var kanjiNumerals = []string{"", "一", "二", "三", "四", "五", "六", "七", "八", "九"}
+
+// pieceName returns the single character name of the piece, 玉 for sente's king and 王 for gote's king
+func pieceName(kind shogi.PieceKind, color shogi.Color) string {
+	if kind == shogi.KindKing && color == shogi.Gote {
+		return "王"
+	}
+	return kind.ShortName()
+}
+
+// num formats the number rounded to 2 decimal places in the shortest form, so the output is stable
+func num(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,73 @@
+package render
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/k-yomo/shogi/shogi"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestBoardSVG(t *testing.T) {
+	customTheme := DefaultTheme
+	customTheme.FontFamily = `"Noto Serif JP", serif`
+	customTheme.CoordinateFont = "Hiragino <Sans> & Co"
+
+	tests := []struct {
+		name string
+		sfen string
+		// moves are played from the SFEN in USI
+		moves []string
+		opts  SVGOptions
+	}{
+		{name: "initial", sfen: "lnsgkgsnl/1r5b1/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL b - 1"},
+		{
+			name:  "hands",
+			sfen:  "lnsgkgsnl/7b1/ppppppp2/9/9/9/PPPPPPP2/1B7/LNSGKGSNL b R2Pr2p 1",
+			moves: []string{"6g6f", "4c4d"},
+			opts: SVGOptions{
+				SquareSize:        30,
+				HighlightLastMove: true,
+				Arrows:            []Arrow{{From: shogi.NewSquare(5, 5), To: shogi.NewSquare(5, 4)}, {From: shogi.NewSquare(2, 8), To: shogi.NewSquare(2, 4), Color: `red" onload="alert(1)`}},
+			},
+		},
+		{name: "monochrome", sfen: "4k4/9/9/9/9/9/9/9/4K4 b 2R2Pb2g3p 1", opts: SVGOptions{Theme: &MonochromeTheme}},
+		{name: "escaped_theme", sfen: "4k4/9/9/9/9/9/9/9/4K4 b - 1", opts: SVGOptions{Theme: &customTheme}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			game, err := shogi.NewGameFromSFEN(tt.sfen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, usi := range tt.moves {
+				m, err := game.Board().ParseMove(usi)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := game.ApplyMove(m); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got := GameSVG(game, tt.opts)
+
+			golden := filepath.Join("testdata", tt.name+".svg")
+			if *update {
+				if err := ioutil.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("GameSVG() differs from %s, run go test -update to see the change:\n%s", golden, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package render
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/k-yomo/shogi/shogi"
+)
+
+const (
+	ansiReset        = "\x1b[0m"
+	ansiSente        = "\x1b[1;34m"
+	ansiGote         = "\x1b[1;31m"
+	ansiLastMove     = "\x1b[43m"
+	ansiMovable      = "\x1b[42m"
+	ansiSelected     = "\x1b[46m"
+	ansiCheckedKing  = "\x1b[41;97m"
+	ansiCursor       = "\x1b[7m"
+	ansiCoordinate   = "\x1b[2m"
+	terminalEmpty    = "・"
+	terminalFiles    = "  ９ ８ ７ ６ ５ ４ ３ ２ １"
+	terminalBorder   = "+---------------------------+"
+	plainFiles       = "   ９  ８  ７  ６  ５  ４  ３  ２  １"
+	plainBorder      = "+------------------------------------+"
+	plainGoteMarker  = "v"
+	plainCursorMark  = ">"
+	plainMovableMark = "*"
+	plainCheckMark   = "!"
+	plainLastMark    = "+"
+)
+
+// HandPiece identifies the pieces of a kind in a player's hand
+type HandPiece struct {
+	Color shogi.Color
+	Kind  shogi.PieceKind
+}
+
+// TerminalOptions configures the terminal renderer
+type TerminalOptions struct {
+	// Color enables ANSI escape sequences. Without it, gote's pieces are marked with "v" as in BOD,
+	// and the column before the marker marks the cursor with ">", the squares the selected piece can move to with "*",
+	// the checked king with "!" and the squares of the last move with "+", in that order of priority.
+	Color bool
+	// Selected is the position of the selected piece whose movable squares are highlighted, or nil
+	Selected *shogi.Position
+	// SelectedHand is the selected piece in hand whose drop squares are highlighted, or nil
+	SelectedHand *HandPiece
+	// Cursor is the square the cursor is on, or nil
+	Cursor *shogi.Position
+	// HandCursor is the piece in hand the cursor is on, or nil. Without colors it's enclosed in brackets.
+	HandCursor *HandPiece
+	// HighlightLastMove highlights the squares the last move is from and to
+	HighlightLastMove bool
+}
+
+// IsTerminal reports if the file is a terminal rather than a pipe or a regular file
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// TerminalOptionsFor returns the options enabling colors only if the file is a terminal and NO_COLOR isn't set
+func TerminalOptionsFor(f *os.File) TerminalOptions {
+	return TerminalOptions{
+		Color:             IsTerminal(f) && os.Getenv("NO_COLOR") == "",
+		HighlightLastMove: true,
+	}
+}
+
+// Terminal renders the current position of the game for terminals.
+// Every square is 3 columns wide, a marker column followed by a full-width character, so the board is aligned.
+// Without colors, every square has one more column for the highlights before the marker column.
+func Terminal(g *shogi.Game, opts TerminalOptions) string {
+	b := g.Board()
+	r := &terminalRenderer{opts: opts, board: b}
+	if m, ok := g.LastMove(); ok && opts.HighlightLastMove {
+		r.lastMove = &m
+	}
+	if opts.Selected != nil {
+		for _, pos := range b.PieceMovablePosition(opts.Selected) {
+			r.movable.Set(shogi.SquareOf(pos))
+		}
+	}
+	if opts.SelectedHand != nil && opts.SelectedHand.Color == b.SideToMove() {
+		for _, m := range b.LegalMoves() {
+			if m.Drop && m.Piece == opts.SelectedHand.Kind {
+				r.movable.Set(m.To)
+			}
+		}
+	}
+	if b.InCheck() {
+		r.checkedKing = b.KingSquare(b.SideToMove())
+	} else {
+		r.checkedKing = shogi.NoSquare
+	}
+
+	files, border := terminalFiles, terminalBorder
+	if !opts.Color {
+		files, border = plainFiles, plainBorder
+	}
+	var sb strings.Builder
+	sb.WriteString("後手の持駒：" + r.hand(shogi.Gote) + "\n")
+	sb.WriteString(r.coordinate(files) + "\n")
+	sb.WriteString(border + "\n")
+	for y := shogi.Axis(1); y <= 9; y++ {
+		sb.WriteString("|")
+		for x := shogi.Axis(9); x >= 1; x-- {
+			sb.WriteString(r.square(shogi.NewSquare(x, y)))
+		}
+		sb.WriteString("|" + r.coordinate(kanjiNumerals[y]) + "\n")
+	}
+	sb.WriteString(border + "\n")
+	sb.WriteString("先手の持駒：" + r.hand(shogi.Sente) + "\n")
+
+	status := fmt.Sprintf("手数＝%d  %s番", g.MoveNumber()-1, b.SideToMove())
+	if notations := g.JapaneseMoves(); len(notations) > 0 {
+		status += fmt.Sprintf("  前の手：%s%s", b.SideToMove().Opponent().Marker(), notations[len(notations)-1])
+	}
+	if b.InCheck() {
+		status += "  王手"
+	}
+	sb.WriteString(status + "\n")
+	return sb.String()
+}
+
+type terminalRenderer struct {
+	opts        TerminalOptions
+	board       *shogi.BitboardBoard
+	lastMove    *shogi.Move
+	movable     shogi.Bitboard
+	checkedKing shogi.Square
+}
+
+func (r *terminalRenderer) square(sq shogi.Square) string {
+	kind, color := r.board.PieceAt(sq)
+	name := terminalEmpty
+	if kind != shogi.KindNone {
+		name = pieceName(kind, color)
+	}
+
+	isCursor := r.opts.Cursor != nil && sq == shogi.SquareOf(r.opts.Cursor)
+	isLastMove := r.lastMove != nil && (sq == r.lastMove.To || (!r.lastMove.Drop && sq == r.lastMove.From))
+	if !r.opts.Color {
+		highlight := " "
+		switch {
+		case isCursor:
+			highlight = plainCursorMark
+		case r.movable.Has(sq):
+			highlight = plainMovableMark
+		case sq == r.checkedKing:
+			highlight = plainCheckMark
+		case isLastMove:
+			highlight = plainLastMark
+		}
+		marker := " "
+		if kind != shogi.KindNone && color == shogi.Gote {
+			marker = plainGoteMarker
+		}
+		return highlight + marker + name
+	}
+
+	var style string
+	if kind != shogi.KindNone {
+		style = ansiSente
+		if color == shogi.Gote {
+			style = ansiGote
+		}
+	}
+	switch {
+	case sq == r.checkedKing:
+		style += ansiCheckedKing
+	case r.opts.Selected != nil && sq == shogi.SquareOf(r.opts.Selected):
+		style += ansiSelected
+	case r.movable.Has(sq):
+		style += ansiMovable
+	case isLastMove:
+		style += ansiLastMove
+	}
+	if isCursor {
+		style += ansiCursor
+	}
+	if style == "" {
+		return " " + name
+	}
+	// the marker column is included in the highlight so the square looks like a block
+	return style + " " + name + ansiReset
+}
+
+func (r *terminalRenderer) coordinate(s string) string {
+	if !r.opts.Color {
+		return s
+	}
+	return ansiCoordinate + s + ansiReset
+}
+
+func (r *terminalRenderer) hand(c shogi.Color) string {
+	var pieces []string
+	for _, kind := range shogi.HandKinds {
+		n := r.board.Hand(c, kind)
+		if n == 0 {
+			continue
+		}
+		piece := kind.ShortName()
+		if n > 1 {
+			piece += strconv.Itoa(n)
+		}
+		hp := HandPiece{Color: c, Kind: kind}
+		isCursor := r.opts.HandCursor != nil && *r.opts.HandCursor == hp
+		isSelected := r.opts.SelectedHand != nil && *r.opts.SelectedHand == hp
+		switch {
+		case !r.opts.Color && isCursor:
+			piece = "[" + piece + "]"
+		case r.opts.Color && (isCursor || isSelected):
+			style := ""
+			if isSelected {
+				style += ansiSelected
+			}
+			if isCursor {
+				style += ansiCursor
+			}
+			piece = style + piece + ansiReset
+		}
+		pieces = append(pieces, piece)
+	}
+	if len(pieces) == 0 {
+		return "なし"
+	}
+	return strings.Join(pieces, "　")
+}
@@ -0,0 +1,125 @@
+package render
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k-yomo/shogi/shogi"
+)
+
+func TestTerminal(t *testing.T) {
+	// the rook checks the king along the first rank
+	const check = "4k4/9/9/9/9/9/9/9/R3K4 b - 1"
+	king := &shogi.Position{X: 5, Y: 1}
+
+	tests := []struct {
+		name string
+		sfen string
+		// moves are played from the SFEN in USI
+		moves []string
+		opts  TerminalOptions
+	}{
+		{name: "plain_initial", sfen: shogi.InitialSFEN},
+		{
+			name:  "plain_check",
+			sfen:  check,
+			moves: []string{"9i9a"},
+			opts:  TerminalOptions{HighlightLastMove: true},
+		},
+		{
+			name:  "plain_selected",
+			sfen:  check,
+			moves: []string{"9i9a"},
+			opts:  TerminalOptions{HighlightLastMove: true, Selected: king, Cursor: king},
+		},
+		{
+			name:  "plain_hand_cursor",
+			sfen:  "4k4/9/9/9/9/9/9/9/4K4 w 2Pg 1",
+			moves: []string{"G*5b"},
+			opts:  TerminalOptions{HandCursor: &HandPiece{Color: shogi.Sente, Kind: shogi.KindPawn}},
+		},
+		{
+			name:  "color_selected",
+			sfen:  check,
+			moves: []string{"9i9a"},
+			opts:  TerminalOptions{Color: true, HighlightLastMove: true, Selected: king, Cursor: king},
+		},
+		{
+			name:  "color_hand",
+			sfen:  "4k4/9/9/9/9/9/9/9/4K4 b 2Pg 1",
+			moves: []string{"P*5e"},
+			opts: TerminalOptions{
+				Color:        true,
+				SelectedHand: &HandPiece{Color: shogi.Gote, Kind: shogi.KindGold},
+				HandCursor:   &HandPiece{Color: shogi.Gote, Kind: shogi.KindGold},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			game, err := shogi.NewGameFromSFEN(tt.sfen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, usi := range tt.moves {
+				m, err := game.Board().ParseMove(usi)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := game.ApplyMove(m); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got := Terminal(game, tt.opts)
+
+			golden := filepath.Join("testdata", tt.name+".txt")
+			if *update {
+				if err := ioutil.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("Terminal() differs from %s, run go test -update to see the change:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestTerminal_PlainAlignment(t *testing.T) {
+	game, err := shogi.NewGameFromSFEN("4k4/9/9/9/9/9/9/9/R3K4 b - 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	king := &shogi.Position{X: 5, Y: 1}
+	got := Terminal(game, TerminalOptions{Selected: king, Cursor: king})
+	lines := strings.Split(got, "\n")
+	// the files, the borders and the ranks are as wide as each other, counting a full-width character as 2 columns
+	width := func(s string) int {
+		w := 0
+		for _, r := range s {
+			if r < 0x80 {
+				w++
+			} else {
+				w += 2
+			}
+		}
+		return w
+	}
+	border := width(lines[2])
+	for _, line := range lines[2:13] {
+		// the ranks end with the kanji numeral after the border
+		if w := width(strings.TrimRight(line, "一二三四五六七八九")); w != border {
+			t.Errorf("width of %q = %d, want %d", line, w, border)
+		}
+	}
+	if w := width(lines[1]) + 1; w != border {
+		t.Errorf("width of the files %q = %d, want %d", lines[1], w, border)
+	}
+}
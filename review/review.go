@@ -0,0 +1,244 @@
+// Package review reviews finished games by analyzing every move, classifying the moves by the score they lose
+// against the best move and annotating the records with the better alternatives.
+package review
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/k-yomo/shogi/shogi"
+	"github.com/pkg/errors"
+)
+
+// Class is the quality of a move judged by the score it loses against the best move
+type Class int
+
+const (
+	ClassBest Class = iota
+	ClassGood
+	ClassInaccuracy
+	ClassMistake
+	ClassBlunder
+)
+
+var classNames = [...]string{
+	ClassBest:       "best",
+	ClassGood:       "good",
+	ClassInaccuracy: "inaccuracy",
+	ClassMistake:    "mistake",
+	ClassBlunder:    "blunder",
+}
+
+// classJapaneseNames are the names of the classes written in the KIF comments
+var classJapaneseNames = [...]string{
+	ClassBest:       "最善手",
+	ClassGood:       "有力手",
+	ClassInaccuracy: "緩手",
+	ClassMistake:    "疑問手",
+	ClassBlunder:    "悪手",
+}
+
+func (c Class) String() string {
+	return classNames[c]
+}
+
+// JapaneseName returns the name of the class in Japanese, e.g. 悪手 for a blunder
+func (c Class) JapaneseName() string {
+	return classJapaneseNames[c]
+}
+
+// Thresholds are the least score losses of the classes, in the units of shogi.SearchResult.Score.
+// A move losing less than Inaccuracy is good, and one losing nothing is the best.
+type Thresholds struct {
+	Inaccuracy int
+	Mistake    int
+	Blunder    int
+}
+
+// DefaultThresholds are about a pawn, a minor piece and a rook
+var DefaultThresholds = Thresholds{Inaccuracy: 100, Mistake: 300, Blunder: 800}
+
+// Classify returns the class of the move losing the score
+func (t Thresholds) Classify(loss int) Class {
+	switch {
+	case loss <= 0:
+		return ClassBest
+	case loss < t.Inaccuracy:
+		return ClassGood
+	case loss < t.Mistake:
+		return ClassInaccuracy
+	case loss < t.Blunder:
+		return ClassMistake
+	default:
+		return ClassBlunder
+	}
+}
+
+// Options configures the review
+type Options struct {
+	// Depth is the depth of the analysis of each move, 4 if it's zero
+	Depth int
+	// MoveTime is the maximum time of finding the best move of each position, no limit if it's zero.
+	// The move played is analyzed at the depth reached within it.
+	MoveTime time.Duration
+	// Thresholds are DefaultThresholds if it's zero
+	Thresholds Thresholds
+	// OnMove is called with the review of each move as soon as it's analyzed if it's not nil
+	OnMove func(MoveReview)
+}
+
+// MoveReview is the review of a move of the game
+type MoveReview struct {
+	// Ply is the index of the move in the history of the game
+	Ply   int
+	Color shogi.Color
+	Move  shogi.Move
+	// Score is the score of the move, and BestScore is the one of the best move, both from the mover's perspective
+	Score     int
+	BestScore int
+	// Loss is how much worse the move is than the best move, which is zero for the best move
+	Loss  int
+	Class Class
+	// Best is the best move and PV is the principal variation starting with it
+	Best shogi.Move
+	PV   []shogi.Move
+}
+
+// Review is the review of a game
+type Review struct {
+	Moves []MoveReview
+}
+
+// Count returns the number of the moves of the color classified as the class
+func (r *Review) Count(c shogi.Color, class Class) int {
+	n := 0
+	for _, m := range r.Moves {
+		if m.Color == c && m.Class == class {
+			n++
+		}
+	}
+	return n
+}
+
+// Game reviews every move of the history of the game. Each position is analyzed to find the best move,
+// and the move played is analyzed at the depth the best move reached unless it's the best, to compare their scores.
+// It returns the moves reviewed so far with the error of the context if it's done, or with an error if the analysis
+// of a position completes no depth within opts.MoveTime.
+func Game(ctx context.Context, game *shogi.Game, opts Options) (*Review, error) {
+	if opts.Thresholds == (Thresholds{}) {
+		opts.Thresholds = DefaultThresholds
+	}
+	board := game.InitialBoard()
+	review := &Review{}
+	for i, m := range game.History() {
+		if err := ctx.Err(); err != nil {
+			return review, err
+		}
+		mr, err := reviewMove(ctx, board, m, opts)
+		if err != nil {
+			return review, errors.Wrapf(err, "review move %d", i+1)
+		}
+		mr.Ply = i
+		review.Moves = append(review.Moves, mr)
+		if opts.OnMove != nil {
+			opts.OnMove(mr)
+		}
+		board.DoMove(m)
+	}
+	return review, nil
+}
+
+func reviewMove(ctx context.Context, board *shogi.BitboardBoard, m shogi.Move, opts Options) (MoveReview, error) {
+	bestCtx := ctx
+	if opts.MoveTime > 0 {
+		var cancel context.CancelFunc
+		bestCtx, cancel = context.WithTimeout(ctx, opts.MoveTime)
+		defer cancel()
+	}
+	analysis, ok := board.Analyze(bestCtx, shogi.AnalyzeOptions{Depth: opts.Depth, MultiPV: 1})
+	if !ok {
+		return MoveReview{}, errors.New("no legal move")
+	}
+	if analysis.Depth == 0 {
+		if err := ctx.Err(); err != nil {
+			return MoveReview{}, err
+		}
+		return MoveReview{}, errors.Errorf("no depth completed within %s", opts.MoveTime)
+	}
+	best, _ := analysis.Best()
+	played := best
+	if best.Move != m {
+		// the scores are comparable only at the same depth, so the move played is searched to the depth the best
+		// move reached without the move time
+		depth := analysis.Depth
+		analysis, ok := board.Analyze(ctx, shogi.AnalyzeOptions{Depth: depth, MultiPV: 1, Moves: []shogi.Move{m}})
+		if !ok {
+			return MoveReview{}, errors.Errorf("illegal move %s", m)
+		}
+		played, _ = analysis.Best()
+		// the search stops before the depth only when it finds a mate or the context is done
+		if err := ctx.Err(); err != nil && analysis.Depth < depth && !played.IsMate() {
+			return MoveReview{}, err
+		}
+	}
+	mr := MoveReview{
+		Color:     board.SideToMove(),
+		Move:      m,
+		Score:     played.Score,
+		BestScore: best.Score,
+		Best:      best.Move,
+		PV:        best.PV,
+	}
+	// the move found better by its own search is regarded as the best as well
+	if mr.Loss = best.Score - played.Score; mr.Loss < 0 {
+		mr.Loss = 0
+	}
+	mr.Class = opts.Thresholds.Classify(mr.Loss)
+	return mr, nil
+}
+
+// Comments returns the KIF comments of the reviewed moves keyed by the index in the history for shogi.KIFInfo.
+// The moves worse than good are annotated with the scores and the principal variation of the best move.
+func (r *Review) Comments(game *shogi.Game) map[int]string {
+	comments := map[int]string{}
+	board := game.InitialBoard()
+	history := game.History()
+	var last *shogi.Move
+	for i, m := range history {
+		if i < len(r.Moves) {
+			comments[i] = r.Moves[i].comment(board, last)
+		}
+		board.DoMove(m)
+		last = &history[i]
+	}
+	return comments
+}
+
+// KIF returns the record of the game with the comments of the review
+func (r *Review) KIF(game *shogi.Game, info shogi.KIFInfo) string {
+	info.Comments = r.Comments(game)
+	return game.KIF(info)
+}
+
+// comment returns the comment of the move, which is played from the board, with the scores from the mover's perspective
+func (mr MoveReview) comment(board *shogi.BitboardBoard, last *shogi.Move) string {
+	comment := fmt.Sprintf("%s 評価値 %d", mr.Class.JapaneseName(), mr.Score)
+	if mr.Class <= ClassGood {
+		return comment
+	}
+	return comment + fmt.Sprintf("\n最善 %s 評価値 %d (%d)", japanesePV(board, mr.PV, last), mr.BestScore, -mr.Loss)
+}
+
+// japanesePV returns the moves of the principal variation from the board in Japanese notation
+func japanesePV(board *shogi.BitboardBoard, pv []shogi.Move, last *shogi.Move) string {
+	board = board.Clone()
+	var sb strings.Builder
+	for i, m := range pv {
+		sb.WriteString(board.SideToMove().Marker() + board.JapaneseMove(m, last))
+		board.DoMove(m)
+		last = &pv[i]
+	}
+	return sb.String()
+}
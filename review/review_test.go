@@ -0,0 +1,99 @@
+package review
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k-yomo/shogi/shogi"
+)
+
+// newGame returns the game played from the SFEN with the moves in USI
+func newGame(t *testing.T, sfen string, moves ...string) *shogi.Game {
+	t.Helper()
+	game, err := shogi.NewGameFromSFEN(sfen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range moves {
+		m, err := game.Board().ParseMove(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := game.ApplyMove(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return game
+}
+
+func TestGame(t *testing.T) {
+	// sente drops the rook where the gold takes it for nothing
+	game := newGame(t, "4k4/5g3/9/9/9/9/9/9/4K4 b R 1", "R*4c", "4b4c")
+	for _, opts := range []Options{{Depth: 3}, {Depth: 3, MoveTime: time.Minute}} {
+		var reviewed []MoveReview
+		opts.OnMove = func(mr MoveReview) {
+			reviewed = append(reviewed, mr)
+		}
+		r, err := Game(context.Background(), game, opts)
+		if err != nil {
+			t.Fatalf("Game(%+v) error = %v", opts, err)
+		}
+		if len(r.Moves) != 2 || len(reviewed) != 2 {
+			t.Fatalf("Game(%+v) reviewed %d moves and called OnMove %d times, want 2", opts, len(r.Moves), len(reviewed))
+		}
+		if blunder := r.Moves[0]; blunder.Class != ClassBlunder || blunder.Color != shogi.Sente || blunder.Best == blunder.Move {
+			t.Errorf("review of R*4c = %+v, want a blunder with another best move", blunder)
+		}
+		if capture := r.Moves[1]; capture.Class != ClassBest || capture.Loss != 0 {
+			t.Errorf("review of 4b4c = %+v, want the best move", capture)
+		}
+		if n := r.Count(shogi.Sente, ClassBlunder); n != 1 {
+			t.Errorf("Count(Sente, ClassBlunder) = %d, want 1", n)
+		}
+
+		kif := r.KIF(game, shogi.KIFInfo{})
+		i := strings.Index(kif, "４三飛打")
+		if i < 0 {
+			t.Fatalf("KIF = %q, want the blunder", kif)
+		}
+		blunder := kif[i:]
+		if lines := strings.SplitN(blunder, "\n", 4); len(lines) < 3 || !strings.HasPrefix(lines[1], "*悪手 評価値 -") || !strings.HasPrefix(lines[2], "*最善 ▲") {
+			t.Errorf("KIF after the blunder = %q, want the comments of the blunder and the best move", blunder)
+		}
+		if !strings.Contains(kif, "同　金(42)\n*最善手 評価値 ") {
+			t.Errorf("KIF = %q, want the capture commented as the best move", kif)
+		}
+	}
+}
+
+func TestGame_Cancelled(t *testing.T) {
+	game := newGame(t, "4k4/5g3/9/9/9/9/9/9/4K4 b R 1", "R*4c")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r, err := Game(ctx, game, Options{Depth: 3})
+	if err != context.Canceled || len(r.Moves) != 0 {
+		t.Errorf("Game() = %+v, %v, want no move and %v", r, err, context.Canceled)
+	}
+}
+
+func TestThresholds_Classify(t *testing.T) {
+	tests := []struct {
+		loss int
+		want Class
+	}{
+		{-10, ClassBest},
+		{0, ClassBest},
+		{99, ClassGood},
+		{100, ClassInaccuracy},
+		{300, ClassMistake},
+		{799, ClassMistake},
+		{800, ClassBlunder},
+	}
+	for _, tt := range tests {
+		if got := DefaultThresholds.Classify(tt.loss); got != tt.want {
+			t.Errorf("Classify(%d) = %s, want %s", tt.loss, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/k-yomo/shogi/shogi"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultAnalysisDepth = 4
+	maxAnalysisDepth     = 6
+	defaultMultiPV       = 3
+	maxMultiPV           = 10
+	// maxAnalysisTime is the longest an analysis request searches, returning the deepest completed depth
+	maxAnalysisTime = 10 * time.Second
+)
+
+// Analysis is the response of analyzing the current position of a game
+type Analysis struct {
+	// SideToMove is the player whose perspective the scores are from
+	SideToMove string       `json:"sideToMove"`
+	Depth      int          `json:"depth"`
+	Candidates []*Candidate `json:"candidates"`
+}
+
+// Candidate is a candidate move of the analysis, the best first
+type Candidate struct {
+	Move string `json:"move"`
+	// Score is in centipawn-like units, or ±30000 minus the plies for forced mates
+	Score int      `json:"score"`
+	Mate  bool     `json:"mate"`
+	PV    []string `json:"pv"`
+}
+
+// analyze analyzes the position with the query parameters depth (4 by default, up to 6) and multipv (3 by default, up to 10)
+func (s *Server) analyze(id string, r *http.Request) (*Analysis, error) {
+	depth, err := intParam(r, "depth", defaultAnalysisDepth, maxAnalysisDepth)
+	if err != nil {
+		return nil, err
+	}
+	multiPV, err := intParam(r, "multipv", defaultMultiPV, maxMultiPV)
+	if err != nil {
+		return nil, err
+	}
+	var board *shogi.BitboardBoard
+	err = s.store.View(id, func(game *shogi.Game) error {
+		if _, over := game.Result(); over {
+			return newHTTPError(http.StatusConflict, errors.New("the game is over"))
+		}
+		board = game.Board()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the game isn't locked while analyzing
+	ctx, cancel := context.WithTimeout(r.Context(), maxAnalysisTime)
+	defer cancel()
+	result, ok := board.Analyze(ctx, shogi.AnalyzeOptions{Depth: depth, MultiPV: multiPV})
+	if !ok {
+		return nil, newHTTPError(http.StatusConflict, errors.New("no legal move"))
+	}
+	analysis := &Analysis{SideToMove: colorName(board.SideToMove()), Depth: result.Depth, Candidates: []*Candidate{}}
+	for _, c := range result.Candidates {
+		candidate := &Candidate{Move: c.Move.String(), Score: c.Score, Mate: c.IsMate(), PV: make([]string, len(c.PV))}
+		for i, m := range c.PV {
+			candidate.PV[i] = m.String()
+		}
+		analysis.Candidates = append(analysis.Candidates, candidate)
+	}
+	return analysis, nil
+}
+
+// intParam returns the positive integer query parameter, or the default if it's missing
+func intParam(r *http.Request, name string, defaultValue, max int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 || n > max {
+		return 0, newHTTPError(http.StatusBadRequest, errors.Errorf("%s must be an integer from 1 to %d", name, max))
+	}
+	return n, nil
+}
@@ -0,0 +1,346 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/k-yomo/shogi/server/websocket"
+	"github.com/k-yomo/shogi/shogi"
+	"github.com/pkg/errors"
+)
+
+// subscriberBufferSize is the number of events buffered for a subscriber.
+// A subscriber falling behind more than this is disconnected, and it can catch up by reconnecting.
+const subscriberBufferSize = 64
+
+// Event types sent to WebSocket subscribers
+const (
+	EventState    = "state"
+	EventMove     = "move"
+	EventUndo     = "undo"
+	EventClock    = "clock"
+	EventCheck    = "check"
+	EventGameOver = "gameOver"
+	EventError    = "error"
+)
+
+// Event is a message sent to the subscribers of a game
+type Event struct {
+	Type string `json:"type"`
+	// Ply is the number of moves played after the event, which is given as since to replay the following moves
+	Ply int `json:"ply"`
+	// Move is the move in USI for move events
+	Move string `json:"move,omitempty"`
+	// Notation is the move in Japanese notation with the side marker for move events, e.g. ▲７六歩
+	Notation string `json:"notation,omitempty"`
+	// SFEN is the position after the event for move and undo events
+	SFEN string `json:"sfen,omitempty"`
+	// Color is the side in check for check events
+	Color  string  `json:"color,omitempty"`
+	Clock  *Clock  `json:"clock,omitempty"`
+	Result *Result `json:"result,omitempty"`
+	State  *State  `json:"state,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// Clock is the thinking time each side has consumed
+type Clock struct {
+	SenteMillis int64  `json:"senteMillis"`
+	GoteMillis  int64  `json:"goteMillis"`
+	SideToMove  string `json:"sideToMove"`
+}
+
+// ClientMessage is a message sent by a player over WebSocket, {"type": "move", "move": "7g7f"} or {"type": "resign"}.
+// A player can also declare the win by the entering king rule with {"type": "declare"}
+type ClientMessage struct {
+	Type string `json:"type"`
+	Move string `json:"move"`
+}
+
+// room is the live state of a game, the subscribers and the clock.
+// It's created by the first subscriber or move of the game, when the clock starts, and it's released when the game
+// is over and has no subscriber, or deleted. The clock of a finished game taken back after that starts again from zero.
+type room struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	consumed    [2]time.Duration
+	turnStarted time.Time
+}
+
+type subscriber struct {
+	events chan *Event
+	closed bool
+}
+
+// room returns the room of the game, creating it if there is none
+func (s *Server) room(id string) *room {
+	s.roomsMu.Lock()
+	defer s.roomsMu.Unlock()
+	r, ok := s.rooms[id]
+	if !ok {
+		r = &room{subscribers: map[*subscriber]struct{}{}, turnStarted: s.now()}
+		s.rooms[id] = r
+	}
+	return r
+}
+
+// publish sends the events to every subscriber of the game, and releases the room after the game over event if no one
+// is subscribed. It must be called while the game is locked by the store, so that the events are in the order of the game.
+func (s *Server) publish(id string, events ...*Event) {
+	s.roomsMu.Lock()
+	defer s.roomsMu.Unlock()
+	r, ok := s.rooms[id]
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for sub := range r.subscribers {
+		for _, e := range events {
+			if !r.send(sub, e) {
+				break
+			}
+		}
+	}
+	if len(r.subscribers) == 0 && len(events) > 0 && events[len(events)-1].Type == EventGameOver {
+		delete(s.rooms, id)
+	}
+}
+
+// leave unsubscribes the subscriber from the room, and releases the room if it was the last subscriber of the finished game
+func (s *Server) leave(id string, r *room, sub *subscriber) {
+	err := s.store.View(id, func(game *shogi.Game) error {
+		over := game.IsOver()
+		s.roomsMu.Lock()
+		defer s.roomsMu.Unlock()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.unsubscribe(sub)
+		if over && len(r.subscribers) == 0 && s.rooms[id] == r {
+			delete(s.rooms, id)
+		}
+		return nil
+	})
+	if err != nil {
+		// the game has been deleted with its room
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.unsubscribe(sub)
+	}
+}
+
+// send queues the event for the subscriber, disconnecting the subscriber if it has fallen behind.
+// r.mu must be held.
+func (r *room) send(sub *subscriber, e *Event) bool {
+	select {
+	case sub.events <- e:
+		return true
+	default:
+		r.unsubscribe(sub)
+		return false
+	}
+}
+
+// unsubscribe removes the subscriber and closes its channel. r.mu must be held.
+func (r *room) unsubscribe(sub *subscriber) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(r.subscribers, sub)
+	close(sub.events)
+}
+
+// tick charges the time since the last move to the side which has been thinking, which has just moved or whose turn
+// is taken back, restarts the clock for the side to move and returns the clock event
+func (r *room) tick(thinking, sideToMove shogi.Color, now time.Time, ply int) *Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.consumed[thinking] += now.Sub(r.turnStarted)
+	r.turnStarted = now
+	return &Event{Type: EventClock, Ply: ply, Clock: r.clock(sideToMove)}
+}
+
+// clock returns the current clock. r.mu must be held.
+func (r *room) clock(sideToMove shogi.Color) *Clock {
+	return &Clock{
+		SenteMillis: int64(r.consumed[shogi.Sente] / time.Millisecond),
+		GoteMillis:  int64(r.consumed[shogi.Gote] / time.Millisecond),
+		SideToMove:  colorName(sideToMove),
+	}
+}
+
+// moveEvents returns the events of playing the move, which is made on the game by this function
+func (s *Server) moveEvents(game *shogi.Game, move shogi.Move) ([]*Event, error) {
+	board := game.Board()
+	var last *shogi.Move
+	if m, ok := game.LastMove(); ok {
+		last = &m
+	}
+	notation := board.SideToMove().Marker() + board.JapaneseMove(move, last)
+	if err := game.ApplyMove(move); err != nil {
+		return nil, err
+	}
+	return append([]*Event{{
+		Type:     EventMove,
+		Ply:      len(game.History()),
+		Move:     move.String(),
+		Notation: notation,
+		SFEN:     game.SFEN(),
+	}}, positionEvents(game)...), nil
+}
+
+// positionEvents returns the check and game over events of the current position
+func positionEvents(game *shogi.Game) []*Event {
+	var events []*Event
+	ply := len(game.History())
+	if game.InCheck() {
+		events = append(events, &Event{Type: EventCheck, Ply: ply, Color: colorName(game.SideToMove())})
+	}
+	if result := gameResult(game); result != nil {
+		events = append(events, &Event{Type: EventGameOver, Ply: ply, Result: result})
+	}
+	return events
+}
+
+// replayEvents returns the events of the moves played after the ply
+func replayEvents(game *shogi.Game, since int) ([]*Event, error) {
+	replay, err := shogi.NewGameFromBoard(game.InitialBoard())
+	if err != nil {
+		return nil, err
+	}
+	var events []*Event
+	for i, move := range game.History() {
+		if i < since {
+			if err := replay.ApplyMove(move); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		board := replay.Board()
+		var last *shogi.Move
+		if m, ok := replay.LastMove(); ok {
+			last = &m
+		}
+		notation := board.SideToMove().Marker() + board.JapaneseMove(move, last)
+		if err := replay.ApplyMove(move); err != nil {
+			return nil, err
+		}
+		events = append(events, &Event{Type: EventMove, Ply: i + 1, Move: move.String(), Notation: notation, SFEN: replay.SFEN()})
+	}
+	return events, nil
+}
+
+// serveWebSocket streams the events of the game to the client, and plays the moves sent by the player.
+// The query parameter token is the token of the player's seat given when the game is created, without which the client
+// is a spectator, and since is the ply the client has seen, from which the missed moves are replayed.
+// Without since, the client receives the whole state first.
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request, id string) {
+	var player *shogi.Color
+	if token := r.URL.Query().Get("token"); token != "" {
+		c, ok := s.seat(id, token)
+		if !ok {
+			writeError(w, newHTTPError(http.StatusForbidden, errors.New("invalid token")))
+			return
+		}
+		player = &c
+	}
+	since := -1
+	if v := r.URL.Query().Get("since"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, newHTTPError(http.StatusBadRequest, errors.Errorf("invalid since: %q", v)))
+			return
+		}
+		since = n
+	}
+
+	// subscribe and take the initial events under the game's lock, so that no event is missed or duplicated
+	var rm *room
+	sub := &subscriber{events: make(chan *Event, subscriberBufferSize)}
+	var initial []*Event
+	err := s.store.View(id, func(game *shogi.Game) error {
+		rm = s.room(id)
+		ply := len(game.History())
+		if since < 0 || since > ply {
+			// new clients and the clients which have seen moves undone since then start from the whole state
+			initial = []*Event{{Type: EventState, Ply: ply, State: newState(id, game)}}
+		} else {
+			events, err := replayEvents(game, since)
+			if err != nil {
+				return err
+			}
+			initial = append(events, positionEvents(game)...)
+		}
+		rm.mu.Lock()
+		defer rm.mu.Unlock()
+		initial = append(initial, &Event{Type: EventClock, Ply: ply, Clock: rm.clock(game.SideToMove())})
+		rm.subscribers[sub] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	unsubscribe := func() {
+		s.leave(id, rm, sub)
+	}
+
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		unsubscribe()
+		return
+	}
+	defer conn.Close()
+
+	go func() {
+		defer unsubscribe()
+		for {
+			var msg ClientMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			if err := s.handleClientMessage(id, player, msg); err != nil {
+				rm.mu.Lock()
+				if !sub.closed {
+					rm.send(sub, &Event{Type: EventError, Error: err.Error()})
+				}
+				rm.mu.Unlock()
+			}
+		}
+	}()
+
+	for _, e := range initial {
+		if err := conn.WriteJSON(e); err != nil {
+			unsubscribe()
+			return
+		}
+	}
+	for e := range sub.events {
+		if err := conn.WriteJSON(e); err != nil {
+			unsubscribe()
+			return
+		}
+	}
+}
+
+func (s *Server) handleClientMessage(id string, player *shogi.Color, msg ClientMessage) error {
+	if player == nil {
+		return errors.New("spectators can't play moves")
+	}
+	switch msg.Type {
+	case "move":
+		_, err := s.play(id, msg.Move, player)
+		return err
+	case "resign":
+		_, err := s.resign(id, *player)
+		return err
+	case "declare":
+		_, err := s.declare(id, player)
+		return err
+	default:
+		return errors.Errorf("unknown message type: %q", msg.Type)
+	}
+}
@@ -0,0 +1,255 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k-yomo/shogi/server/websocket"
+)
+
+// fakeClock is the time source of the server advanced by the tests
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+type liveTest struct {
+	t      *testing.T
+	server *Server
+	ts     *httptest.Server
+	clock  *fakeClock
+	id     string
+	seats  *Seats
+}
+
+// newServerTest serves the API in process without games
+func newServerTest(t *testing.T) *liveTest {
+	t.Helper()
+	s := New(NewMemoryStore())
+	clock := &fakeClock{now: time.Date(2020, 8, 7, 12, 0, 0, 0, time.UTC)}
+	s.now = clock.Now
+	return &liveTest{t: t, server: s, ts: httptest.NewServer(s), clock: clock}
+}
+
+// newLiveTest serves a game created from the SFEN in process
+func newLiveTest(t *testing.T, sfen string) *liveTest {
+	t.Helper()
+	lt := newServerTest(t)
+	var state State
+	lt.post("/games", `{"sfen": "`+sfen+`"}`, http.StatusCreated, &state)
+	lt.id, lt.seats = state.ID, state.Seats
+	return lt
+}
+
+func (lt *liveTest) close() {
+	lt.ts.Close()
+}
+
+// post sends the request and decodes the response, failing the test unless it has the status
+func (lt *liveTest) post(path, body string, status int, v interface{}) {
+	lt.t.Helper()
+	lt.request(http.MethodPost, path, body, status, v)
+}
+
+// request sends the request with the method and decodes the response, failing the test unless it has the status
+func (lt *liveTest) request(method, path, body string, status int, v interface{}) *http.Response {
+	lt.t.Helper()
+	req, err := http.NewRequest(method, lt.ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		lt.t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		lt.t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != status {
+		lt.t.Fatalf("%s %s responded %s, want %d", method, path, res.Status, status)
+	}
+	if v != nil {
+		if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+			lt.t.Fatal(err)
+		}
+	}
+	return res
+}
+
+// dial subscribes to the game with the query such as "token=...&since=1"
+func (lt *liveTest) dial(query string) *websocket.Conn {
+	lt.t.Helper()
+	conn, err := websocket.Dial("ws" + strings.TrimPrefix(lt.ts.URL, "http") + "/games/" + lt.id + "/ws?" + query)
+	if err != nil {
+		lt.t.Fatal(err)
+	}
+	return conn
+}
+
+// expect reads the events, failing the test unless they have the types
+func (lt *liveTest) expect(conn *websocket.Conn, want ...string) []*Event {
+	lt.t.Helper()
+	var events []*Event
+	for _, w := range want {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var e Event
+		if err := conn.ReadJSON(&e); err != nil {
+			lt.t.Fatalf("read %s event: %v", w, err)
+		}
+		if got := e.Type; got != w {
+			lt.t.Fatalf("event = %s %+v, want %s", got, e, w)
+		}
+		events = append(events, &e)
+	}
+	return events
+}
+
+func TestServer_WebSocket(t *testing.T) {
+	lt := newLiveTest(t, "4k4/9/9/9/9/9/9/9/4K4 b Rr 1")
+	defer lt.close()
+
+	player := lt.dial("token=" + lt.seats.Sente)
+	defer player.Close()
+	spectator := lt.dial("")
+	initial := lt.expect(spectator, EventState, EventClock)
+	if initial[0].State.SFEN != "4k4/9/9/9/9/9/9/9/4K4 b Rr 1" {
+		t.Errorf("initial state = %+v", initial[0].State)
+	}
+	lt.expect(player, EventState, EventClock)
+
+	lt.clock.Advance(3 * time.Second)
+	if err := player.WriteJSON(ClientMessage{Type: "move", Move: "R*5e"}); err != nil {
+		t.Fatal(err)
+	}
+	events := lt.expect(spectator, EventMove, EventClock, EventCheck)
+	if e := events[0]; e.Ply != 1 || e.Move != "R*5e" || e.Notation != "▲５五飛" {
+		t.Errorf("move event = %+v", e)
+	}
+	if c := events[1].Clock; c.SenteMillis != 3000 || c.GoteMillis != 0 || c.SideToMove != "gote" {
+		t.Errorf("clock = %+v, want 3s for sente and gote to move", c)
+	}
+	if e := events[2]; e.Color != "gote" {
+		t.Errorf("check event = %+v, want gote in check", e)
+	}
+	lt.expect(player, EventMove, EventClock, EventCheck)
+
+	// a player can't move for the opponent
+	if err := player.WriteJSON(ClientMessage{Type: "move", Move: "5a4a"}); err != nil {
+		t.Fatal(err)
+	}
+	if e := lt.expect(player, EventError)[0]; e.Error != "it's not your turn" {
+		t.Errorf("error event = %+v", e)
+	}
+
+	// the spectator misses two moves and catches up by reconnecting with since
+	spectator.Close()
+	lt.clock.Advance(2 * time.Second)
+	lt.post("/games/"+lt.id+"/moves", `{"move": "5a4a"}`, http.StatusOK, nil)
+	lt.post("/games/"+lt.id+"/moves", `{"move": "5e5a+"}`, http.StatusOK, nil)
+	spectator = lt.dial("since=1")
+	defer spectator.Close()
+	events = lt.expect(spectator, EventMove, EventMove, EventCheck, EventClock)
+	if events[0].Ply != 2 || events[0].Move != "5a4a" || events[1].Ply != 3 || events[1].Move != "5e5a+" {
+		t.Errorf("replayed moves = %+v, %+v", events[0], events[1])
+	}
+	if c := events[3].Clock; c.SenteMillis != 3000 || c.GoteMillis != 2000 {
+		t.Errorf("clock = %+v, want 3s for sente and 2s for gote", c)
+	}
+}
+
+func TestServer_WebSocket_UndoClock(t *testing.T) {
+	lt := newLiveTest(t, "4k4/9/9/9/9/9/9/9/4K4 b Rr 1")
+	defer lt.close()
+	spectator := lt.dial("")
+	defer spectator.Close()
+	lt.expect(spectator, EventState, EventClock)
+
+	lt.clock.Advance(3 * time.Second)
+	lt.post("/games/"+lt.id+"/moves", `{"move": "5i4i"}`, http.StatusOK, nil)
+	lt.expect(spectator, EventMove, EventClock)
+
+	// gote thinks for 5 seconds before the move is taken back, and sente thinks again for 2 seconds
+	lt.clock.Advance(5 * time.Second)
+	lt.post("/games/"+lt.id+"/undo", "", http.StatusOK, nil)
+	events := lt.expect(spectator, EventUndo, EventClock)
+	if c := events[1].Clock; c.SenteMillis != 3000 || c.GoteMillis != 5000 || c.SideToMove != "sente" {
+		t.Errorf("clock after undo = %+v, want 3s for sente and 5s for gote", c)
+	}
+	lt.clock.Advance(2 * time.Second)
+	lt.post("/games/"+lt.id+"/moves", `{"move": "5i6i"}`, http.StatusOK, nil)
+	events = lt.expect(spectator, EventMove, EventClock)
+	if c := events[1].Clock; c.SenteMillis != 5000 || c.GoteMillis != 5000 {
+		t.Errorf("clock = %+v, want 5s for sente and 5s for gote", c)
+	}
+}
+
+func TestServer_WebSocket_Seats(t *testing.T) {
+	lt := newLiveTest(t, "4k4/9/9/9/9/9/9/9/4K4 b Rr 1")
+	defer lt.close()
+	if lt.seats == nil || lt.seats.Sente == "" || lt.seats.Gote == "" || lt.seats.Sente == lt.seats.Gote {
+		t.Fatalf("seats = %+v, want two tokens", lt.seats)
+	}
+	var state State
+	lt.request(http.MethodGet, "/games/"+lt.id, "", http.StatusOK, &state)
+	if state.Seats != nil {
+		t.Errorf("seats of the game = %+v, want them only in the response of creating it", state.Seats)
+	}
+
+	if conn, err := websocket.Dial("ws" + strings.TrimPrefix(lt.ts.URL, "http") + "/games/" + lt.id + "/ws?token=invalid"); err == nil {
+		conn.Close()
+		t.Error("Dial() with an invalid token succeeded")
+	}
+	// the seats aren't taken by the roles any more
+	spectator := lt.dial("role=sente")
+	defer spectator.Close()
+	gote := lt.dial("token=" + lt.seats.Gote)
+	defer gote.Close()
+	sente := lt.dial("token=" + lt.seats.Sente)
+	defer sente.Close()
+	for _, conn := range []*websocket.Conn{spectator, gote, sente} {
+		lt.expect(conn, EventState, EventClock)
+	}
+
+	tests := []struct {
+		name string
+		conn *websocket.Conn
+		want string
+	}{
+		{name: "spectator", conn: spectator, want: "spectators can't play moves"},
+		{name: "gote", conn: gote, want: "it's not your turn"},
+	}
+	for _, tt := range tests {
+		if err := tt.conn.WriteJSON(ClientMessage{Type: "move", Move: "R*5e"}); err != nil {
+			t.Fatal(err)
+		}
+		if e := lt.expect(tt.conn, EventError)[0]; e.Error != tt.want {
+			t.Errorf("%s: error event = %+v, want %q", tt.name, e, tt.want)
+		}
+	}
+	if err := sente.WriteJSON(ClientMessage{Type: "move", Move: "R*5e"}); err != nil {
+		t.Fatal(err)
+	}
+	lt.expect(sente, EventMove, EventClock, EventCheck)
+
+	lt.request(http.MethodDelete, "/games/"+lt.id, "", http.StatusNoContent, nil)
+	lt.server.seatsMu.Lock()
+	defer lt.server.seatsMu.Unlock()
+	if _, ok := lt.server.seats[lt.id]; ok {
+		t.Error("the seats of the deleted game are kept")
+	}
+}
@@ -0,0 +1,375 @@
+// Package server provides an HTTP/JSON API to play shogi games.
+//
+//	POST /games                 create a game, {"handicap": "bishop"} or {"sfen": "..."} optionally
+//	GET  /games/{id}            get the state of the game
+//	DELETE /games/{id}          delete the game, closing its WebSocket connections
+//	POST /games/{id}/moves      play a move, {"move": "7g7f"}
+//	POST /games/{id}/undo       take back the last move
+//	POST /games/{id}/resign     resign the game, {"color": "sente"}
+//	POST /games/{id}/abort      abort the game
+//	POST /games/{id}/declare    declare the win of the side to move by the entering king rule
+//	GET  /games/{id}/analysis   analyze the current position, ?depth=4&multipv=3 optionally
+//	GET  /games/{id}/ws         subscribe to the events of the game over WebSocket
+//
+// Every endpoint other than delete, analysis and WebSocket responds with the state of the game, or {"error": "..."} on failure.
+// Delete responds with no content. Creating a game also responds with the tokens of the seats, see Seats.
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k-yomo/shogi/shogi"
+	"github.com/pkg/errors"
+)
+
+// maxRequestBodySize is the maximum size of request bodies, which are small JSON objects
+const maxRequestBodySize = 1 << 16
+
+// Server serves the game API
+type Server struct {
+	store   Store
+	roomsMu sync.Mutex
+	rooms   map[string]*room
+	seatsMu sync.Mutex
+	// seats is the tokens of the players by game ID, which are kept only for the games created by the server
+	seats map[string]*Seats
+	// now returns the current time, which is replaced to control the clock
+	now func() time.Time
+}
+
+func New(store Store) *Server {
+	return &Server{store: store, rooms: map[string]*room{}, seats: map[string]*Seats{}, now: time.Now}
+}
+
+// CreateGameRequest is the request body of creating a game. Both fields are optional but exclusive.
+type CreateGameRequest struct {
+	Handicap string `json:"handicap"`
+	SFEN     string `json:"sfen"`
+}
+
+// MoveRequest is the request body of playing a move
+type MoveRequest struct {
+	Move string `json:"move"`
+}
+
+// ResignRequest is the request body of resigning a game
+type ResignRequest struct {
+	Color string `json:"color"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// httpError is an error with the status code to respond
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string {
+	return e.err.Error()
+}
+
+func newHTTPError(status int, err error) error {
+	return &httpError{status: status, err: err}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	if parts[0] != "games" {
+		writeError(w, newHTTPError(http.StatusNotFound, errors.New("not found")))
+		return
+	}
+
+	var state *State
+	var err error
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodPost:
+		state, err = s.createGame(r)
+		if err == nil {
+			w.Header().Set("Location", "/games/"+state.ID)
+			writeJSON(w, http.StatusCreated, state)
+			return
+		}
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		state, err = s.getGame(parts[1])
+	case len(parts) == 2 && r.Method == http.MethodDelete:
+		if err = s.deleteGame(parts[1]); err == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	case len(parts) == 3 && parts[2] == "moves" && r.Method == http.MethodPost:
+		state, err = s.playMove(parts[1], r)
+	case len(parts) == 3 && parts[2] == "undo" && r.Method == http.MethodPost:
+		state, err = s.undo(parts[1])
+	case len(parts) == 3 && parts[2] == "resign" && r.Method == http.MethodPost:
+		state, err = s.resignGame(parts[1], r)
+	case len(parts) == 3 && parts[2] == "abort" && r.Method == http.MethodPost:
+		state, err = s.end(parts[1], (*shogi.Game).Abort)
+	case len(parts) == 3 && parts[2] == "declare" && r.Method == http.MethodPost:
+		state, err = s.declare(parts[1], nil)
+	case len(parts) == 3 && parts[2] == "analysis" && r.Method == http.MethodGet:
+		analysis, err := s.analyze(parts[1], r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, analysis)
+		return
+	case len(parts) == 3 && parts[2] == "ws" && r.Method == http.MethodGet:
+		s.serveWebSocket(w, r, parts[1])
+		return
+	case len(parts) <= 3:
+		err = newHTTPError(http.StatusMethodNotAllowed, errors.Errorf("method %s is not allowed", r.Method))
+	default:
+		err = newHTTPError(http.StatusNotFound, errors.New("not found"))
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, state)
+}
+
+func (s *Server) createGame(r *http.Request) (*State, error) {
+	var req CreateGameRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+
+	var game *shogi.Game
+	var err error
+	switch {
+	case req.Handicap != "" && req.SFEN != "":
+		return nil, newHTTPError(http.StatusBadRequest, errors.New("handicap and sfen can't be specified together"))
+	case req.SFEN != "":
+		game, err = shogi.NewGameFromSFEN(req.SFEN)
+	case req.Handicap != "":
+		var h shogi.Handicap
+		if h, err = shogi.ParseHandicap(req.Handicap); err == nil {
+			game, err = shogi.NewGameWithHandicap(h)
+		}
+	default:
+		game = shogi.NewGame()
+	}
+	if err != nil {
+		return nil, newHTTPError(http.StatusBadRequest, err)
+	}
+
+	seats := &Seats{}
+	for _, token := range []*string{&seats.Sente, &seats.Gote} {
+		if *token, err = newSeatToken(); err != nil {
+			return nil, err
+		}
+	}
+	id, err := s.store.Create(game)
+	if err != nil {
+		return nil, err
+	}
+	s.seatsMu.Lock()
+	s.seats[id] = seats
+	s.seatsMu.Unlock()
+	// the game may be updated as soon as it's stored, so it's read through the store as well
+	state, err := s.getGame(id)
+	if err != nil {
+		return nil, err
+	}
+	state.Seats = seats
+	return state, nil
+}
+
+// seat returns the color whose token is given for the game, or false if it's not the token of either seat
+func (s *Server) seat(id, token string) (shogi.Color, bool) {
+	s.seatsMu.Lock()
+	seats, ok := s.seats[id]
+	s.seatsMu.Unlock()
+	switch {
+	case !ok:
+		return shogi.Sente, false
+	case subtle.ConstantTimeCompare([]byte(token), []byte(seats.Sente)) == 1:
+		return shogi.Sente, true
+	case subtle.ConstantTimeCompare([]byte(token), []byte(seats.Gote)) == 1:
+		return shogi.Gote, true
+	default:
+		return shogi.Sente, false
+	}
+}
+
+func (s *Server) getGame(id string) (*State, error) {
+	var state *State
+	err := s.store.View(id, func(game *shogi.Game) error {
+		state = newState(id, game)
+		return nil
+	})
+	return state, err
+}
+
+// deleteGame deletes the game and its room, closing the connections of the subscribers
+func (s *Server) deleteGame(id string) error {
+	if err := s.store.Delete(id); err != nil {
+		return err
+	}
+	s.seatsMu.Lock()
+	delete(s.seats, id)
+	s.seatsMu.Unlock()
+	s.roomsMu.Lock()
+	defer s.roomsMu.Unlock()
+	if r, ok := s.rooms[id]; ok {
+		r.mu.Lock()
+		for sub := range r.subscribers {
+			r.unsubscribe(sub)
+		}
+		r.mu.Unlock()
+		delete(s.rooms, id)
+	}
+	return nil
+}
+
+func (s *Server) playMove(id string, r *http.Request) (*State, error) {
+	var req MoveRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	return s.play(id, req.Move, nil)
+}
+
+// play plays the move in USI and publishes the events. If player isn't nil, it must be the side to move.
+func (s *Server) play(id string, usi string, player *shogi.Color) (*State, error) {
+	var state *State
+	err := s.store.Update(id, func(game *shogi.Game) error {
+		if game.IsOver() {
+			return newHTTPError(http.StatusConflict, errors.New("the game is over"))
+		}
+		if player != nil && *player != game.SideToMove() {
+			return newHTTPError(http.StatusConflict, errors.New("it's not your turn"))
+		}
+		move, err := game.Board().ParseMove(usi)
+		if err != nil {
+			return newHTTPError(http.StatusBadRequest, err)
+		}
+		moved := game.SideToMove()
+		events, err := s.moveEvents(game, move)
+		if err != nil {
+			return newHTTPError(http.StatusBadRequest, err)
+		}
+		clock := s.room(id).tick(moved, game.SideToMove(), s.now(), len(game.History()))
+		// the clock event comes right after the move event
+		events = append(events[:1], append([]*Event{clock}, events[1:]...)...)
+		s.publish(id, events...)
+		state = newState(id, game)
+		return nil
+	})
+	return state, err
+}
+
+func (s *Server) undo(id string) (*State, error) {
+	var state *State
+	err := s.store.Update(id, func(game *shogi.Game) error {
+		thinking := game.SideToMove()
+		if err := game.Undo(); err != nil {
+			return newHTTPError(http.StatusConflict, err)
+		}
+		// the side which was thinking is charged until the undo, and the clock restarts for the side to move again
+		ply := len(game.History())
+		clock := s.room(id).tick(thinking, game.SideToMove(), s.now(), ply)
+		s.publish(id, &Event{Type: EventUndo, Ply: ply, SFEN: game.SFEN()}, clock)
+		state = newState(id, game)
+		return nil
+	})
+	return state, err
+}
+
+func (s *Server) resignGame(id string, r *http.Request) (*State, error) {
+	var req ResignRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	var color shogi.Color
+	switch req.Color {
+	case "sente":
+		color = shogi.Sente
+	case "gote":
+		color = shogi.Gote
+	default:
+		return nil, newHTTPError(http.StatusBadRequest, errors.Errorf("invalid color: %q", req.Color))
+	}
+	return s.resign(id, color)
+}
+
+func (s *Server) resign(id string, color shogi.Color) (*State, error) {
+	return s.end(id, func(game *shogi.Game) error {
+		return game.Resign(color)
+	})
+}
+
+// declare declares the win by the entering king rule. If player isn't nil, it must be the side to move.
+func (s *Server) declare(id string, player *shogi.Color) (*State, error) {
+	return s.end(id, func(game *shogi.Game) error {
+		if player != nil && *player != game.SideToMove() {
+			return errors.New("it's not your turn")
+		}
+		_, err := game.DeclareWin()
+		return err
+	})
+}
+
+// end ends the game by the function such as resignation, and publishes the game over event
+func (s *Server) end(id string, fn func(game *shogi.Game) error) (*State, error) {
+	var state *State
+	err := s.store.Update(id, func(game *shogi.Game) error {
+		if err := fn(game); err != nil {
+			return newHTTPError(http.StatusConflict, err)
+		}
+		s.publish(id, &Event{Type: EventGameOver, Ply: len(game.History()), Result: gameResult(game)})
+		state = newState(id, game)
+		return nil
+	})
+	return state, err
+}
+
+func newSeatToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "generate seat token")
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func decodeBody(r *http.Request, v interface{}) error {
+	if r.Body == nil {
+		return nil
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxRequestBodySize))
+	dec.DisallowUnknownFields()
+	// an empty body is the same as an empty object
+	if err := dec.Decode(v); err != nil && err != io.EOF {
+		return newHTTPError(http.StatusBadRequest, errors.Wrap(err, "invalid request body"))
+	}
+	return nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if httpErr, ok := err.(*httpError); ok {
+		status = httpErr.status
+	} else if errors.Cause(err) == ErrGameNotFound {
+		status = http.StatusNotFound
+	}
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,313 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k-yomo/shogi/shogi"
+)
+
+func TestServer_CreateGame(t *testing.T) {
+	lt := newServerTest(t)
+	defer lt.close()
+
+	bishop, err := shogi.ParseHandicap("bishop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		body     string
+		wantSFEN string
+	}{
+		{name: "even", body: "", wantSFEN: shogi.InitialSFEN},
+		{name: "even with empty object", body: `{}`, wantSFEN: shogi.InitialSFEN},
+		{name: "handicap", body: `{"handicap": "bishop"}`, wantSFEN: bishop.SFEN()},
+		{name: "handicap in Japanese", body: `{"handicap": "角落ち"}`, wantSFEN: bishop.SFEN()},
+		{name: "sfen", body: `{"sfen": "4k4/9/9/9/9/9/9/9/4K4 b Rr 1"}`, wantSFEN: "4k4/9/9/9/9/9/9/9/4K4 b Rr 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created State
+			res := lt.request(http.MethodPost, "/games", tt.body, http.StatusCreated, &created)
+			if created.SFEN != tt.wantSFEN {
+				t.Errorf("sfen = %s, want %s", created.SFEN, tt.wantSFEN)
+			}
+			if got := res.Header.Get("Location"); got != "/games/"+created.ID {
+				t.Errorf("Location = %s, want /games/%s", got, created.ID)
+			}
+			var state State
+			lt.request(http.MethodGet, "/games/"+created.ID, "", http.StatusOK, &state)
+			if state.SFEN != tt.wantSFEN || len(state.LegalMoves) == 0 || len(state.Moves) != 0 || state.Result != nil {
+				t.Errorf("state = %+v", state)
+			}
+		})
+	}
+
+	for _, body := range []string{
+		`{"handicap": "bishop", "sfen": "4k4/9/9/9/9/9/9/9/4K4 b - 1"}`,
+		`{"handicap": "queen"}`,
+		`{"sfen": "4k4/9/9 b - 1"}`,
+		`{"sfen": "9/9/9/9/9/9/9/9/9 b 19P 1"}`,
+		`{"color": "sente"}`,
+		`{`,
+	} {
+		var res errorResponse
+		lt.request(http.MethodPost, "/games", body, http.StatusBadRequest, &res)
+		if res.Error == "" {
+			t.Errorf("POST /games %s responded no error", body)
+		}
+	}
+}
+
+func TestServer_Game(t *testing.T) {
+	lt := newLiveTest(t, shogi.InitialSFEN)
+	defer lt.close()
+	path := "/games/" + lt.id
+
+	var state State
+	lt.post(path+"/moves", `{"move": "7g7f"}`, http.StatusOK, &state)
+	if state.SideToMove != "gote" || state.MoveNumber != 2 || strings.Join(state.Moves, " ") != "7g7f" {
+		t.Errorf("state after 7g7f = %+v", state)
+	}
+	if p := state.Board[5][2]; p == nil || p.Kind != "pawn" || p.Color != "sente" {
+		t.Errorf("7f = %+v, want sente's pawn", p)
+	}
+	lt.post(path+"/moves", `{"move": "3c3d"}`, http.StatusOK, nil)
+	lt.post(path+"/moves", `{"move": "8h2b+"}`, http.StatusOK, &state)
+	if state.Hands["sente"]["bishop"] != 1 {
+		t.Errorf("hands = %v, want a bishop for sente", state.Hands)
+	}
+
+	lt.post(path+"/undo", "", http.StatusOK, &state)
+	if strings.Join(state.Moves, " ") != "7g7f 3c3d" || len(state.Hands["sente"]) != 0 {
+		t.Errorf("state after undo = %+v", state)
+	}
+	lt.request(http.MethodGet, path, "", http.StatusOK, &state)
+	if state.MoveNumber != 3 {
+		t.Errorf("move number = %d, want 3", state.MoveNumber)
+	}
+
+	errorTests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{method: http.MethodPost, path: path + "/moves", body: `{"move": "5e5d"}`, status: http.StatusBadRequest},
+		{method: http.MethodPost, path: path + "/moves", body: `{"move": "7z7f"}`, status: http.StatusBadRequest},
+		{method: http.MethodPost, path: path + "/moves", body: `{"move": 1}`, status: http.StatusBadRequest},
+		{method: http.MethodPost, path: path + "/resign", body: `{"color": "black"}`, status: http.StatusBadRequest},
+		{method: http.MethodGet, path: "/games/unknown", status: http.StatusNotFound},
+		{method: http.MethodPost, path: "/games/unknown/moves", body: `{"move": "7g7f"}`, status: http.StatusNotFound},
+		{method: http.MethodGet, path: "/players", status: http.StatusNotFound},
+		{method: http.MethodGet, path: path + "/moves/1/2", status: http.StatusNotFound},
+		{method: http.MethodPut, path: path, status: http.StatusMethodNotAllowed},
+		{method: http.MethodGet, path: path + "/moves", status: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range errorTests {
+		var res errorResponse
+		lt.request(tt.method, tt.path, tt.body, tt.status, &res)
+		if res.Error == "" {
+			t.Errorf("%s %s responded no error", tt.method, tt.path)
+		}
+	}
+
+	lt.post(path+"/resign", `{"color": "sente"}`, http.StatusOK, &state)
+	if state.Result == nil || *state.Result != (Result{Winner: "gote", Reason: "resignation"}) || len(state.LegalMoves) != 0 {
+		t.Errorf("state after resignation = %+v", state)
+	}
+	lt.post(path+"/moves", `{"move": "2g2f"}`, http.StatusConflict, nil)
+	lt.post(path+"/undo", "", http.StatusConflict, nil)
+	lt.post(path+"/abort", "", http.StatusConflict, nil)
+
+	lt.request(http.MethodDelete, path, "", http.StatusNoContent, nil)
+	lt.request(http.MethodGet, path, "", http.StatusNotFound, nil)
+	lt.request(http.MethodDelete, path, "", http.StatusNotFound, nil)
+}
+
+func TestServer_Undo_NoMove(t *testing.T) {
+	lt := newLiveTest(t, shogi.InitialSFEN)
+	defer lt.close()
+	lt.post("/games/"+lt.id+"/undo", "", http.StatusConflict, nil)
+}
+
+// the moves sent to the same game at the same time are made one by one, which must be run with -race
+func TestServer_ParallelMoves(t *testing.T) {
+	lt := newLiveTest(t, shogi.InitialSFEN)
+	defer lt.close()
+	spectator := lt.dial("")
+	defer spectator.Close()
+	lt.expect(spectator, EventState, EventClock)
+
+	// every client tries the first moves of both sides, and only one of each can be made
+	const clients = 8
+	moves := []string{"7g7f", "3c3d"}
+	var wg sync.WaitGroup
+	statuses := make([][]int, clients)
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for _, m := range moves {
+				res, err := http.Post(lt.ts.URL+"/games/"+lt.id+"/moves", "application/json", strings.NewReader(`{"move": "`+m+`"}`))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				res.Body.Close()
+				statuses[i] = append(statuses[i], res.StatusCode)
+				// reading the game meanwhile must be safe as well
+				res, err = http.Get(lt.ts.URL + "/games/" + lt.id)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				res.Body.Close()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, s := range statuses {
+		for _, status := range s {
+			switch status {
+			case http.StatusOK:
+				succeeded++
+			case http.StatusBadRequest:
+			default:
+				t.Errorf("status = %d, want 200 or 400", status)
+			}
+		}
+	}
+	var state State
+	lt.request(http.MethodGet, "/games/"+lt.id, "", http.StatusOK, &state)
+	if got := strings.Join(state.Moves, " "); got != "7g7f 3c3d" && got != "7g7f" {
+		t.Errorf("moves = %s, want 7g7f 3c3d or 7g7f", got)
+	}
+	if succeeded != len(state.Moves) {
+		t.Errorf("%d moves succeeded, want %d", succeeded, len(state.Moves))
+	}
+	// the subscriber receives the moves in order
+	for ply := 1; ply <= len(state.Moves); ply++ {
+		if e := lt.expect(spectator, EventMove, EventClock)[0]; e.Ply != ply || e.Move != state.Moves[ply-1] {
+			t.Errorf("move event = %+v, want %s at %d", e, state.Moves[ply-1], ply)
+		}
+	}
+}
+
+// roomCount returns the number of rooms the server keeps
+func (lt *liveTest) roomCount() int {
+	lt.server.roomsMu.Lock()
+	defer lt.server.roomsMu.Unlock()
+	return len(lt.server.rooms)
+}
+
+// waitRoomCount waits until the server keeps the number of rooms, as subscribers leave in the background
+func (lt *liveTest) waitRoomCount(want int) {
+	lt.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for lt.roomCount() != want {
+		if time.Now().After(deadline) {
+			lt.t.Fatalf("rooms = %d, want %d", lt.roomCount(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestServer_Rooms(t *testing.T) {
+	lt := newServerTest(t)
+	defer lt.close()
+
+	// creating and playing games without subscribers keeps no rooms after they are over
+	for i := 0; i < 3; i++ {
+		var state State
+		lt.post("/games", "", http.StatusCreated, &state)
+		lt.post("/games/"+state.ID+"/resign", `{"color": "gote"}`, http.StatusOK, nil)
+	}
+	var state State
+	lt.post("/games", `{"sfen": "7kl/9/6PPp/9/9/9/9/9/K8 b 2G 1"}`, http.StatusCreated, &state)
+	lt.post("/games/"+state.ID+"/moves", `{"move": "G*2b"}`, http.StatusOK, nil)
+	if n := lt.roomCount(); n != 0 {
+		t.Errorf("rooms = %d after the games are over, want 0", n)
+	}
+
+	// the room of a finished game is kept until its last subscriber leaves
+	lt.post("/games", "", http.StatusCreated, &state)
+	lt.id = state.ID
+	first, second := lt.dial(""), lt.dial("")
+	lt.expect(first, EventState, EventClock)
+	lt.expect(second, EventState, EventClock)
+	lt.post("/games/"+lt.id+"/moves", `{"move": "7g7f"}`, http.StatusOK, nil)
+	lt.post("/games/"+lt.id+"/abort", "", http.StatusOK, nil)
+	lt.expect(first, EventMove, EventClock, EventGameOver)
+	first.Close()
+	time.Sleep(10 * time.Millisecond)
+	lt.waitRoomCount(1)
+	second.Close()
+	lt.waitRoomCount(0)
+
+	// deleting a game closes the connections of its subscribers and removes its room
+	lt.post("/games", "", http.StatusCreated, &state)
+	lt.id = state.ID
+	conn := lt.dial("")
+	defer conn.Close()
+	lt.expect(conn, EventState, EventClock)
+	lt.request(http.MethodDelete, "/games/"+lt.id, "", http.StatusNoContent, nil)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var e Event
+	if err := conn.ReadJSON(&e); err == nil {
+		t.Errorf("read %+v after the game is deleted, want the connection closed", e)
+	}
+	lt.waitRoomCount(0)
+}
+
+func TestServer_Analysis(t *testing.T) {
+	lt := newLiveTest(t, "7kl/9/6PPp/9/9/9/9/9/K8 b 2G 1")
+	defer lt.close()
+
+	var analysis Analysis
+	lt.request(http.MethodGet, "/games/"+lt.id+"/analysis?depth=2&multipv=2", "", http.StatusOK, &analysis)
+	if analysis.SideToMove != "sente" || analysis.Depth != 1 || len(analysis.Candidates) != 2 {
+		t.Fatalf("analysis = %+v, want 2 candidates for sente stopping at depth 1 with the mate", analysis)
+	}
+	best := analysis.Candidates[0]
+	if !best.Mate || best.Score != shogi.MateScore-1 || len(best.PV) != 1 || best.PV[0] != best.Move {
+		t.Errorf("best candidate = %+v, want the mate in 1", best)
+	}
+	if c := analysis.Candidates[1]; c.Move == best.Move || c.Score > best.Score || len(c.PV) == 0 || c.PV[0] != c.Move {
+		t.Errorf("second candidate = %+v, want another move not better than %+v", c, best)
+	}
+	// the analysis doesn't play the move
+	var state State
+	lt.request(http.MethodGet, "/games/"+lt.id, "", http.StatusOK, &state)
+	if len(state.Moves) != 0 {
+		t.Errorf("moves after the analysis = %v, want none", state.Moves)
+	}
+
+	// the default depth and multipv
+	lt.request(http.MethodGet, "/games/"+lt.id+"/analysis", "", http.StatusOK, &analysis)
+	if len(analysis.Candidates) != defaultMultiPV {
+		t.Errorf("analysis has %d candidates, want %d", len(analysis.Candidates), defaultMultiPV)
+	}
+
+	for _, query := range []string{"depth=0", "depth=7", "depth=x", "multipv=0", "multipv=11"} {
+		var res errorResponse
+		lt.request(http.MethodGet, "/games/"+lt.id+"/analysis?"+query, "", http.StatusBadRequest, &res)
+		if res.Error == "" {
+			t.Errorf("analysis with %s responded no error", query)
+		}
+	}
+	lt.request(http.MethodGet, "/games/unknown/analysis", "", http.StatusNotFound, nil)
+
+	// the checkmated position has no move to analyze, and the finished game isn't analyzed
+	var mated State
+	lt.post("/games", `{"sfen": "8k/8G/8P/9/9/9/9/9/K8 w - 1"}`, http.StatusCreated, &mated)
+	lt.request(http.MethodGet, "/games/"+mated.ID+"/analysis", "", http.StatusConflict, nil)
+	lt.post("/games/"+lt.id+"/resign", `{"color": "gote"}`, http.StatusOK, nil)
+	lt.request(http.MethodGet, "/games/"+lt.id+"/analysis", "", http.StatusConflict, nil)
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"github.com/k-yomo/shogi/shogi"
+)
+
+// pieceKindNames are the names of piece kinds in JSON
+var pieceKindNames = [...]string{
+	shogi.KindNone:           "",
+	shogi.KindPawn:           "pawn",
+	shogi.KindLance:          "lance",
+	shogi.KindKnight:         "knight",
+	shogi.KindSilver:         "silver",
+	shogi.KindGold:           "gold",
+	shogi.KindBishop:         "bishop",
+	shogi.KindRook:           "rook",
+	shogi.KindKing:           "king",
+	shogi.KindPromotedPawn:   "promotedPawn",
+	shogi.KindPromotedLance:  "promotedLance",
+	shogi.KindPromotedKnight: "promotedKnight",
+	shogi.KindPromotedSilver: "promotedSilver",
+	shogi.KindPromotedBishop: "promotedBishop",
+	shogi.KindPromotedRook:   "promotedRook",
+}
+
+// Piece is a piece on the board
+type Piece struct {
+	Kind  string `json:"kind"`
+	Color string `json:"color"`
+}
+
+// Result is the result of a finished game
+type Result struct {
+	// Winner is omitted for draws and aborted games
+	Winner string `json:"winner,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// State is the state of a game returned by the API
+type State struct {
+	ID         string `json:"id"`
+	SFEN       string `json:"sfen"`
+	MoveNumber int    `json:"moveNumber"`
+	SideToMove string `json:"sideToMove"`
+	// Board is the ranks from 一 to 九, each of which has the squares from file 9 to 1 as in board diagrams.
+	// Empty squares are null.
+	Board [][]*Piece `json:"board"`
+	// Hands is the number of pieces in hand by color and kind
+	Hands map[string]map[string]int `json:"hands"`
+	// LegalMoves is the legal moves for the side to move in USI
+	LegalMoves []string `json:"legalMoves"`
+	// Moves is the moves played so far in USI
+	Moves []string `json:"moves"`
+	Check bool     `json:"check"`
+	// Result is null while the game is in progress
+	Result *Result `json:"result"`
+	// Seats is given only in the response of creating the game, to be passed on to the players
+	Seats *Seats `json:"seats,omitempty"`
+}
+
+// Seats is the tokens with which the players take their seats over WebSocket
+type Seats struct {
+	Sente string `json:"sente"`
+	Gote  string `json:"gote"`
+}
+
+func colorName(c shogi.Color) string {
+	if c == shogi.Sente {
+		return "sente"
+	}
+	return "gote"
+}
+
+func newState(id string, g *shogi.Game) *State {
+	b := g.Board()
+	s := &State{
+		ID:         id,
+		SFEN:       g.SFEN(),
+		MoveNumber: g.MoveNumber(),
+		SideToMove: colorName(b.SideToMove()),
+		Board:      make([][]*Piece, 9),
+		Hands:      map[string]map[string]int{},
+		LegalMoves: []string{},
+		Moves:      []string{},
+		Check:      b.InCheck(),
+	}
+	for y := shogi.Axis(1); y <= 9; y++ {
+		rank := make([]*Piece, 0, 9)
+		for x := shogi.Axis(9); x >= 1; x-- {
+			var piece *Piece
+			if kind, c := b.PieceAt(shogi.NewSquare(x, y)); kind != shogi.KindNone {
+				piece = &Piece{Kind: pieceKindNames[kind], Color: colorName(c)}
+			}
+			rank = append(rank, piece)
+		}
+		s.Board[y-1] = rank
+	}
+	for _, c := range []shogi.Color{shogi.Sente, shogi.Gote} {
+		hand := map[string]int{}
+		for _, kind := range shogi.HandKinds {
+			if n := b.Hand(c, kind); n > 0 {
+				hand[pieceKindNames[kind]] = n
+			}
+		}
+		s.Hands[colorName(c)] = hand
+	}
+	for _, m := range g.History() {
+		s.Moves = append(s.Moves, m.String())
+	}
+	s.Result = gameResult(g)
+	if s.Result == nil {
+		for _, m := range g.LegalMoves() {
+			s.LegalMoves = append(s.LegalMoves, m.String())
+		}
+	}
+	return s
+}
+
+// reasonNames are the names of the reasons games end in JSON
+var reasonNames = map[shogi.Reason]string{
+	shogi.ReasonCheckmate:      "checkmate",
+	shogi.ReasonResignation:    "resignation",
+	shogi.ReasonTimeUp:         "timeUp",
+	shogi.ReasonIllegalMove:    "illegalMove",
+	shogi.ReasonSennichite:     "sennichite",
+	shogi.ReasonPerpetualCheck: "perpetualCheck",
+	shogi.ReasonJishogi:        "jishogi",
+	shogi.ReasonAbort:          "abort",
+	shogi.ReasonDeclaration:    "declaration",
+}
+
+// gameResult returns the result of the game, or nil if it's in progress
+func gameResult(g *shogi.Game) *Result {
+	result, ok := g.Result()
+	if !ok {
+		return nil
+	}
+	r := &Result{Reason: reasonNames[result.Reason]}
+	if result.HasWinner() {
+		r.Winner = colorName(result.Winner)
+	}
+	return r
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+
+	"github.com/k-yomo/shogi/shogi"
+	"github.com/pkg/errors"
+)
+
+// ErrGameNotFound is returned by Store when there is no game with the ID
+var ErrGameNotFound = errors.New("game not found")
+
+// Store keeps games by ID.
+// Game isn't safe for concurrent use, even for reading since legal move generation makes and takes back moves,
+// so implementations must serialize the functions given to View and Update for the same game.
+type Store interface {
+	// Create stores the game and returns its new ID
+	Create(game *shogi.Game) (string, error)
+	// View calls fn with the game to read it
+	View(id string, fn func(game *shogi.Game) error) error
+	// Update calls fn with the game to modify it
+	Update(id string, fn func(game *shogi.Game) error) error
+	// Delete removes the game after the functions running with it return, and it's not found from then on
+	Delete(id string) error
+}
+
+// MemoryStore is a Store keeping games in memory, which are lost when the process exits
+type MemoryStore struct {
+	mu    sync.RWMutex
+	games map[string]*memoryGame
+}
+
+type memoryGame struct {
+	mu   sync.Mutex
+	game *shogi.Game
+	// deleted is set when the game is deleted while a function is waiting for it
+	deleted bool
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{games: map[string]*memoryGame{}}
+}
+
+func (s *MemoryStore) Create(game *shogi.Game) (string, error) {
+	id, err := newGameID()
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.games[id] = &memoryGame{game: game}
+	return id, nil
+}
+
+func (s *MemoryStore) View(id string, fn func(game *shogi.Game) error) error {
+	return s.Update(id, fn)
+}
+
+func (s *MemoryStore) Update(id string, fn func(game *shogi.Game) error) error {
+	g, err := s.get(id)
+	if err != nil {
+		return err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.deleted {
+		return ErrGameNotFound
+	}
+	return fn(g.game)
+}
+
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	g, ok := s.games[id]
+	delete(s.games, id)
+	s.mu.Unlock()
+	if !ok {
+		return ErrGameNotFound
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.deleted = true
+	return nil
+}
+
+func (s *MemoryStore) get(id string) (*memoryGame, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	g, ok := s.games[id]
+	if !ok {
+		return nil, ErrGameNotFound
+	}
+	return g, nil
+}
+
+func newGameID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "generate game id")
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,316 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// frame encodes the frame, masking it with a fixed key if masked is set
+func frame(fin bool, op byte, payload []byte, masked bool) []byte {
+	b := []byte{op}
+	if fin {
+		b[0] |= 0x80
+	}
+	maskBit := byte(0)
+	if masked {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		b = append(b, maskBit|byte(n))
+	case n <= 0xffff:
+		b = append(b, maskBit|126, byte(n>>8), byte(n))
+	default:
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		b = append(append(b, maskBit|127), ext[:]...)
+	}
+	if !masked {
+		return append(b, payload...)
+	}
+	mask := []byte{0x12, 0x34, 0x56, 0x78}
+	b = append(b, mask...)
+	for i, c := range payload {
+		b = append(b, c^mask[i%4])
+	}
+	return b
+}
+
+// serve lets the server side of a connection read a message from the frames sent by the client,
+// and returns what the server wrote to the client until the connection was closed
+func serve(t *testing.T, frames ...[]byte) (message, written []byte, err error) {
+	t.Helper()
+	client, server := net.Pipe()
+	c := &Conn{conn: server, br: bufio.NewReader(server)}
+	// the frames accepted by mistake leave the server waiting for more
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	go func() {
+		// the server may close the connection before reading everything
+		client.Write(bytes.Join(frames, nil))
+	}()
+	read := make(chan []byte)
+	go func() {
+		b, _ := ioutil.ReadAll(client)
+		read <- b
+	}()
+	message, err = c.ReadMessage()
+	server.Close()
+	written = <-read
+	client.Close()
+	return message, written, err
+}
+
+var (
+	normalClosure = frame(true, opClose, []byte{0x03, 0xe8}, false)
+	protocolError = frame(true, opClose, []byte{0x03, 0xea}, false)
+)
+
+func TestConn_ReadMessage(t *testing.T) {
+	long := bytes.Repeat([]byte("a"), 200)
+	longer := bytes.Repeat([]byte("b"), 70000)
+	tests := []struct {
+		name   string
+		frames [][]byte
+		want   []byte
+		// wantErr is a part of the error, which is empty if the message is read
+		wantErr string
+		// wantWritten is what the server writes to the client
+		wantWritten []byte
+	}{
+		{
+			name:   "masked text",
+			frames: [][]byte{frame(true, opText, []byte("hello"), true)},
+			want:   []byte("hello"),
+		},
+		{
+			name:        "unmasked frame from a client",
+			frames:      [][]byte{frame(true, opText, []byte("hello"), false)},
+			wantErr:     "invalid masking",
+			wantWritten: protocolError,
+		},
+		{
+			name:   "16-bit length",
+			frames: [][]byte{frame(true, opBinary, long, true)},
+			want:   long,
+		},
+		{
+			name:   "64-bit length",
+			frames: [][]byte{frame(true, opText, longer, true)},
+			want:   longer,
+		},
+		{
+			name: "fragments with a ping and a pong between them",
+			frames: [][]byte{
+				frame(false, opText, []byte("hel"), true),
+				frame(true, opPing, []byte("hi"), true),
+				frame(false, opContinuation, []byte("l"), true),
+				frame(true, opPong, []byte("hi"), true),
+				frame(true, opContinuation, []byte("o"), true),
+			},
+			want:        []byte("hello"),
+			wantWritten: frame(true, opPong, []byte("hi"), false),
+		},
+		{
+			name:        "continuation without a message",
+			frames:      [][]byte{frame(true, opContinuation, []byte("lo"), true)},
+			wantErr:     "continuation frame without a message",
+			wantWritten: protocolError,
+		},
+		{
+			name:        "new message before the previous one finished",
+			frames:      [][]byte{frame(false, opText, []byte("hel"), true), frame(true, opText, []byte("lo"), true)},
+			wantErr:     "new message started",
+			wantWritten: protocolError,
+		},
+		{
+			name:        "fragmented ping",
+			frames:      [][]byte{frame(false, opPing, []byte("hi"), true)},
+			wantErr:     "fragmented control frame",
+			wantWritten: protocolError,
+		},
+		{
+			name:        "too large ping",
+			frames:      [][]byte{frame(true, opPing, bytes.Repeat([]byte("p"), 126), true)},
+			wantErr:     "frame is too large",
+			wantWritten: protocolError,
+		},
+		{
+			name:        "too large message",
+			frames:      [][]byte{frame(false, opText, bytes.Repeat([]byte("a"), MaxMessageSize), true), frame(true, opContinuation, []byte("a"), true)},
+			wantErr:     "message is too large",
+			wantWritten: protocolError,
+		},
+		{
+			name:        "unknown opcode",
+			frames:      [][]byte{frame(true, 0x3, []byte("?"), true)},
+			wantErr:     "unknown opcode",
+			wantWritten: protocolError,
+		},
+		{
+			name:        "close",
+			frames:      [][]byte{frame(true, opClose, []byte{0x03, 0xe8}, true)},
+			wantErr:     ErrClosed.Error(),
+			wantWritten: normalClosure,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, written, err := serve(t, tt.frames...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ReadMessage() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Errorf("ReadMessage() error = %v", err)
+			} else if !bytes.Equal(got, tt.want) {
+				t.Errorf("ReadMessage() = %d bytes, want %d bytes", len(got), len(tt.want))
+			}
+			if !bytes.Equal(written, tt.wantWritten) {
+				t.Errorf("written = %x, want %x", written, tt.wantWritten)
+			}
+		})
+	}
+}
+
+func TestConn_WriteMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		// header is the header of the frame without the mask bit
+		header []byte
+	}{
+		{name: "7-bit length", size: 125, header: []byte{0x81, 125}},
+		{name: "16-bit length", size: 126, header: []byte{0x81, 126, 0x00, 0x7e}},
+		{name: "largest 16-bit length", size: 0xffff, header: []byte{0x81, 126, 0xff, 0xff}},
+		{name: "64-bit length", size: 0x10000, header: []byte{0x81, 127, 0, 0, 0, 0, 0, 0x01, 0x00, 0x00}},
+	}
+	for _, tt := range tests {
+		for _, isClient := range []bool{false, true} {
+			payload := bytes.Repeat([]byte("x"), tt.size)
+			a, b := net.Pipe()
+			c := &Conn{conn: a, isClient: isClient}
+			go func() {
+				c.WriteMessage(payload)
+				a.Close()
+			}()
+			got, _ := ioutil.ReadAll(b)
+			b.Close()
+
+			header := append([]byte(nil), got[:len(tt.header)]...)
+			// clients mask the frames and servers don't
+			if masked := header[1]&0x80 != 0; masked != isClient {
+				t.Errorf("%s: masked = %t from a client %t", tt.name, masked, isClient)
+			}
+			header[1] &^= 0x80
+			if !bytes.Equal(header, tt.header) {
+				t.Errorf("%s: header = %x, want %x", tt.name, header, tt.header)
+			}
+			body := got[len(tt.header):]
+			if isClient {
+				mask := body[:4]
+				body = body[4:]
+				for i := range body {
+					body[i] ^= mask[i%4]
+				}
+			}
+			if !bytes.Equal(body, payload) {
+				t.Errorf("%s: payload from a client %t isn't the message", tt.name, isClient)
+			}
+		}
+	}
+}
+
+func TestConn_Close(t *testing.T) {
+	a, b := net.Pipe()
+	c := &Conn{conn: a}
+	go c.Close()
+	got, _ := ioutil.ReadAll(b)
+	b.Close()
+	if !bytes.Equal(got, normalClosure) {
+		t.Errorf("Close() wrote %x, want %x", got, normalClosure)
+	}
+	if err := c.WriteMessage([]byte("hello")); err != ErrClosed {
+		t.Errorf("WriteMessage() after Close() error = %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// echo the messages
+		for {
+			message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(message); err != nil {
+				return
+			}
+		}
+	}))
+	defer ts.Close()
+
+	conn, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	if err := conn.WriteMessage([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := conn.ReadMessage(); err != nil || string(got) != "hello" {
+		t.Errorf("ReadMessage() = %q, %v, want the echo", got, err)
+	}
+	conn.Close()
+
+	host := strings.TrimPrefix(ts.URL, "http://")
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   int
+	}{
+		{name: "no origin", want: http.StatusSwitchingProtocols},
+		{name: "same origin", header: map[string]string{"Origin": "http://" + host}, want: http.StatusSwitchingProtocols},
+		{name: "another origin", header: map[string]string{"Origin": "http://example.com"}, want: http.StatusForbidden},
+		{name: "another port", header: map[string]string{"Origin": "http://" + strings.Split(host, ":")[0] + ":1"}, want: http.StatusForbidden},
+		{name: "old version", header: map[string]string{"Sec-WebSocket-Version": "8"}, want: http.StatusBadRequest},
+		{name: "no key", header: map[string]string{"Sec-WebSocket-Key": ""}, want: http.StatusBadRequest},
+		{name: "no upgrade", header: map[string]string{"Upgrade": ""}, want: http.StatusUpgradeRequired},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		for k, v := range tt.header {
+			req.Header.Set(k, v)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, res.StatusCode, tt.want)
+		}
+		// the accept key of the example in RFC 6455
+		if res.StatusCode == http.StatusSwitchingProtocols && res.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+			t.Errorf("%s: accept key = %q", tt.name, res.Header.Get("Sec-WebSocket-Accept"))
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package shogi
+
+import (
+	"context"
+	"sort"
+)
+
+const defaultMultiPV = 3
+
+// AnalyzeOptions configures the analysis
+type AnalyzeOptions struct {
+	// Depth is the maximum depth in plies. It defaults to 4.
+	Depth int
+	// MultiPV is the number of candidate moves, 3 if it's zero
+	MultiPV int
+	// Moves restricts the candidate moves to the legal ones of them if it's not empty, like searchmoves of USI
+	Moves []Move
+	// TableSize is the number of entries of the transposition table, 1<<18 if it's zero
+	TableSize int
+	// QuiescenceChecks makes the quiescence search try the checking moves, see SearchOptions
+	QuiescenceChecks bool
+	// OnUpdate is called with the analysis of each completed depth if it's not nil
+	OnUpdate func(Analysis)
+}
+
+// Analysis is the result of the deepest completed depth of the analysis
+type Analysis struct {
+	Depth int
+	// Candidates are the best moves of the position, from the best
+	Candidates []Candidate
+	Nodes      uint64
+}
+
+// Candidate is a candidate move and its evaluation
+type Candidate struct {
+	Move Move
+	// Score is from the side to move's perspective, see SearchResult.Score
+	Score int
+	// PV is the principal variation starting with Move
+	PV []Move
+}
+
+// IsMate reports if the score means a forced mate for either side
+func (c Candidate) IsMate() bool {
+	return c.Score >= mateThreshold || c.Score <= -mateThreshold
+}
+
+// Best returns the best candidate, ok is false if there is no candidate
+func (a Analysis) Best() (c Candidate, ok bool) {
+	if len(a.Candidates) == 0 {
+		return Candidate{}, false
+	}
+	return a.Candidates[0], true
+}
+
+// Analyze evaluates the best moves of the position with iterative deepening until the depth or the context is done.
+// Each candidate is searched with the better candidates excluded from the root moves, sharing the transposition table.
+// ok is false if the side to move has no legal move, or none of the moves of opts.Moves is legal.
+func (b *BitboardBoard) Analyze(ctx context.Context, opts AnalyzeOptions) (analysis Analysis, ok bool) {
+	maxDepth := opts.Depth
+	if maxDepth <= 0 {
+		maxDepth = defaultSearchDepth
+	}
+	multiPV := opts.MultiPV
+	if multiPV <= 0 {
+		multiPV = defaultMultiPV
+	}
+	rootMoves := b.LegalMoves()
+	if len(opts.Moves) > 0 {
+		n := 0
+		for _, m := range rootMoves {
+			if containsMove(opts.Moves, m) {
+				rootMoves[n] = m
+				n++
+			}
+		}
+		rootMoves = rootMoves[:n]
+	}
+	if len(rootMoves) == 0 {
+		return Analysis{}, false
+	}
+	if multiPV > len(rootMoves) {
+		multiPV = len(rootMoves)
+	}
+
+	s := &searcher{ctx: ctx, board: b.Clone(), tt: newTranspositionTable(opts.TableSize), checks: opts.QuiescenceChecks}
+	for depth := 1; depth <= maxDepth; depth++ {
+		// the search notices the context only every nodesPerContextCheck nodes, which the shallow depths may not reach
+		if ctx.Err() != nil {
+			break
+		}
+		candidates, completed := s.searchCandidates(rootMoves, analysis.Candidates, depth, multiPV)
+		if !completed {
+			break
+		}
+		analysis = Analysis{Depth: depth, Candidates: candidates, Nodes: s.nodes}
+		if opts.OnUpdate != nil {
+			opts.OnUpdate(analysis)
+		}
+		if candidates[0].IsMate() {
+			break
+		}
+	}
+	if analysis.Depth == 0 {
+		// not even the first depth completed
+		analysis.Candidates = []Candidate{{Move: rootMoves[0], PV: []Move{rootMoves[0]}}}
+	}
+	analysis.Nodes = s.nodes
+	return analysis, true
+}
+
+// Analyze evaluates the best moves for the current player, see BitboardBoard.Analyze
+func (g *Game) Analyze(ctx context.Context, opts AnalyzeOptions) (Analysis, bool) {
+	return g.board.Analyze(ctx, opts)
+}
+
+// searchCandidates searches the best n moves at the depth, starting each search with the principal variation of
+// the best previous candidate still remaining. completed is false if the search is stopped.
+func (s *searcher) searchCandidates(rootMoves []Move, previous []Candidate, depth, n int) (candidates []Candidate, completed bool) {
+	remaining := append([]Move(nil), rootMoves...)
+	defer func() { s.rootMoves = nil }()
+	for len(candidates) < n {
+		s.rootMoves = remaining
+		s.pv = nil
+		for _, c := range previous {
+			if containsMove(remaining, c.Move) {
+				s.pv = c.PV
+				break
+			}
+		}
+		var pv []Move
+		score := s.alphaBeta(depth, 0, -infiniteScore, infiniteScore, &pv)
+		if s.stopped {
+			return nil, false
+		}
+		candidates = append(candidates, Candidate{Move: pv[0], Score: score, PV: pv})
+		remaining = removeMove(remaining, pv[0])
+	}
+	// a later search may find a better score with the deeper entries of the transposition table
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	return candidates, true
+}
+
+func containsMove(moves []Move, m Move) bool {
+	for _, move := range moves {
+		if move == m {
+			return true
+		}
+	}
+	return false
+}
+
+// removeMove returns the moves without m, reusing the slice
+func removeMove(moves []Move, m Move) []Move {
+	for i, move := range moves {
+		if move == m {
+			return append(moves[:i], moves[i+1:]...)
+		}
+	}
+	return moves
+}
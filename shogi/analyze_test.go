@@ -0,0 +1,160 @@
+package shogi
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGame_Analyze(t *testing.T) {
+	game, err := NewGameFromSFEN(middleGameSFEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var updates []Analysis
+	analysis, ok := game.Analyze(context.Background(), AnalyzeOptions{
+		Depth:    3,
+		MultiPV:  3,
+		OnUpdate: func(a Analysis) { updates = append(updates, a) },
+	})
+	if !ok {
+		t.Fatal("Analyze() found no legal move")
+	}
+	if game.SFEN() != middleGameSFEN {
+		t.Errorf("Analyze() changed the game to %s", game.SFEN())
+	}
+
+	// every depth is reported in order
+	if len(updates) != 3 {
+		t.Fatalf("OnUpdate() is called %d times, want 3", len(updates))
+	}
+	for i, u := range updates {
+		if u.Depth != i+1 {
+			t.Errorf("updates[%d].Depth = %d, want %d", i, u.Depth, i+1)
+		}
+		if i > 0 && u.Nodes <= updates[i-1].Nodes {
+			t.Errorf("updates[%d].Nodes = %d, want more than %d", i, u.Nodes, updates[i-1].Nodes)
+		}
+	}
+	if analysis.Depth != 3 || analysis.Nodes != updates[2].Nodes {
+		t.Errorf("Analyze() = depth %d with %d nodes, want the last update of depth 3 with %d nodes", analysis.Depth, analysis.Nodes, updates[2].Nodes)
+	}
+
+	// the candidates are distinct legal moves from the best
+	if len(analysis.Candidates) != 3 {
+		t.Fatalf("Analyze() has %d candidates, want 3", len(analysis.Candidates))
+	}
+	seen := map[Move]bool{}
+	for i, c := range analysis.Candidates {
+		if game.board.ValidateMove(c.Move) != nil || seen[c.Move] {
+			t.Errorf("Candidates[%d].Move = %s, want a distinct legal move", i, c.Move)
+		}
+		seen[c.Move] = true
+		if len(c.PV) == 0 || c.PV[0] != c.Move {
+			t.Errorf("Candidates[%d].PV = %v, want it to start with %s", i, c.PV, c.Move)
+		}
+		if i > 0 && c.Score > analysis.Candidates[i-1].Score {
+			t.Errorf("Candidates[%d].Score = %d, want at most %d of the better candidate", i, c.Score, analysis.Candidates[i-1].Score)
+		}
+	}
+	if best, ok := analysis.Best(); !ok || best.Move != analysis.Candidates[0].Move {
+		t.Errorf("Best() = %+v, %v, want the first candidate", best, ok)
+	}
+}
+
+func TestGame_Analyze_Options(t *testing.T) {
+	analyze := func(sfen string, opts AnalyzeOptions) (Analysis, bool) {
+		t.Helper()
+		game, err := NewGameFromSFEN(sfen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return game.Analyze(context.Background(), opts)
+	}
+	// the king at 9i has 3 legal moves
+	const lonelyKing = "8k/9/9/9/9/9/9/9/K8 b - 1"
+	board, _, err := ParseSFEN(lonelyKing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	up, err := board.ParseMove("9i9h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	right, err := board.ParseMove("9i8i")
+	if err != nil {
+		t.Fatal(err)
+	}
+	illegal, err := board.ParseMove("9i9g")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// more candidates than the legal moves
+	if a, ok := analyze(lonelyKing, AnalyzeOptions{Depth: 1, MultiPV: 5}); !ok || len(a.Candidates) != 3 {
+		t.Errorf("Analyze() with multipv 5 = %+v, %v, want the 3 legal moves", a, ok)
+	}
+
+	// the candidates restricted to the legal ones of the moves
+	a, ok := analyze(lonelyKing, AnalyzeOptions{Depth: 1, Moves: []Move{up, right, illegal}})
+	if !ok || len(a.Candidates) != 2 {
+		t.Fatalf("Analyze() with the moves = %+v, %v, want 2 candidates", a, ok)
+	}
+	for _, c := range a.Candidates {
+		if c.Move != up && c.Move != right {
+			t.Errorf("Analyze() with the moves has the candidate %s", c.Move)
+		}
+	}
+	if a, ok := analyze(lonelyKing, AnalyzeOptions{Depth: 1, Moves: []Move{illegal}}); ok {
+		t.Errorf("Analyze() with only an illegal move = %+v, want no candidate", a)
+	}
+
+	// no legal move
+	if a, ok := analyze("8k/8G/8P/9/9/9/9/9/K8 w - 1", AnalyzeOptions{Depth: 1}); ok {
+		t.Errorf("Analyze() of the checkmated position = %+v, want no candidate", a)
+	}
+
+	// the analysis stops at the depth finding a mate
+	var depths []int
+	a, ok = analyze("7kl/9/6PPp/9/9/9/9/9/K8 b 2G 1", AnalyzeOptions{Depth: 4, MultiPV: 2, OnUpdate: func(a Analysis) {
+		depths = append(depths, a.Depth)
+	}})
+	if !ok || len(depths) != 1 || a.Depth != 1 {
+		t.Fatalf("Analyze() of a mate in 1 = %+v with the updates of depths %v, want only depth 1", a, depths)
+	}
+	if best := a.Candidates[0]; !best.IsMate() || best.Score != MateScore-1 {
+		t.Errorf("Analyze() of a mate in 1 = %+v, want the score %d", best, MateScore-1)
+	}
+}
+
+func TestGame_Analyze_Cancel(t *testing.T) {
+	game, err := NewGameFromSFEN(middleGameSFEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	timeout, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	for name, ctx := range map[string]context.Context{"cancelled": cancelled, "timeout": timeout} {
+		updated := 0
+		start := time.Now()
+		analysis, ok := game.Analyze(ctx, AnalyzeOptions{Depth: 30, OnUpdate: func(Analysis) { updated++ }})
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%s: Analyze() took %s after the context was done", name, elapsed)
+		}
+		if !ok || len(analysis.Candidates) == 0 || game.board.ValidateMove(analysis.Candidates[0].Move) != nil {
+			t.Fatalf("%s: Analyze() = %+v, %v, want a legal move", name, analysis, ok)
+		}
+		if analysis.Depth >= 30 || updated != analysis.Depth {
+			t.Errorf("%s: Analyze() completed depth %d with %d updates, want fewer than 30 and an update for each", name, analysis.Depth, updated)
+		}
+	}
+
+	// a cancelled analysis completes no depth but still gives a legal move
+	analysis, _ := game.Analyze(cancelled, AnalyzeOptions{Depth: 3, MultiPV: 3})
+	if analysis.Depth != 0 || len(analysis.Candidates) != 1 || len(analysis.Candidates[0].PV) != 1 {
+		t.Errorf("Analyze() of the cancelled context = %+v, want a candidate without depth", analysis)
+	}
+}
@@ -0,0 +1,160 @@
+package shogi
+
+// direction is a unit step on the board. dy is -1 towards the top (gote's camp).
+type direction struct {
+	dx, dy int
+}
+
+// isAscending reports if a step in the direction increases the square index
+func (d direction) isAscending() bool {
+	return d.dy*9+d.dx > 0
+}
+
+const (
+	dirUp = iota
+	dirUpRight
+	dirRight
+	dirDownRight
+	dirDown
+	dirDownLeft
+	dirLeft
+	dirUpLeft
+	numDirections
+)
+
+var directions = [numDirections]direction{
+	{dx: 0, dy: -1}, {dx: 1, dy: -1}, {dx: 1, dy: 0}, {dx: 1, dy: 1},
+	{dx: 0, dy: 1}, {dx: -1, dy: 1}, {dx: -1, dy: 0}, {dx: -1, dy: -1},
+}
+
+var rookDirections = []int{dirUp, dirRight, dirDown, dirLeft}
+var bishopDirections = []int{dirUpRight, dirDownRight, dirDownLeft, dirUpLeft}
+
+var (
+	// stepAttackTable holds attacks of the pieces moving a single step (or a knight jump)
+	stepAttackTable [numColors][numPieceKinds][NumSquares]Bitboard
+	// rayTable holds every square from the square (exclusive) to the edge of the board in the direction
+	rayTable [numDirections][NumSquares]Bitboard
+	// fileTable holds the squares on the same column
+	fileTable [10]Bitboard
+	// promotionZoneTable holds the opponent area where the pieces of the color can promote
+	promotionZoneTable [numColors]Bitboard
+	// deadEndTable holds the squares where the piece of the kind and color can never move any further
+	deadEndTable [numColors][numPieceKinds]Bitboard
+)
+
+func init() {
+	relativePositions := map[PieceKind]PositionList{
+		KindPawn:           PawnMovableRelativePositions(),
+		KindKnight:         KnightMovableRelativePositions(),
+		KindSilver:         SilverMovableRelativePositions(),
+		KindGold:           GoldMovableRelativePositions(),
+		KindKing:           KingMovableRelativePositions(),
+		KindPromotedPawn:   GoldMovableRelativePositions(),
+		KindPromotedLance:  GoldMovableRelativePositions(),
+		KindPromotedKnight: GoldMovableRelativePositions(),
+		KindPromotedSilver: GoldMovableRelativePositions(),
+	}
+	for sq := Square(0); sq < NumSquares; sq++ {
+		x, y := int(sq.X()), int(sq.Y())
+		for c := Sente; c <= Gote; c++ {
+			// relative positions are from first player's perspective, forward is positive Y
+			yDirection := -1
+			if c == Gote {
+				yDirection = 1
+			}
+			for kind, positions := range relativePositions {
+				for _, rel := range positions {
+					ax, ay := x+int(rel.X), y+int(rel.Y)*yDirection
+					if ax >= 1 && ax <= 9 && ay >= 1 && ay <= 9 {
+						stepAttackTable[c][kind][sq].Set(NewSquare(Axis(ax), Axis(ay)))
+					}
+				}
+			}
+		}
+		for d, dir := range directions {
+			for ax, ay := x+dir.dx, y+dir.dy; ax >= 1 && ax <= 9 && ay >= 1 && ay <= 9; ax, ay = ax+dir.dx, ay+dir.dy {
+				rayTable[d][sq].Set(NewSquare(Axis(ax), Axis(ay)))
+			}
+		}
+		fileTable[x].Set(sq)
+		if y <= 3 {
+			promotionZoneTable[Sente].Set(sq)
+		}
+		if y >= 7 {
+			promotionZoneTable[Gote].Set(sq)
+		}
+		if y == 1 {
+			deadEndTable[Sente][KindPawn].Set(sq)
+			deadEndTable[Sente][KindLance].Set(sq)
+		}
+		if y <= 2 {
+			deadEndTable[Sente][KindKnight].Set(sq)
+		}
+		if y == 9 {
+			deadEndTable[Gote][KindPawn].Set(sq)
+			deadEndTable[Gote][KindLance].Set(sq)
+		}
+		if y >= 8 {
+			deadEndTable[Gote][KindKnight].Set(sq)
+		}
+	}
+}
+
+// rayAttacks returns the squares reachable in the direction until the first occupied square (inclusive)
+func rayAttacks(sq Square, d int, occupied Bitboard) Bitboard {
+	ray := rayTable[d][sq]
+	blockers := ray.And(occupied)
+	if blockers.IsZero() {
+		return ray
+	}
+	var blocker Square
+	if directions[d].isAscending() {
+		blocker = blockers.First()
+	} else {
+		blocker = blockers.Last()
+	}
+	return ray.AndNot(rayTable[d][blocker])
+}
+
+func rookAttacks(sq Square, occupied Bitboard) Bitboard {
+	var attacks Bitboard
+	for _, d := range rookDirections {
+		attacks = attacks.Or(rayAttacks(sq, d, occupied))
+	}
+	return attacks
+}
+
+func bishopAttacks(sq Square, occupied Bitboard) Bitboard {
+	var attacks Bitboard
+	for _, d := range bishopDirections {
+		attacks = attacks.Or(rayAttacks(sq, d, occupied))
+	}
+	return attacks
+}
+
+func lanceAttacks(c Color, sq Square, occupied Bitboard) Bitboard {
+	if c == Sente {
+		return rayAttacks(sq, dirUp, occupied)
+	}
+	return rayAttacks(sq, dirDown, occupied)
+}
+
+// PieceAttacks returns the squares attacked by a piece of the kind and color at the square,
+// taking the occupied squares into account for sliding pieces.
+func PieceAttacks(kind PieceKind, c Color, sq Square, occupied Bitboard) Bitboard {
+	switch kind {
+	case KindLance:
+		return lanceAttacks(c, sq, occupied)
+	case KindBishop:
+		return bishopAttacks(sq, occupied)
+	case KindRook:
+		return rookAttacks(sq, occupied)
+	case KindPromotedBishop:
+		return bishopAttacks(sq, occupied).Or(stepAttackTable[c][KindKing][sq])
+	case KindPromotedRook:
+		return rookAttacks(sq, occupied).Or(stepAttackTable[c][KindKing][sq])
+	default:
+		return stepAttackTable[c][kind][sq]
+	}
+}
@@ -0,0 +1,171 @@
+package shogi
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Square is an index of a square on the board, (Y-1)*9 + (X-1).
+// Unlike Position it's a plain value, so it can be used without allocation.
+type Square int8
+
+const NumSquares = 81
+
+// NoSquare represents absence of a square, e.g. the origin of a dropped piece
+const NoSquare Square = -1
+
+func NewSquare(x, y Axis) Square {
+	return Square(y.Idx()*9 + x.Idx())
+}
+
+// SquareOf converts the position to a square.
+func SquareOf(pos *Position) Square {
+	return NewSquare(pos.X, pos.Y)
+}
+
+func (s Square) X() Axis {
+	return Axis(int(s)%9 + 1)
+}
+
+func (s Square) Y() Axis {
+	return Axis(int(s)/9 + 1)
+}
+
+func (s Square) IsValid() bool {
+	return s >= 0 && s < NumSquares
+}
+
+func (s Square) Position() *Position {
+	return &Position{X: s.X(), Y: s.Y()}
+}
+
+// String returns the square in USI notation, e.g. "7g"
+func (s Square) String() string {
+	if !s.IsValid() {
+		return "-"
+	}
+	return fmt.Sprintf("%d%c", s.X(), 'a'+rune(s.Y()-1))
+}
+
+// Bitboard is a set of squares represented as an 81-bit integer.
+// Squares 0-63 are stored in lo, and squares 64-80 are stored in hi.
+type Bitboard struct {
+	lo uint64
+	hi uint64
+}
+
+const bitboardHiMask = 1<<(NumSquares-64) - 1
+
+// FullBitboard contains every square on the board
+var FullBitboard = Bitboard{lo: ^uint64(0), hi: bitboardHiMask}
+
+func SquareBitboard(sq Square) Bitboard {
+	if sq < 64 {
+		return Bitboard{lo: 1 << uint(sq)}
+	}
+	return Bitboard{hi: 1 << uint(sq-64)}
+}
+
+func (bb Bitboard) Has(sq Square) bool {
+	if sq < 64 {
+		return bb.lo&(1<<uint(sq)) != 0
+	}
+	return bb.hi&(1<<uint(sq-64)) != 0
+}
+
+func (bb *Bitboard) Set(sq Square) {
+	if sq < 64 {
+		bb.lo |= 1 << uint(sq)
+	} else {
+		bb.hi |= 1 << uint(sq-64)
+	}
+}
+
+func (bb *Bitboard) Clear(sq Square) {
+	if sq < 64 {
+		bb.lo &^= 1 << uint(sq)
+	} else {
+		bb.hi &^= 1 << uint(sq-64)
+	}
+}
+
+func (bb Bitboard) And(o Bitboard) Bitboard {
+	return Bitboard{lo: bb.lo & o.lo, hi: bb.hi & o.hi}
+}
+
+func (bb Bitboard) Or(o Bitboard) Bitboard {
+	return Bitboard{lo: bb.lo | o.lo, hi: bb.hi | o.hi}
+}
+
+func (bb Bitboard) AndNot(o Bitboard) Bitboard {
+	return Bitboard{lo: bb.lo &^ o.lo, hi: bb.hi &^ o.hi}
+}
+
+func (bb Bitboard) Not() Bitboard {
+	return Bitboard{lo: ^bb.lo, hi: ^bb.hi & bitboardHiMask}
+}
+
+func (bb Bitboard) IsZero() bool {
+	return bb.lo == 0 && bb.hi == 0
+}
+
+func (bb Bitboard) Count() int {
+	return bits.OnesCount64(bb.lo) + bits.OnesCount64(bb.hi)
+}
+
+// First returns the square with the smallest index, or NoSquare if the bitboard is empty
+func (bb Bitboard) First() Square {
+	if bb.lo != 0 {
+		return Square(bits.TrailingZeros64(bb.lo))
+	}
+	if bb.hi != 0 {
+		return Square(64 + bits.TrailingZeros64(bb.hi))
+	}
+	return NoSquare
+}
+
+// Last returns the square with the largest index, or NoSquare if the bitboard is empty
+func (bb Bitboard) Last() Square {
+	if bb.hi != 0 {
+		return Square(127 - bits.LeadingZeros64(bb.hi))
+	}
+	if bb.lo != 0 {
+		return Square(63 - bits.LeadingZeros64(bb.lo))
+	}
+	return NoSquare
+}
+
+// PopFirst removes the square with the smallest index from the bitboard and returns it
+func (bb *Bitboard) PopFirst() Square {
+	if bb.lo != 0 {
+		sq := Square(bits.TrailingZeros64(bb.lo))
+		bb.lo &= bb.lo - 1
+		return sq
+	}
+	sq := Square(64 + bits.TrailingZeros64(bb.hi))
+	bb.hi &= bb.hi - 1
+	return sq
+}
+
+func (bb Bitboard) PositionList() PositionList {
+	positions := make(PositionList, 0, bb.Count())
+	for !bb.IsZero() {
+		positions = append(positions, bb.PopFirst().Position())
+	}
+	return positions
+}
+
+func (bb Bitboard) String() string {
+	var s string
+	for y := Axis(1); y <= 9; y++ {
+		for x := Axis(9); x >= 1; x-- {
+			if bb.Has(NewSquare(x, y)) {
+				s += "1"
+			} else {
+				s += "."
+			}
+		}
+		s += "\n"
+	}
+	return s
+}
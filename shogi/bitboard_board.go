@@ -0,0 +1,359 @@
+package shogi
+
+import (
+	"github.com/pkg/errors"
+)
+
+// BitboardBoard is a board representation built on bitboards for each piece kind and color.
+// Pieces are plain kinds instead of Piece values, so move generation and making/unmaking moves don't allocate.
+// It also holds the pieces in hand and the side to move, which is everything needed to generate legal moves.
+type BitboardBoard struct {
+	kinds    [NumSquares]PieceKind
+	owners   [NumSquares]Color
+	pieces   [numColors][numPieceKinds]Bitboard
+	occupied [numColors]Bitboard
+	hands    [numColors][numPieceKinds]int
+	turn     Color
+	// hash is updated as the pieces move, see Hash
+	hash uint64
+}
+
+// NewBitboardBoard returns a board with the initial layout and sente to move
+func NewBitboardBoard() *BitboardBoard {
+	return NewBitboardBoardFrom(NewBoard(NewPlayer(true), NewPlayer(false)))
+}
+
+// NewBitboardBoardFrom converts the board into a bitboard board with sente to move.
+// Pieces in hand are kept by players, so they need to be set separately.
+func NewBitboardBoardFrom(board Board) *BitboardBoard {
+	b := &BitboardBoard{}
+	board.iterateThrough(func(pos *Position, piece Piece, exist bool) (finished bool) {
+		if exist {
+			b.put(SquareOf(pos), PieceKindOf(piece), ColorOf(piece.Owner()))
+		}
+		return false
+	})
+	return b
+}
+
+// Board converts the bitboard board into Board with pieces owned by the given players
+func (b *BitboardBoard) Board(firstPlayer, secondPlayer Player) Board {
+	board := make(Board, 9)
+	for i := range board {
+		board[i] = make([]Piece, 9)
+	}
+	for sq := Square(0); sq < NumSquares; sq++ {
+		if b.kinds[sq] == KindNone {
+			continue
+		}
+		owner := firstPlayer
+		if b.owners[sq] == Gote {
+			owner = secondPlayer
+		}
+		board[sq.Y().Idx()][sq.X().Idx()] = NewPiece(b.kinds[sq], owner)
+	}
+	return board
+}
+
+func (b *BitboardBoard) String() string {
+	return b.Board(NewPlayer(true), NewPlayer(false)).String()
+}
+
+// Clone returns a deep copy of the board
+func (b *BitboardBoard) Clone() *BitboardBoard {
+	clone := *b
+	return &clone
+}
+
+func (b *BitboardBoard) SideToMove() Color {
+	return b.turn
+}
+
+// PieceAt returns the kind and the owner of the piece at the square. The kind is KindNone for an empty square.
+func (b *BitboardBoard) PieceAt(sq Square) (PieceKind, Color) {
+	return b.kinds[sq], b.owners[sq]
+}
+
+// Hand returns the number of pieces of the kind the color has in hand
+func (b *BitboardBoard) Hand(c Color, kind PieceKind) int {
+	return b.hands[c][kind]
+}
+
+// Pieces returns the squares of the pieces of the kind and color
+func (b *BitboardBoard) Pieces(c Color, kind PieceKind) Bitboard {
+	return b.pieces[c][kind]
+}
+
+func (b *BitboardBoard) Occupied() Bitboard {
+	return b.occupied[Sente].Or(b.occupied[Gote])
+}
+
+func (b *BitboardBoard) OccupiedBy(c Color) Bitboard {
+	return b.occupied[c]
+}
+
+// KingSquare returns the square of the color's king, or NoSquare if there is no king
+func (b *BitboardBoard) KingSquare(c Color) Square {
+	return b.pieces[c][KindKing].First()
+}
+
+func (b *BitboardBoard) put(sq Square, kind PieceKind, c Color) {
+	b.kinds[sq] = kind
+	b.owners[sq] = c
+	b.pieces[c][kind].Set(sq)
+	b.occupied[c].Set(sq)
+	b.hash += zobristPieces[c][kind][sq]
+}
+
+func (b *BitboardBoard) remove(sq Square) {
+	kind, c := b.kinds[sq], b.owners[sq]
+	b.pieces[c][kind].Clear(sq)
+	b.occupied[c].Clear(sq)
+	b.kinds[sq] = KindNone
+	b.hash -= zobristPieces[c][kind][sq]
+}
+
+// AttackersTo returns the squares of the color's pieces attacking the square
+func (b *BitboardBoard) AttackersTo(sq Square, by Color) Bitboard {
+	return b.attackersTo(sq, by, b.Occupied())
+}
+
+func (b *BitboardBoard) attackersTo(sq Square, by Color, occupied Bitboard) Bitboard {
+	// a piece attacks sq if it's on a square which the same kind of the opponent's piece at sq would attack
+	them := by.Opponent()
+	p := &b.pieces[by]
+	golds := p[KindGold].Or(p[KindPromotedPawn]).Or(p[KindPromotedLance]).Or(p[KindPromotedKnight]).Or(p[KindPromotedSilver])
+	kings := p[KindKing].Or(p[KindPromotedBishop]).Or(p[KindPromotedRook])
+	attackers := stepAttackTable[them][KindPawn][sq].And(p[KindPawn]).
+		Or(stepAttackTable[them][KindKnight][sq].And(p[KindKnight])).
+		Or(stepAttackTable[them][KindSilver][sq].And(p[KindSilver])).
+		Or(stepAttackTable[them][KindGold][sq].And(golds)).
+		Or(stepAttackTable[them][KindKing][sq].And(kings)).
+		Or(lanceAttacks(them, sq, occupied).And(p[KindLance]))
+	if rooks := p[KindRook].Or(p[KindPromotedRook]); !rooks.IsZero() {
+		attackers = attackers.Or(rookAttacks(sq, occupied).And(rooks))
+	}
+	if bishops := p[KindBishop].Or(p[KindPromotedBishop]); !bishops.IsZero() {
+		attackers = attackers.Or(bishopAttacks(sq, occupied).And(bishops))
+	}
+	return attackers
+}
+
+// IsAttacked reports if any piece of the color attacks the square
+func (b *BitboardBoard) IsAttacked(sq Square, by Color) bool {
+	return !b.AttackersTo(sq, by).IsZero()
+}
+
+// InCheck reports if the king of the side to move is checked
+func (b *BitboardBoard) InCheck() bool {
+	return b.isKingAttacked(b.turn)
+}
+
+func (b *BitboardBoard) isKingAttacked(c Color) bool {
+	kingSq := b.KingSquare(c)
+	return kingSq != NoSquare && b.IsAttacked(kingSq, c.Opponent())
+}
+
+// DoMove makes the move for the side to move. The move must be at least pseudo legal.
+func (b *BitboardBoard) DoMove(m Move) {
+	us := b.turn
+	if m.Drop {
+		b.addHand(us, m.Piece, -1)
+		b.put(m.To, m.Piece, us)
+	} else {
+		b.remove(m.From)
+		if m.Captured != KindNone {
+			b.remove(m.To)
+			b.addHand(us, m.Captured.Demote(), 1)
+		}
+		kind := m.Piece
+		if m.Promote {
+			kind = kind.Promote()
+		}
+		b.put(m.To, kind, us)
+	}
+	b.setTurn(us.Opponent())
+}
+
+// UndoMove takes back the move which was made last by DoMove
+func (b *BitboardBoard) UndoMove(m Move) {
+	us := b.turn.Opponent()
+	b.setTurn(us)
+	b.remove(m.To)
+	if m.Drop {
+		b.addHand(us, m.Piece, 1)
+		return
+	}
+	if m.Captured != KindNone {
+		b.addHand(us, m.Captured.Demote(), -1)
+		b.put(m.To, m.Captured, us.Opponent())
+	}
+	b.put(m.From, m.Piece, us)
+}
+
+// PseudoLegalMoves appends the moves obeying piece movement, promotion and drop rules to moves,
+// without checking if they leave the king in check.
+func (b *BitboardBoard) PseudoLegalMoves(moves []Move) []Move {
+	us := b.turn
+	own := b.occupied[us]
+	occupied := b.Occupied()
+	for kind := KindPawn; kind <= KindPromotedRook; kind++ {
+		for from := b.pieces[us][kind]; !from.IsZero(); {
+			fromSq := from.PopFirst()
+			canPromote := kind.IsPromotable()
+			fromZone := promotionZoneTable[us].Has(fromSq)
+			for targets := PieceAttacks(kind, us, fromSq, occupied).AndNot(own); !targets.IsZero(); {
+				toSq := targets.PopFirst()
+				m := Move{From: fromSq, To: toSq, Piece: kind, Captured: b.kinds[toSq]}
+				if canPromote && (fromZone || promotionZoneTable[us].Has(toSq)) {
+					promoted := m
+					promoted.Promote = true
+					moves = append(moves, promoted)
+				}
+				if !deadEndTable[us][kind].Has(toSq) {
+					moves = append(moves, m)
+				}
+			}
+		}
+	}
+
+	empty := occupied.Not()
+	for kind := KindPawn; kind <= KindRook; kind++ {
+		if b.hands[us][kind] == 0 {
+			continue
+		}
+		targets := empty.AndNot(deadEndTable[us][kind])
+		if kind == KindPawn {
+			for pawns := b.pieces[us][KindPawn]; !pawns.IsZero(); {
+				targets = targets.AndNot(fileTable[pawns.PopFirst().X()])
+			}
+		}
+		for !targets.IsZero() {
+			moves = append(moves, Move{From: NoSquare, To: targets.PopFirst(), Piece: kind, Drop: true})
+		}
+	}
+	return moves
+}
+
+// LegalMoves returns every legal move for the side to move
+func (b *BitboardBoard) LegalMoves() []Move {
+	pseudoLegalMoves := b.PseudoLegalMoves(make([]Move, 0, 128))
+	moves := pseudoLegalMoves[:0]
+	for _, m := range pseudoLegalMoves {
+		if b.IsLegal(m) {
+			moves = append(moves, m)
+		}
+	}
+	return moves
+}
+
+// IsLegal reports if the pseudo legal move doesn't leave the king in check and isn't a pawn drop checkmate.
+func (b *BitboardBoard) IsLegal(m Move) bool {
+	us := b.turn
+	b.DoMove(m)
+	legal := !b.isKingAttacked(us)
+	if legal && m.Drop && m.Piece == KindPawn && b.isPawnDropCheck(m.To) {
+		legal = b.hasLegalMove()
+	}
+	b.UndoMove(m)
+	return legal
+}
+
+// isPawnDropCheck reports if the pawn just dropped at the square gives check to the side to move
+func (b *BitboardBoard) isPawnDropCheck(sq Square) bool {
+	return stepAttackTable[b.turn.Opponent()][KindPawn][sq].Has(b.KingSquare(b.turn))
+}
+
+func (b *BitboardBoard) hasLegalMove() bool {
+	for _, m := range b.PseudoLegalMoves(make([]Move, 0, 128)) {
+		if b.IsLegal(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// PieceMovablePosition returns the positions where the side to move's piece at the position can legally move to.
+// It returns nil if there is no piece of the side to move at the position.
+func (b *BitboardBoard) PieceMovablePosition(piecePos *Position) PositionList {
+	if !isOnBoard(piecePos) {
+		return nil
+	}
+	from := SquareOf(piecePos)
+	var destinations Bitboard
+	for _, m := range b.LegalMoves() {
+		if !m.Drop && m.From == from {
+			destinations.Set(m.To)
+		}
+	}
+	return destinations.PositionList()
+}
+
+// IsCheckmated reports if the side to move is checked and has no legal move
+func (b *BitboardBoard) IsCheckmated() bool {
+	return b.InCheck() && !b.hasLegalMove()
+}
+
+// NewMove builds a move from the square to the square for the side to move, filling in the moving and captured pieces.
+// It doesn't validate the move, see ValidateMove.
+func (b *BitboardBoard) NewMove(from, to Square, promote bool) Move {
+	return Move{From: from, To: to, Piece: b.kinds[from], Captured: b.kinds[to], Promote: promote}
+}
+
+// NewDrop builds a drop of the kind to the square for the side to move
+func (b *BitboardBoard) NewDrop(kind PieceKind, to Square) Move {
+	return Move{From: NoSquare, To: to, Piece: kind, Drop: true}
+}
+
+// ValidateMove checks if the move is legal for the side to move, and describes the reason if it's not.
+func (b *BitboardBoard) ValidateMove(m Move) error {
+	us := b.turn
+	if !m.To.IsValid() {
+		return errors.Errorf("%v is out of the board", m.To)
+	}
+	if m.Drop {
+		if b.hands[us][m.Piece] == 0 {
+			return errors.Errorf("%s is not in hand", m.Piece.Name())
+		}
+		if b.kinds[m.To] != KindNone {
+			return errors.Errorf("there is a piece at %v", m.To.Position())
+		}
+		if deadEndTable[us][m.Piece].Has(m.To) {
+			return errors.Errorf("%s can't be dropped at %v", m.Piece.Name(), m.To.Position())
+		}
+		if m.Piece == KindPawn && !b.pieces[us][KindPawn].And(fileTable[m.To.X()]).IsZero() {
+			return errors.Errorf("there is a pawn on the same column: %v", m.To.X())
+		}
+	} else {
+		if !m.From.IsValid() || b.kinds[m.From] == KindNone || b.owners[m.From] != us || b.kinds[m.From] != m.Piece {
+			return errors.Errorf("current player's piece doesn't exist at %v", m.From.Position())
+		}
+		if b.kinds[m.To] != KindNone && b.owners[m.To] == us {
+			return errors.Errorf("there is current user's piece at %v", m.To.Position())
+		}
+		if !PieceAttacks(m.Piece, us, m.From, b.Occupied()).Has(m.To) {
+			return errors.Errorf("the piece can't be moved to %v", m.To.Position())
+		}
+		if b.kinds[m.To] != m.Captured {
+			return errors.Errorf("captured piece doesn't match the piece at %v", m.To.Position())
+		}
+		if m.Promote {
+			if !m.Piece.IsPromotable() || !(promotionZoneTable[us].Has(m.From) || promotionZoneTable[us].Has(m.To)) {
+				return errors.Errorf("the piece can't be promoted by moving to %v", m.To.Position())
+			}
+		} else if deadEndTable[us][m.Piece].Has(m.To) {
+			return errors.Errorf("the piece must be promoted at %v", m.To.Position())
+		}
+	}
+	b.DoMove(m)
+	kingAttacked := b.isKingAttacked(us)
+	pawnDropMate := !kingAttacked && m.Drop && m.Piece == KindPawn && b.isPawnDropCheck(m.To) && !b.hasLegalMove()
+	b.UndoMove(m)
+	if kingAttacked {
+		return errors.New("king is checked, so you must avoid it")
+	}
+	if pawnDropMate {
+		return errors.New("checkmate by dropping a pawn is not allowed")
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package shogi
+
+import "testing"
+
+func BenchmarkBitboardPseudoLegalMoves(b *testing.B) {
+	board := NewBitboardBoard()
+	sfen := board.SFEN(1)
+	moves := make([]Move, 0, 128)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		// generate for both sides to match BenchmarkBoardMovablePositions
+		moves = board.PseudoLegalMoves(moves[:0])
+		m := moves[0]
+		board.DoMove(m)
+		moves = board.PseudoLegalMoves(moves[:0])
+		board.UndoMove(m)
+	}
+	b.StopTimer()
+	if got := board.SFEN(1); got != sfen {
+		b.Fatalf("the board changed to %s", got)
+	}
+}
+
+func BenchmarkBitboardLegalMoves(b *testing.B) {
+	board := NewBitboardBoard()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		board.LegalMoves()
+	}
+}
+
+func BenchmarkBitboardAttackersTo(b *testing.B) {
+	board := NewBitboardBoard()
+	kingSq := NewSquare(5, 9)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		board.AttackersTo(kingSq, Gote)
+	}
+}
@@ -47,7 +47,7 @@ func initializeMiddleZone() [][]Piece {
 
 func (b Board) opponentArea(player Player) [][]Piece {
 	if player.IsFirstPlayer() {
-		return b[0:4]
+		return b[0:3]
 	} else {
 		return b[6:9]
 	}
@@ -127,6 +127,59 @@ func (b Board) IsPieceMovableTo(curPos, distPos *Position) bool {
 	return true
 }
 
+// AttackersOf returns the positions of the player's pieces attacking the given position.
+// A piece attacks a position if it could move there, regardless of the piece at the position.
+func (b Board) AttackersOf(pos *Position, player Player) PositionList {
+	var attackers PositionList
+	b.iterateThrough(func(piecePos *Position, piece Piece, exist bool) (finished bool) {
+		if exist && IsSamePlayer(player, piece.Owner()) && b.IsPieceMovableTo(piecePos, pos) {
+			attackers = append(attackers, piecePos)
+		}
+		return false
+	})
+	return attackers
+}
+
+// IsAttacked checks if any of the player's pieces attacks the given position.
+func (b Board) IsAttacked(pos *Position, player Player) bool {
+	attacked := false
+	b.iterateThrough(func(piecePos *Position, piece Piece, exist bool) (finished bool) {
+		if exist && IsSamePlayer(player, piece.Owner()) && b.IsPieceMovableTo(piecePos, pos) {
+			attacked = true
+			return true
+		}
+		return false
+	})
+	return attacked
+}
+
+// AttackMap holds the number of pieces attacking each square, indexed in the same way as Board.
+type AttackMap [][]int
+
+func (m AttackMap) Count(pos *Position) int {
+	return m[pos.Y.Idx()][pos.X.Idx()]
+}
+
+// AttackMap counts the player's pieces attacking each square.
+func (b Board) AttackMap(player Player) AttackMap {
+	attackMap := make(AttackMap, len(rows))
+	for i := range attackMap {
+		attackMap[i] = make([]int, len(columns))
+	}
+	b.iterateThrough(func(piecePos *Position, piece Piece, exist bool) (finished bool) {
+		if !exist || !IsSamePlayer(player, piece.Owner()) {
+			return false
+		}
+		for _, pos := range SelectValidPositions(piece.MovablePositions(piecePos)) {
+			if b.IsPieceMovableTo(piecePos, pos) {
+				attackMap[pos.Y.Idx()][pos.X.Idx()]++
+			}
+		}
+		return false
+	})
+	return attackMap
+}
+
 func (b Board) PieceMovablePosition(piecePos *Position) PositionList {
 	piece, _ := b.FindPiece(piecePos)
 	movablePositions := SelectValidPositions(piece.MovablePositions(piecePos))
@@ -0,0 +1,198 @@
+package shogi
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// legacyBoard returns the Board of the position in SFEN with the players owning the pieces
+func legacyBoard(t *testing.T, sfen string) (board Board, first, second Player) {
+	t.Helper()
+	b, _, err := ParseSFEN(sfen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second = NewPlayer(true), NewPlayer(false)
+	return b.Board(first, second), first, second
+}
+
+// attackPosition has sente's bishop, gold, pawn and knight attacking 5e and the rook behind the pawn,
+// and gote's silver attacking 5e
+const attackPosition = "k7B/9/9/4s4/9/4PG3/3N5/4R4/K8 b - 1"
+
+func TestBoard_AttackersOf(t *testing.T) {
+	board, first, second := legacyBoard(t, attackPosition)
+	tests := []struct {
+		name   string
+		pos    *Position
+		player Player
+		want   PositionList
+	}{
+		{name: "sente's attackers of 5e", pos: &Position{X: 5, Y: 5}, player: first, want: PositionList{{X: 1, Y: 1}, {X: 4, Y: 6}, {X: 5, Y: 6}, {X: 6, Y: 7}}},
+		{name: "gote's attackers of 5e", pos: &Position{X: 5, Y: 5}, player: second, want: PositionList{{X: 5, Y: 4}}},
+		{name: "rook attacking its own pawn", pos: &Position{X: 5, Y: 6}, player: first, want: PositionList{{X: 4, Y: 6}, {X: 5, Y: 8}}},
+		{name: "rook along the rank", pos: &Position{X: 1, Y: 8}, player: first, want: PositionList{{X: 5, Y: 8}}},
+		{name: "bishop across the board", pos: &Position{X: 7, Y: 7}, player: first, want: PositionList{{X: 1, Y: 1}}},
+		{name: "no attacker", pos: &Position{X: 9, Y: 5}, player: first},
+	}
+	for _, tt := range tests {
+		got := board.AttackersOf(tt.pos, tt.player)
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: AttackersOf() = %v, want %v", tt.name, got, tt.want)
+		}
+		if attacked := board.IsAttacked(tt.pos, tt.player); attacked != (len(tt.want) > 0) {
+			t.Errorf("%s: IsAttacked() = %v", tt.name, attacked)
+		}
+	}
+}
+
+func TestBoard_AttackMap(t *testing.T) {
+	board, first, second := legacyBoard(t, attackPosition)
+	senteMap, goteMap := board.AttackMap(first), board.AttackMap(second)
+	tests := []struct {
+		pos   *Position
+		sente int
+		gote  int
+	}{
+		{pos: &Position{X: 5, Y: 5}, sente: 4, gote: 1},
+		{pos: &Position{X: 5, Y: 6}, sente: 2, gote: 0},
+		{pos: &Position{X: 5, Y: 7}, sente: 1, gote: 0},
+		// gote's silver moves diagonally backward
+		{pos: &Position{X: 6, Y: 3}, sente: 0, gote: 1},
+		// the pawn blocks the rook from the silver
+		{pos: &Position{X: 5, Y: 4}, sente: 0, gote: 0},
+		{pos: &Position{X: 9, Y: 5}, sente: 0, gote: 0},
+	}
+	for _, tt := range tests {
+		if got := senteMap.Count(tt.pos); got != tt.sente {
+			t.Errorf("sente's attacks of %v = %d, want %d", tt.pos, got, tt.sente)
+		}
+		if got := goteMap.Count(tt.pos); got != tt.gote {
+			t.Errorf("gote's attacks of %v = %d, want %d", tt.pos, got, tt.gote)
+		}
+	}
+}
+
+// TestBoard_AttackersOf_Bitboard checks the attackers agree with BitboardBoard.AttackersTo in random positions
+func TestBoard_AttackersOf_Bitboard(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	first, second := NewPlayer(true), NewPlayer(false)
+	b := NewBitboardBoard()
+	for ply := 0; ply < 80; ply++ {
+		moves := b.LegalMoves()
+		if len(moves) == 0 {
+			break
+		}
+		b.DoMove(moves[r.Intn(len(moves))])
+		board := b.Board(first, second)
+		for sq := Square(0); sq < NumSquares; sq++ {
+			for c, player := range []Player{first, second} {
+				want := b.AttackersTo(sq, Color(c)).PositionList()
+				if got := board.AttackersOf(sq.Position(), player); fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Fatalf("%s: attackers of %v by %s = %v, want %v", b.SFEN(ply+2), sq.Position(), Color(c), got, want)
+				}
+				if got := board.AttackMap(player).Count(sq.Position()); got != len(want) {
+					t.Fatalf("%s: attacks of %v by %s = %d, want %d", b.SFEN(ply+2), sq.Position(), Color(c), got, len(want))
+				}
+			}
+		}
+	}
+}
+
+func TestRook_PositionsOnTheWayTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		promoted bool
+		cur      *Position
+		dist     *Position
+		want     PositionList
+		movable  bool
+	}{
+		{name: "to the left", cur: &Position{X: 2, Y: 5}, dist: &Position{X: 6, Y: 5}, want: PositionList{{X: 3, Y: 5}, {X: 4, Y: 5}, {X: 5, Y: 5}}, movable: true},
+		{name: "to the right", cur: &Position{X: 8, Y: 5}, dist: &Position{X: 4, Y: 5}, want: PositionList{{X: 7, Y: 5}, {X: 6, Y: 5}, {X: 5, Y: 5}}, movable: true},
+		{name: "up", cur: &Position{X: 3, Y: 9}, dist: &Position{X: 3, Y: 6}, want: PositionList{{X: 3, Y: 8}, {X: 3, Y: 7}}, movable: true},
+		{name: "adjacent", cur: &Position{X: 3, Y: 9}, dist: &Position{X: 4, Y: 9}, movable: true},
+		{name: "diagonal", cur: &Position{X: 3, Y: 9}, dist: &Position{X: 4, Y: 8}},
+		{name: "diagonal of the dragon", promoted: true, cur: &Position{X: 3, Y: 9}, dist: &Position{X: 4, Y: 8}, movable: true},
+	}
+	for _, tt := range tests {
+		rook := NewPiece(KindRook, NewPlayer(true))
+		if tt.promoted {
+			rook = NewPiece(KindPromotedRook, NewPlayer(true))
+		}
+		got, movable := rook.PositionsOnTheWayTo(tt.cur, tt.dist)
+		if movable != tt.movable || fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: PositionsOnTheWayTo() = %v, %v, want %v, %v", tt.name, got, movable, tt.want, tt.movable)
+		}
+	}
+}
+
+func TestPromotedBishopMovableRelativePositions(t *testing.T) {
+	horse := NewPiece(KindPromotedBishop, NewPlayer(true))
+	cur := &Position{X: 5, Y: 5}
+	for _, pos := range (PositionList{{X: 5, Y: 4}, {X: 4, Y: 5}, {X: 6, Y: 5}, {X: 5, Y: 6}, {X: 1, Y: 1}, {X: 9, Y: 9}}) {
+		if !horse.IsMovableTo(cur, pos) {
+			t.Errorf("horse at %v can't move to %v", cur, pos)
+		}
+	}
+	for _, pos := range (PositionList{{X: 5, Y: 3}, {X: 3, Y: 5}, {X: 4, Y: 3}}) {
+		if horse.IsMovableTo(cur, pos) {
+			t.Errorf("horse at %v can move to %v", cur, pos)
+		}
+	}
+	if n := len(PromotedBishopMovableRelativePositions()); n != len(BishopMovableRelativePositions())+4 {
+		t.Errorf("horse has %d relative positions, want the bishop's and 4", n)
+	}
+}
+
+// TestBoard_opponentArea checks the areas are the three ranks of the promotion zone, the same as the bitboard's
+func TestBoard_opponentArea(t *testing.T) {
+	first, second := NewPlayer(true), NewPlayer(false)
+	board := NewBoard(first, second)
+	for _, tt := range []struct {
+		player   Player
+		firstRow int
+	}{{first, 0}, {second, 6}} {
+		area := board.opponentArea(tt.player)
+		if len(area) != 3 {
+			t.Errorf("opponentArea(%v) has %d ranks, want 3", ColorOf(tt.player), len(area))
+			continue
+		}
+		for i, row := range area {
+			if &row[0] != &board[tt.firstRow+i][0] {
+				t.Errorf("opponentArea(%v) rank %d isn't the rank %d of the board", ColorOf(tt.player), i+1, tt.firstRow+i+1)
+			}
+		}
+	}
+}
+
+// BenchmarkBoardMovablePositions lists movable positions of every piece in the initial position
+func BenchmarkBoardMovablePositions(b *testing.B) {
+	board := NewBoard(NewPlayer(true), NewPlayer(false))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for y := Axis(1); y <= 9; y++ {
+			for x := Axis(1); x <= 9; x++ {
+				pos := &Position{X: x, Y: y}
+				if _, exist := board.FindPiece(pos); exist {
+					board.PieceMovablePosition(pos)
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkBoardIsPieceMovableTo checks if any piece attacks the king's square
+func BenchmarkBoardIsPieceMovableTo(b *testing.B) {
+	board := NewBoard(NewPlayer(true), NewPlayer(false))
+	kingPos := &Position{X: 5, Y: 9}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for y := Axis(1); y <= 9; y++ {
+			for x := Axis(1); x <= 9; x++ {
+				board.IsPieceMovableTo(&Position{X: x, Y: y}, kingPos)
+			}
+		}
+	}
+}
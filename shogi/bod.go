@@ -0,0 +1,216 @@
+package shogi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	bodFileLabels = "  ９ ８ ７ ６ ５ ４ ３ ２ １"
+	bodBorder     = "+---------------------------+"
+	bodEmpty      = "・"
+	bodGoteMarker = "v"
+)
+
+// bodPieceNames are single-character piece names used in board diagrams
+var bodPieceNames = map[string]PieceKind{
+	"歩": KindPawn, "香": KindLance, "桂": KindKnight, "銀": KindSilver, "金": KindGold, "角": KindBishop, "飛": KindRook,
+	"玉": KindKing, "王": KindKing, "と": KindPromotedPawn, "杏": KindPromotedLance, "圭": KindPromotedKnight,
+	"全": KindPromotedSilver, "馬": KindPromotedBishop, "龍": KindPromotedRook, "竜": KindPromotedRook,
+}
+
+// BOD returns the current position as a BOD diagram, the board format used in KIF files, e.g.
+//
+//	後手の持駒：なし
+//	  ９ ８ ７ ６ ５ ４ ３ ２ １
+//	+---------------------------+
+//	|v香v桂v銀v金v玉v金v銀v桂v香|一
+//	...
+//	+---------------------------+
+//	先手の持駒：なし
+//	手数＝1  ▲７六歩  まで
+//	後手番
+func (g *Game) BOD() string {
+	var lastMove string
+	if notations := g.JapaneseMoves(); len(notations) > 0 {
+		lastMove = g.board.SideToMove().Opponent().Marker() + notations[len(notations)-1]
+	}
+	return g.board.BOD(g.MoveNumber()-1, lastMove)
+}
+
+// BOD returns the position as a BOD diagram. moves is the number of moves played so far,
+// and lastMove is the last move in Japanese notation with the side marker, which is omitted if it's empty.
+func (b *BitboardBoard) BOD(moves int, lastMove string) string {
+	var sb strings.Builder
+	sb.WriteString("後手の持駒：" + b.formatBODHand(Gote) + "\n")
+	sb.WriteString(bodFileLabels + "\n")
+	sb.WriteString(bodBorder + "\n")
+	for y := Axis(1); y <= 9; y++ {
+		sb.WriteString("|")
+		for x := Axis(9); x >= 1; x-- {
+			kind, color := b.PieceAt(NewSquare(x, y))
+			switch {
+			case kind == KindNone:
+				sb.WriteString(" " + bodEmpty)
+			case color == Gote:
+				sb.WriteString(bodGoteMarker + kind.ShortName())
+			default:
+				sb.WriteString(" " + kind.ShortName())
+			}
+		}
+		sb.WriteString("|" + kanjiNumerals[y] + "\n")
+	}
+	sb.WriteString(bodBorder + "\n")
+	sb.WriteString("先手の持駒：" + b.formatBODHand(Sente) + "\n")
+	if lastMove != "" {
+		sb.WriteString(fmt.Sprintf("手数＝%d  %s  まで\n", moves, lastMove))
+	} else {
+		sb.WriteString(fmt.Sprintf("手数＝%d\n", moves))
+	}
+	sb.WriteString(b.turn.String() + "番\n")
+	return sb.String()
+}
+
+func (b *BitboardBoard) formatBODHand(c Color) string {
+	var pieces []string
+	for _, kind := range HandKinds {
+		if n := b.hands[c][kind]; n > 0 {
+			pieces = append(pieces, kind.ShortName()+kanjiCount(n))
+		}
+	}
+	if len(pieces) == 0 {
+		return "なし"
+	}
+	return strings.Join(pieces, "　")
+}
+
+// ParseBOD parses the BOD diagram and returns the board and the number of moves played so far.
+// Lines other than the diagram are ignored, so it accepts a whole KIF header.
+func ParseBOD(bod string) (*BitboardBoard, int, error) {
+	b := &BitboardBoard{}
+	moves := 0
+	y := Axis(0)
+	for _, line := range strings.Split(bod, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case strings.HasPrefix(line, "|"):
+			y++
+			if y > 9 {
+				return nil, 0, errors.New("invalid BOD, more than 9 ranks")
+			}
+			if err := b.parseBODRank(line, y); err != nil {
+				return nil, 0, err
+			}
+		case strings.HasPrefix(line, "後手の持駒") || strings.HasPrefix(line, "上手の持駒"):
+			if err := b.parseBODHand(line, Gote); err != nil {
+				return nil, 0, err
+			}
+		case strings.HasPrefix(line, "先手の持駒") || strings.HasPrefix(line, "下手の持駒"):
+			if err := b.parseBODHand(line, Sente); err != nil {
+				return nil, 0, err
+			}
+		case strings.HasPrefix(line, "手数＝"):
+			fields := strings.Fields(strings.TrimPrefix(line, "手数＝"))
+			if len(fields) == 0 {
+				return nil, 0, errors.Errorf("invalid BOD move count: %q", line)
+			}
+			n, err := strconv.Atoi(fields[0])
+			if err != nil {
+				return nil, 0, errors.Wrapf(err, "invalid BOD move count: %q", line)
+			}
+			moves = n
+		case strings.HasPrefix(line, "後手番") || strings.HasPrefix(line, "上手番"):
+			b.setTurn(Gote)
+		case strings.HasPrefix(line, "先手番") || strings.HasPrefix(line, "下手番"):
+			b.setTurn(Sente)
+		}
+	}
+	if y != 9 {
+		return nil, 0, errors.Errorf("invalid BOD, 9 ranks are expected but got %d", y)
+	}
+	return b, moves, nil
+}
+
+func (b *BitboardBoard) parseBODRank(line string, y Axis) error {
+	cells := []rune(strings.TrimPrefix(line, "|"))
+	if len(cells) < 18 {
+		return errors.Errorf("invalid BOD rank: %q", line)
+	}
+	for i := 0; i < 9; i++ {
+		marker, name := string(cells[i*2]), string(cells[i*2+1])
+		if name == bodEmpty {
+			continue
+		}
+		kind, ok := bodPieceNames[name]
+		if !ok {
+			return errors.Errorf("invalid BOD piece %q: %q", name, line)
+		}
+		color := Sente
+		if marker == bodGoteMarker {
+			color = Gote
+		}
+		b.put(NewSquare(Axis(9-i), y), kind, color)
+	}
+	return nil
+}
+
+func (b *BitboardBoard) parseBODHand(line string, c Color) error {
+	i := strings.Index(line, "：")
+	if i < 0 {
+		return errors.Errorf("invalid BOD hand: %q", line)
+	}
+	hand := strings.TrimSpace(line[i+len("："):])
+	if hand == "なし" || hand == "" {
+		return nil
+	}
+	for _, piece := range strings.FieldsFunc(hand, func(r rune) bool { return r == '　' || r == ' ' }) {
+		runes := []rune(piece)
+		kind, ok := bodPieceNames[string(runes[0])]
+		if !ok || kind < KindPawn || kind > KindRook {
+			return errors.Errorf("invalid BOD hand piece %q: %q", piece, line)
+		}
+		n, ok := parseKanjiCount(string(runes[1:]))
+		if !ok {
+			return errors.Errorf("invalid BOD hand count %q: %q", piece, line)
+		}
+		b.addHand(c, kind, n)
+	}
+	return nil
+}
+
+// kanjiCount returns the number of pieces in kanji, omitting 一
+func kanjiCount(n int) string {
+	switch {
+	case n <= 1:
+		return ""
+	case n < 10:
+		return kanjiNumerals[n]
+	case n == 10:
+		return "十"
+	default:
+		return "十" + kanjiNumerals[n-10]
+	}
+}
+
+func parseKanjiCount(s string) (int, bool) {
+	if s == "" {
+		return 1, true
+	}
+	n := 0
+	if strings.HasPrefix(s, "十") {
+		n = 10
+		s = strings.TrimPrefix(s, "十")
+		if s == "" {
+			return n, true
+		}
+	}
+	for i := 1; i <= 9; i++ {
+		if s == kanjiNumerals[i] {
+			return n + i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,107 @@
+package shogi
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// checkGolden compares the output with the golden file in testdata, which is rewritten with -update
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	golden := filepath.Join("testdata", name)
+	if *update {
+		if err := ioutil.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %s, run go test -update to see the change:\n%s", golden, got)
+	}
+}
+
+func TestGame_BOD(t *testing.T) {
+	tests := []struct {
+		name  string
+		sfen  string
+		moves []string
+	}{
+		{name: "initial", sfen: InitialSFEN},
+		// both players have a bishop in hand after the exchange, and the last move is a recapture
+		{name: "bishop_exchange", sfen: InitialSFEN, moves: []string{"7g7f", "3c3d", "8h2b+", "3a2b"}},
+		{name: "hands", sfen: "4k4/9/9/9/9/9/9/9/4K4 w RB2G2S2N2L9Prb2g2s2n2l9p 1", moves: []string{"P*5e"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGameFromSFEN(tt.sfen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			playUSI(t, g, tt.moves...)
+			bod := g.BOD()
+			checkGolden(t, tt.name+".bod", bod)
+
+			b, moves, err := ParseBOD(bod)
+			if err != nil {
+				t.Fatalf("ParseBOD() error = %v", err)
+			}
+			if got := b.SFEN(moves + 1); got != g.SFEN() {
+				t.Errorf("ParseBOD(BOD()) = %s, want %s", got, g.SFEN())
+			}
+		})
+	}
+}
+
+func TestParseBOD(t *testing.T) {
+	// a handicap diagram written with 上手 and 下手, the count of pieces in kanji and the lines around it
+	bod := strings.Join([]string{
+		"# comment",
+		"上手の持駒：歩十二　香",
+		"  ９ ８ ７ ６ ５ ４ ３ ２ １",
+		"+---------------------------+",
+		"|v香v桂v銀v金v玉v金v銀v桂 ・|一",
+		"| ・v飛 ・ ・ ・ ・ ・v角 ・|二",
+		"| ・ ・ ・ ・ ・ ・ ・ ・ ・|三",
+		"| ・ ・ ・ ・ ・ ・ ・ ・ ・|四",
+		"| ・ ・ ・ ・ ・ ・ ・ ・ ・|五",
+		"| ・ ・ 歩 ・ ・ ・ ・ ・ ・|六",
+		"| 歩 歩 ・ 歩 歩 歩 歩 歩 歩|七",
+		"| ・ 馬 ・ ・ ・ ・ ・ 龍 ・|八",
+		"| 香 桂 銀 金 玉 金 銀 桂 香|九",
+		"+---------------------------+",
+		"下手の持駒：なし",
+		"手数＝30  ▲７六歩  まで",
+		"上手番",
+		"先手：someone",
+	}, "\r\n")
+	b, moves, err := ParseBOD(bod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moves != 30 {
+		t.Errorf("moves = %d, want 30", moves)
+	}
+	want := "lnsgkgsn1/1r5b1/9/9/9/2P6/PP1PPPPPP/1+B5+R1/LNSGKGSNL w l12p 31"
+	if got := b.SFEN(moves + 1); got != want {
+		t.Errorf("ParseBOD() = %s, want %s", got, want)
+	}
+
+	for name, invalid := range map[string]string{
+		"8 ranks":       strings.Join(strings.Split(bod, "\r\n")[:12], "\n"),
+		"unknown piece": strings.Replace(bod, "v飛", "v象", 1),
+		"king in hand":  strings.Replace(bod, "なし", "玉", 1),
+		"move count":    strings.Replace(bod, "手数＝30", "手数＝x", 1),
+	} {
+		if _, _, err := ParseBOD(invalid); err == nil {
+			t.Errorf("%s: ParseBOD() error = nil", name)
+		}
+	}
+}
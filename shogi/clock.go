@@ -0,0 +1,168 @@
+package shogi
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrTimeUp is returned when the player to move has run out of time
+var ErrTimeUp = errors.New("time is up")
+
+// TimeControl is the time settings of a game, the zero value means no time limit.
+// MainTime alone is sudden death (切れ負け), Byoyomi (秒読み) is the time for each move after the main time runs out,
+// and Increment is added after each move (Fischer). They can be combined.
+type TimeControl struct {
+	MainTime time.Duration
+	Byoyomi  time.Duration
+	// ByoyomiPeriods is the number of byoyomi periods, 1 if it's zero.
+	// A period is lost for each whole Byoyomi a move takes, and the time is up when a move takes longer than the last period.
+	ByoyomiPeriods int
+	Increment      time.Duration
+}
+
+// IsUnlimited reports if the time control has no time limit
+func (tc TimeControl) IsUnlimited() bool {
+	return tc == TimeControl{}
+}
+
+// Clock is the game clock of both players. The time runs for the side to move only while the clock is running.
+type Clock struct {
+	control TimeControl
+	now     func() time.Time
+	// remaining is the main time left and periods is the byoyomi periods left at the start of the turn
+	remaining [2]time.Duration
+	periods   [2]int
+	turn      Color
+	running   bool
+	// turnStarted is when the clock started or resumed running in the current turn,
+	// and elapsed is the time spent in the turn before that
+	turnStarted time.Time
+	elapsed     time.Duration
+}
+
+// NewClock returns a stopped clock. now is the time source, time.Now if it's nil.
+func NewClock(control TimeControl, now func() time.Time) *Clock {
+	if now == nil {
+		now = time.Now
+	}
+	periods := control.ByoyomiPeriods
+	if periods == 0 && control.Byoyomi > 0 {
+		periods = 1
+	}
+	return &Clock{
+		control:   control,
+		now:       now,
+		remaining: [2]time.Duration{control.MainTime, control.MainTime},
+		periods:   [2]int{periods, periods},
+	}
+}
+
+func (c *Clock) TimeControl() TimeControl {
+	return c.control
+}
+
+// Start starts the turn of the color and runs the clock
+func (c *Clock) Start(turn Color) {
+	c.turn = turn
+	c.elapsed = 0
+	c.running = true
+	c.turnStarted = c.now()
+}
+
+// Pause stops the clock keeping the time spent in the current turn
+func (c *Clock) Pause() {
+	if !c.running {
+		return
+	}
+	c.elapsed = c.Elapsed()
+	c.running = false
+}
+
+// Resume runs the paused clock again
+func (c *Clock) Resume() {
+	if c.running {
+		return
+	}
+	c.running = true
+	c.turnStarted = c.now()
+}
+
+func (c *Clock) Running() bool {
+	return c.running
+}
+
+// Turn returns the color whose time runs
+func (c *Clock) Turn() Color {
+	return c.turn
+}
+
+// Elapsed returns the time spent in the current turn
+func (c *Clock) Elapsed() time.Duration {
+	if !c.running {
+		return c.elapsed
+	}
+	return c.elapsed + c.now().Sub(c.turnStarted)
+}
+
+// Remaining returns the main time left for the color, counting the time spent in the current turn.
+// It's always zero without time limit.
+func (c *Clock) Remaining(color Color) time.Duration {
+	if color != c.turn {
+		return c.remaining[color]
+	}
+	remaining, _, _ := c.charge(color, c.Elapsed())
+	return remaining
+}
+
+// Periods returns the byoyomi periods left for the color, counting the time spent in the current turn
+func (c *Clock) Periods(color Color) int {
+	if color != c.turn {
+		return c.periods[color]
+	}
+	_, periods, _ := c.charge(color, c.Elapsed())
+	return periods
+}
+
+// TimeUp reports if the color to move has run out of time
+func (c *Clock) TimeUp() bool {
+	_, _, timeUp := c.charge(c.turn, c.Elapsed())
+	return timeUp
+}
+
+// Press ends the turn after a move, and starts the opponent's turn.
+// It returns the time spent on the move, and ErrTimeUp without ending the turn if the move was too late, which stops the clock.
+func (c *Clock) Press() (time.Duration, error) {
+	elapsed := c.Elapsed()
+	remaining, periods, timeUp := c.charge(c.turn, elapsed)
+	if timeUp {
+		c.Pause()
+		return elapsed, ErrTimeUp
+	}
+	c.remaining[c.turn] = remaining + c.control.Increment
+	c.periods[c.turn] = periods
+	c.turn = c.turn.Opponent()
+	c.elapsed = 0
+	c.turnStarted = c.now()
+	return elapsed, nil
+}
+
+// charge returns the main time and byoyomi periods left after the color spends elapsed on a move, and if the time is up
+func (c *Clock) charge(color Color, elapsed time.Duration) (remaining time.Duration, periods int, timeUp bool) {
+	remaining, periods = c.remaining[color], c.periods[color]
+	if c.control.IsUnlimited() {
+		return remaining, periods, false
+	}
+	if elapsed <= remaining {
+		return remaining - elapsed, periods, false
+	}
+	if c.control.Byoyomi <= 0 {
+		return 0, periods, true
+	}
+	over := elapsed - remaining
+	lost := int((over - 1) / c.control.Byoyomi)
+	if lost >= periods {
+		return 0, 0, true
+	}
+	return 0, periods - lost, false
+}
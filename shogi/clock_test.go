@@ -0,0 +1,184 @@
+package shogi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// fakeNow is the time source of the clocks advanced by the tests
+type fakeNow struct {
+	now time.Time
+}
+
+func newFakeNow() *fakeNow {
+	return &fakeNow{now: time.Date(2020, 8, 7, 12, 0, 0, 0, time.UTC)}
+}
+
+func (f *fakeNow) Now() time.Time {
+	return f.now
+}
+
+func (f *fakeNow) Advance(d time.Duration) {
+	f.now = f.now.Add(d)
+}
+
+// press advances the time by d and presses the clock, failing the test on an error
+func press(t *testing.T, c *Clock, now *fakeNow, d time.Duration) time.Duration {
+	t.Helper()
+	now.Advance(d)
+	consumed, err := c.Press()
+	if err != nil {
+		t.Fatalf("Press() after %s error = %v", d, err)
+	}
+	return consumed
+}
+
+func TestClock_Byoyomi(t *testing.T) {
+	now := newFakeNow()
+	c := NewClock(TimeControl{MainTime: time.Minute, Byoyomi: 10 * time.Second, ByoyomiPeriods: 3}, now.Now)
+	c.Start(Sente)
+
+	// sente spends the main time and 5 seconds of the first period, which isn't lost
+	if consumed := press(t, c, now, 65*time.Second); consumed != 65*time.Second {
+		t.Errorf("Press() = %s, want 1m5s", consumed)
+	}
+	if r, p := c.Remaining(Sente), c.Periods(Sente); r != 0 || p != 3 {
+		t.Errorf("sente has %s and %d periods, want 0 and 3", r, p)
+	}
+	press(t, c, now, time.Second)
+
+	// a move taking a whole period and a bit loses the first period
+	now.Advance(15 * time.Second)
+	if p := c.Periods(Sente); p != 2 {
+		t.Errorf("Periods() during the turn = %d, want 2", p)
+	}
+	c.Pause()
+	now.Advance(time.Hour)
+	if e := c.Elapsed(); e != 15*time.Second {
+		t.Errorf("Elapsed() while paused = %s, want 15s", e)
+	}
+	c.Resume()
+	if consumed := press(t, c, now, 0); consumed != 15*time.Second {
+		t.Errorf("Press() = %s, want 15s spent before and after the pause", consumed)
+	}
+	if p := c.Periods(Sente); p != 2 {
+		t.Errorf("Periods() = %d, want 2", p)
+	}
+	press(t, c, now, time.Second)
+
+	// exactly two periods are still in time with the last one
+	press(t, c, now, 20*time.Second)
+	if p := c.Periods(Sente); p != 1 {
+		t.Errorf("Periods() = %d, want 1", p)
+	}
+	press(t, c, now, time.Second)
+
+	now.Advance(10 * time.Second)
+	if c.TimeUp() {
+		t.Fatal("TimeUp() = true at the end of the last period")
+	}
+	now.Advance(time.Millisecond)
+	if !c.TimeUp() {
+		t.Fatal("TimeUp() = false after the last period")
+	}
+	if _, err := c.Press(); err != ErrTimeUp {
+		t.Errorf("Press() error = %v, want ErrTimeUp", err)
+	}
+	if c.Running() || c.Turn() != Sente {
+		t.Errorf("running %v and turn %s after the time is up, want the clock stopped in the turn of sente", c.Running(), c.Turn())
+	}
+}
+
+func TestClock_Increment(t *testing.T) {
+	now := newFakeNow()
+	c := NewClock(TimeControl{MainTime: time.Minute, Increment: 10 * time.Second}, now.Now)
+	c.Start(Sente)
+
+	press(t, c, now, 30*time.Second)
+	if r := c.Remaining(Sente); r != 40*time.Second {
+		t.Errorf("Remaining(Sente) = %s, want 40s", r)
+	}
+	if r := c.Remaining(Gote); r != time.Minute {
+		t.Errorf("Remaining(Gote) before the move = %s, want 1m", r)
+	}
+	press(t, c, now, 5*time.Second)
+	if r := c.Remaining(Gote); r != 65*time.Second {
+		t.Errorf("Remaining(Gote) = %s, want 1m5s", r)
+	}
+	if p := c.Periods(Gote); p != 0 {
+		t.Errorf("Periods(Gote) = %d, want 0 without byoyomi", p)
+	}
+
+	// the increment doesn't save a move made after the time is up
+	now.Advance(41 * time.Second)
+	if _, err := c.Press(); err != ErrTimeUp {
+		t.Errorf("Press() error = %v, want ErrTimeUp", err)
+	}
+}
+
+func TestClock_SuddenDeath(t *testing.T) {
+	now := newFakeNow()
+	c := NewClock(TimeControl{MainTime: time.Minute}, now.Now)
+	c.Start(Gote)
+
+	press(t, c, now, time.Minute)
+	if r := c.Remaining(Gote); r != 0 {
+		t.Errorf("Remaining(Gote) = %s, want 0", r)
+	}
+	now.Advance(time.Minute + time.Nanosecond)
+	if r := c.Remaining(Sente); r != 0 {
+		t.Errorf("Remaining(Sente) = %s, want 0", r)
+	}
+	if !c.TimeUp() {
+		t.Error("TimeUp() = false after the main time")
+	}
+}
+
+func TestClock_Unlimited(t *testing.T) {
+	now := newFakeNow()
+	c := NewClock(TimeControl{}, now.Now)
+	c.Start(Sente)
+	now.Advance(24 * time.Hour)
+	if c.TimeUp() {
+		t.Error("TimeUp() = true without time limit")
+	}
+	press(t, c, now, 0)
+	if c.Turn() != Gote {
+		t.Errorf("Turn() = %s, want gote", c.Turn())
+	}
+}
+
+func TestGame_ApplyMove_TimeUp(t *testing.T) {
+	now := newFakeNow()
+	g := NewGame()
+	g.StartClock(NewClock(TimeControl{MainTime: time.Minute, Byoyomi: 10 * time.Second}, now.Now))
+	now.Advance(30 * time.Second)
+	playUSI(t, g, "7g7f")
+	now.Advance(70 * time.Second)
+	playUSI(t, g, "3c3d")
+	if times := g.MoveTimes(); len(times) != 2 || times[0] != 30*time.Second || times[1] != 70*time.Second {
+		t.Errorf("MoveTimes() = %v, want [30s 1m10s]", times)
+	}
+
+	// sente thinks for the rest of the main time and longer than byoyomi
+	now.Advance(40*time.Second + 10*time.Second + time.Millisecond)
+	m, err := g.Board().ParseMove("2g2f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyMove(m); errors.Cause(err) != ErrTimeUp {
+		t.Fatalf("ApplyMove() error = %v, want ErrTimeUp", err)
+	}
+	want := Result{Reason: ReasonTimeUp, Winner: Gote}
+	if result, ok := g.Result(); !ok || result != want {
+		t.Errorf("Result() = %v, %v, want %v", result, ok, want)
+	}
+	if got := len(g.History()); got != 2 {
+		t.Errorf("moves = %d, want the late move not played", got)
+	}
+	if g.Clock().Running() {
+		t.Error("the clock runs after the game is over")
+	}
+}
@@ -0,0 +1,360 @@
+package shogi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// csaTimeFormat is the format of $START_TIME and $END_TIME in CSA records
+const csaTimeFormat = "2006/01/02 15:04:05"
+
+// csaPieceNames are the piece names in CSA format
+var csaPieceNames = [numPieceKinds]string{
+	"", "FU", "KY", "KE", "GI", "KI", "KA", "HI", "OU", "TO", "NY", "NK", "NG", "UM", "RY",
+}
+
+func csaPieceKind(name string) PieceKind {
+	for kind := KindPawn; kind <= KindPromotedRook; kind++ {
+		if csaPieceNames[kind] == name {
+			return kind
+		}
+	}
+	return KindNone
+}
+
+// csaSign returns the sign of the color in CSA format, + for sente and - for gote
+func csaSign(c Color) string {
+	if c == Sente {
+		return "+"
+	}
+	return "-"
+}
+
+// CSAInfo is the information written in a CSA record besides the position and the moves
+type CSAInfo struct {
+	SenteName string
+	GoteName  string
+	// StartTime and EndTime are omitted if they are zero
+	StartTime time.Time
+	EndTime   time.Time
+	// Times are the times consumed by the moves, which are written as T lines if they are given for every move.
+	// The times recorded in the game are used if it's nil.
+	Times []time.Duration
+	// Terminator is the special move ending the game such as %TORYO.
+	// The one for the result of the game is written if it's empty, and nothing if the game is in progress.
+	Terminator string
+}
+
+// CSAMove returns the move for the side to move in CSA format, e.g. "+7776FU", "-0055KA".
+// The piece is the one after the move, so a promoting move gives the promoted piece, e.g. "+8822UM".
+func (b *BitboardBoard) CSAMove(m Move) string {
+	from := "00"
+	kind := m.Piece
+	if !m.Drop {
+		from = fmt.Sprintf("%d%d", m.From.X(), m.From.Y())
+		if m.Promote {
+			kind = kind.Promote()
+		}
+	}
+	return fmt.Sprintf("%s%s%d%d%s", csaSign(b.turn), from, m.To.X(), m.To.Y(), csaPieceNames[kind])
+}
+
+// ParseCSAMove parses the move in CSA format for the side to move, e.g. "+7776FU".
+// It only checks the notation and the pieces on the board, see ValidateMove for legality.
+func (b *BitboardBoard) ParseCSAMove(s string) (Move, error) {
+	if len(s) != 7 || s[0] != csaSign(b.turn)[0] {
+		return Move{}, errors.Errorf("invalid csa move: %q", s)
+	}
+	for _, c := range s[1:5] {
+		if c < '0' || c > '9' {
+			return Move{}, errors.Errorf("invalid csa move: %q", s)
+		}
+	}
+	kind := csaPieceKind(s[5:])
+	if kind == KindNone {
+		return Move{}, errors.Errorf("invalid csa piece: %q", s)
+	}
+	toX, toY := Axis(s[3]-'0'), Axis(s[4]-'0')
+	if toX == 0 || toY == 0 {
+		return Move{}, errors.Errorf("invalid csa move: %q", s)
+	}
+	to := NewSquare(toX, toY)
+
+	if s[1:3] == "00" {
+		if kind == KindKing || kind.IsPromoted() {
+			return Move{}, errors.Errorf("invalid csa drop: %q", s)
+		}
+		return b.NewDrop(kind, to), nil
+	}
+	fromX, fromY := Axis(s[1]-'0'), Axis(s[2]-'0')
+	if fromX == 0 || fromY == 0 {
+		return Move{}, errors.Errorf("invalid csa move: %q", s)
+	}
+	from := NewSquare(fromX, fromY)
+	current, _ := b.PieceAt(from)
+	switch {
+	case current == kind:
+		return b.NewMove(from, to, false), nil
+	case current.IsPromotable() && current.Promote() == kind:
+		return b.NewMove(from, to, true), nil
+	default:
+		return Move{}, errors.Errorf("the piece at %v is not %s: %q", from.Position(), csaPieceNames[kind], s)
+	}
+}
+
+// CSAPosition returns the position in CSA format, the rows P1 to P9, the pieces in hand and the side to move
+func (b *BitboardBoard) CSAPosition() string {
+	var sb strings.Builder
+	for y := Axis(1); y <= 9; y++ {
+		sb.WriteString(fmt.Sprintf("P%d", y))
+		for x := Axis(9); x >= 1; x-- {
+			kind, color := b.PieceAt(NewSquare(x, y))
+			if kind == KindNone {
+				sb.WriteString(" * ")
+			} else {
+				sb.WriteString(csaSign(color) + csaPieceNames[kind])
+			}
+		}
+		sb.WriteString("\n")
+	}
+	for _, c := range []Color{Sente, Gote} {
+		var hand strings.Builder
+		for _, kind := range HandKinds {
+			for i := 0; i < b.hands[c][kind]; i++ {
+				hand.WriteString("00" + csaPieceNames[kind])
+			}
+		}
+		if hand.Len() > 0 {
+			sb.WriteString("P" + csaSign(c) + hand.String() + "\n")
+		}
+	}
+	sb.WriteString(csaSign(b.turn) + "\n")
+	return sb.String()
+}
+
+// CSA returns the game record in CSA format version 2.2
+func (g *Game) CSA(info CSAInfo) string {
+	var sb strings.Builder
+	sb.WriteString("V2.2\n")
+	if info.SenteName != "" {
+		sb.WriteString("N+" + info.SenteName + "\n")
+	}
+	if info.GoteName != "" {
+		sb.WriteString("N-" + info.GoteName + "\n")
+	}
+	if !info.StartTime.IsZero() {
+		sb.WriteString("$START_TIME:" + info.StartTime.Format(csaTimeFormat) + "\n")
+	}
+	if !info.EndTime.IsZero() {
+		sb.WriteString("$END_TIME:" + info.EndTime.Format(csaTimeFormat) + "\n")
+	}
+	sb.WriteString(g.initialBoard.CSAPosition())
+
+	board := g.initialBoard.Clone()
+	times := info.Times
+	if times == nil {
+		times = g.MoveTimes()
+	}
+	withTimes := times != nil && len(times) >= len(g.history)
+	for i, m := range g.history {
+		sb.WriteString(board.CSAMove(m) + "\n")
+		if withTimes {
+			sb.WriteString(fmt.Sprintf("T%d\n", int(times[i]/time.Second)))
+		}
+		board.DoMove(m)
+	}
+	terminator := info.Terminator
+	if result, ok := g.Result(); ok && terminator == "" {
+		terminator = g.csaTerminator(result)
+	}
+	if terminator != "" {
+		sb.WriteString(terminator + "\n")
+	}
+	return sb.String()
+}
+
+// ParseCSA parses the game record or the position in CSA format and plays its moves.
+// The position is given by PI, P1 to P9 or P+/P- lines, and the moves follow the side to move line.
+// The times of the moves are recorded from T lines, other information lines such as names are ignored,
+// and it stops at the special moves such as %TORYO, which end the game with the result if they mean one.
+func ParseCSA(csa string) (*Game, error) {
+	b := NewEmptyBitboardBoard()
+	var game *Game
+	for _, line := range strings.Split(strings.Replace(csa, "\r\n", "\n", -1), "\n") {
+		if strings.HasPrefix(line, "'") {
+			continue
+		}
+		// statements can be put on a line separated by commas
+		for _, stmt := range strings.Split(line, ",") {
+			stmt = strings.TrimRight(stmt, " ")
+			switch {
+			case stmt == "" || stmt[0] == 'V' || stmt[0] == 'N' || stmt[0] == '$':
+			case stmt[0] == 'T':
+				if game == nil || len(game.history) == 0 {
+					continue
+				}
+				seconds, err := strconv.Atoi(stmt[1:])
+				if err != nil {
+					return nil, errors.Errorf("parse csa: invalid time: %q", stmt)
+				}
+				game.times[len(game.times)-1] = time.Duration(seconds) * time.Second
+				game.timed = true
+			case stmt[0] == '%':
+				if game == nil {
+					return nil, errors.Errorf("parse csa: %s before the position", stmt)
+				}
+				if result, ok := game.parseCSATerminator(stmt); ok {
+					game.end(result)
+				}
+				return game, nil
+			case stmt[0] == 'P':
+				if game != nil {
+					return nil, errors.Errorf("parse csa: position after the moves: %q", stmt)
+				}
+				if err := b.parseCSAPositionLine(stmt); err != nil {
+					return nil, errors.Wrap(err, "parse csa")
+				}
+			case stmt == "+" || stmt == "-":
+				if game != nil {
+					return nil, errors.Errorf("parse csa: side to move after the moves: %q", stmt)
+				}
+				b.setTurn(Sente)
+				if stmt == "-" {
+					b.setTurn(Gote)
+				}
+				var err error
+				if game, err = NewGameFromBoard(b); err != nil {
+					return nil, errors.Wrap(err, "parse csa")
+				}
+			case stmt[0] == '+' || stmt[0] == '-':
+				if game == nil {
+					return nil, errors.Errorf("parse csa: move before the side to move: %q", stmt)
+				}
+				move, err := game.board.ParseCSAMove(stmt)
+				if err != nil {
+					return nil, errors.Wrap(err, "parse csa")
+				}
+				if err := game.ApplyMove(move); err != nil {
+					return nil, errors.Wrapf(err, "parse csa: move %s", stmt)
+				}
+			default:
+				return nil, errors.Errorf("parse csa: unknown line: %q", stmt)
+			}
+		}
+	}
+	if game == nil {
+		return nil, errors.New("parse csa: side to move is missing")
+	}
+	return game, nil
+}
+
+func (b *BitboardBoard) parseCSAPositionLine(line string) error {
+	switch {
+	case strings.HasPrefix(line, "PI"):
+		initial, _, err := ParseSFEN(InitialSFEN)
+		if err != nil {
+			return err
+		}
+		*b = *initial
+		// the pieces removed for handicap games follow, e.g. PI82HI22KA
+		for rest := line[2:]; len(rest) > 0; rest = rest[4:] {
+			if len(rest) < 4 {
+				return errors.Errorf("invalid csa position: %q", line)
+			}
+			sq, err := parseCSASquare(rest[:2])
+			if err != nil || csaPieceKind(rest[2:4]) == KindNone || b.kinds[sq] != csaPieceKind(rest[2:4]) {
+				return errors.Errorf("invalid csa handicap: %q", line)
+			}
+			b.remove(sq)
+		}
+	case len(line) >= 2 && line[1] >= '1' && line[1] <= '9':
+		y := Axis(line[1] - '0')
+		cells := line[2:]
+		for x := Axis(9); x >= 1; x-- {
+			i := int(9-x) * 3
+			if len(cells) < i+3 {
+				// trailing empty squares may be trimmed
+				break
+			}
+			cell := cells[i : i+3]
+			if cell == " * " {
+				continue
+			}
+			kind := csaPieceKind(cell[1:])
+			if (cell[0] != '+' && cell[0] != '-') || kind == KindNone {
+				return errors.Errorf("invalid csa position: %q", line)
+			}
+			color := Sente
+			if cell[0] == '-' {
+				color = Gote
+			}
+			sq := NewSquare(x, y)
+			if b.kinds[sq] != KindNone {
+				b.remove(sq)
+			}
+			b.put(sq, kind, color)
+		}
+	case strings.HasPrefix(line, "P+") || strings.HasPrefix(line, "P-"):
+		color := Sente
+		if line[1] == '-' {
+			color = Gote
+		}
+		for rest := line[2:]; len(rest) > 0; rest = rest[4:] {
+			if len(rest) < 4 {
+				return errors.Errorf("invalid csa position: %q", line)
+			}
+			if rest == "00AL" {
+				b.putRestInHand(color)
+				break
+			}
+			kind := csaPieceKind(rest[2:4])
+			if kind == KindNone {
+				return errors.Errorf("invalid csa piece: %q", line)
+			}
+			if rest[:2] == "00" {
+				if kind < KindPawn || kind > KindRook {
+					return errors.Errorf("invalid csa piece in hand: %q", line)
+				}
+				b.addHand(color, kind, 1)
+				continue
+			}
+			sq, err := parseCSASquare(rest[:2])
+			if err != nil {
+				return errors.Wrapf(err, "invalid csa position: %q", line)
+			}
+			if b.kinds[sq] != KindNone {
+				b.remove(sq)
+			}
+			b.put(sq, kind, color)
+		}
+	default:
+		return errors.Errorf("invalid csa position: %q", line)
+	}
+	return nil
+}
+
+// putRestInHand gives the color the pieces which are neither on the board nor in hand, except kings
+func (b *BitboardBoard) putRestInHand(c Color) {
+	for kind := KindPawn; kind <= KindRook; kind++ {
+		n := pieceKindCounts[kind] - b.hands[Sente][kind] - b.hands[Gote][kind]
+		for sq := Square(0); sq < NumSquares; sq++ {
+			if b.kinds[sq] != KindNone && b.kinds[sq].Demote() == kind {
+				n--
+			}
+		}
+		if n > 0 {
+			b.addHand(c, kind, n)
+		}
+	}
+}
+
+func parseCSASquare(s string) (Square, error) {
+	if len(s) != 2 || s[0] < '1' || s[0] > '9' || s[1] < '1' || s[1] > '9' {
+		return NoSquare, errors.Errorf("invalid csa square: %q", s)
+	}
+	return NewSquare(Axis(s[0]-'0'), Axis(s[1]-'0')), nil
+}
@@ -0,0 +1,70 @@
+package shogi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestGame_DeclareWin(t *testing.T) {
+	tests := []struct {
+		name string
+		// sfen has sente's king in gote's area with 10 pieces worth 26 points, and the pawns in hand add the rest
+		sfen string
+		rule DeclarationRule
+		want Result
+	}{
+		{name: "28 points", sfen: "RRBB5/4K4/PPPPPP3/9/9/9/9/9/4k4 b 2P 1", want: Result{Reason: ReasonDeclaration, Winner: Sente}},
+		{name: "27 points for sente", sfen: "RRBB5/4K4/PPPPPP3/9/9/9/9/9/4k4 b P 1", want: Result{Reason: ReasonIllegalMove, Winner: Gote}},
+		{name: "9 pieces", sfen: "RRBB5/4K4/PPPPP4/9/9/9/9/9/4k4 b 3P 1", want: Result{Reason: ReasonIllegalMove, Winner: Gote}},
+		{name: "king outside the area", sfen: "RRBB5/9/PPPPPP3/4K4/9/9/9/9/4k4 b 2P 1", want: Result{Reason: ReasonIllegalMove, Winner: Gote}},
+		{name: "in check", sfen: "RRBBs4/4K4/PPPPPP3/9/9/9/9/9/4k4 b 2P 1", want: Result{Reason: ReasonIllegalMove, Winner: Gote}},
+		{name: "24-point rule draw", sfen: "RRBB5/4K4/PPPPPP3/9/9/9/9/9/4k4 b 2P 1", rule: DeclarationRule24, want: Result{Reason: ReasonJishogi}},
+		{name: "24-point rule win", sfen: "RRBB5/4K4/PPPPPP3/9/9/9/9/9/4k4 b G5P 1", rule: DeclarationRule24, want: Result{Reason: ReasonDeclaration, Winner: Sente}},
+	}
+	for _, tt := range tests {
+		g, err := NewGameFromSFEN(tt.sfen)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		g.SetDeclarationRule(tt.rule)
+		result, err := g.DeclareWin()
+		if err != nil {
+			t.Errorf("%s: DeclareWin() error = %v", tt.name, err)
+			continue
+		}
+		if result != tt.want {
+			t.Errorf("%s: DeclareWin() = %v, want %v", tt.name, result, tt.want)
+		}
+		if got, ok := g.Result(); !ok || got != tt.want {
+			t.Errorf("%s: Result() = %v, %v, want %v", tt.name, got, ok, tt.want)
+		}
+		if _, err := g.DeclareWin(); errors.Cause(err) != ErrGameOver {
+			t.Errorf("%s: DeclareWin() after the end error = %v, want ErrGameOver", tt.name, err)
+		}
+	}
+}
+
+func TestGame_DeclareWin_TimeUp(t *testing.T) {
+	now := newFakeNow()
+	g, err := NewGameFromSFEN("RRBB5/4K4/PPPPPP3/9/9/9/9/9/4k4 b 2P 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.StartClock(NewClock(TimeControl{MainTime: time.Minute, Byoyomi: 10 * time.Second}, now.Now))
+
+	// the declaration in the last period of byoyomi is in time, and the one after it is too late
+	now.Advance(70 * time.Second)
+	if g.CheckTime() {
+		t.Fatal("CheckTime() = true in byoyomi")
+	}
+	now.Advance(time.Millisecond)
+	if _, err := g.DeclareWin(); errors.Cause(err) != ErrTimeUp {
+		t.Fatalf("DeclareWin() error = %v, want ErrTimeUp", err)
+	}
+	want := Result{Reason: ReasonTimeUp, Winner: Gote}
+	if result, ok := g.Result(); !ok || result != want {
+		t.Errorf("Result() = %v, %v, want %v", result, ok, want)
+	}
+}
@@ -0,0 +1,130 @@
+package shogi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// pieceKindCounts is the number of pieces of each kind in a set
+var pieceKindCounts = map[PieceKind]int{
+	KindPawn:   18,
+	KindLance:  4,
+	KindKnight: 4,
+	KindSilver: 4,
+	KindGold:   4,
+	KindBishop: 2,
+	KindRook:   2,
+	KindKing:   2,
+}
+
+// NewEmptyBitboardBoard returns a board without any pieces and sente to move, to set up arbitrary positions.
+func NewEmptyBitboardBoard() *BitboardBoard {
+	return &BitboardBoard{}
+}
+
+// PlacePiece places the piece of the kind and color at the position, replacing the existing piece if any.
+func (b *BitboardBoard) PlacePiece(pos *Position, kind PieceKind, c Color, promoted bool) error {
+	if !isOnBoard(pos) {
+		return errors.Errorf("%v is out of the board", pos)
+	}
+	if kind <= KindNone || kind >= numPieceKinds {
+		return errors.Errorf("invalid piece kind: %d", kind)
+	}
+	if !c.IsValid() {
+		return errors.Errorf("invalid color: %d", c)
+	}
+	if promoted {
+		if !kind.IsPromotable() {
+			return errors.Errorf("%s can't be promoted", kind.Name())
+		}
+		kind = kind.Promote()
+	}
+	b.RemovePiece(pos)
+	b.put(SquareOf(pos), kind, c)
+	return nil
+}
+
+// RemovePiece removes the piece at the position if any
+func (b *BitboardBoard) RemovePiece(pos *Position) {
+	if !isOnBoard(pos) {
+		return
+	}
+	if sq := SquareOf(pos); b.kinds[sq] != KindNone {
+		b.remove(sq)
+	}
+}
+
+// SetHand sets the number of pieces of the kind the color has in hand
+func (b *BitboardBoard) SetHand(c Color, kind PieceKind, n int) error {
+	if !c.IsValid() {
+		return errors.Errorf("invalid color: %d", c)
+	}
+	if kind < KindPawn || kind > KindRook {
+		return errors.Errorf("%s can't be in hand", kind.Name())
+	}
+	if n < 0 {
+		return errors.Errorf("invalid number of pieces in hand: %d", n)
+	}
+	b.addHand(c, kind, n-b.hands[c][kind])
+	return nil
+}
+
+// SetSideToMove sets the color to move
+func (b *BitboardBoard) SetSideToMove(c Color) error {
+	if !c.IsValid() {
+		return errors.Errorf("invalid color: %d", c)
+	}
+	b.setTurn(c)
+	return nil
+}
+
+// Validate reports problems which make the position impossible to reach in a game.
+// A missing king is allowed since tsume shogi problems leave out the attacker's king.
+// The side without a king is never in check, so it can't be checkmated.
+func (b *BitboardBoard) Validate() error {
+	var problems []string
+
+	var counts [numPieceKinds]int
+	for sq := Square(0); sq < NumSquares; sq++ {
+		if b.kinds[sq] != KindNone {
+			counts[b.kinds[sq].Demote()]++
+		}
+	}
+	for c := Sente; c <= Gote; c++ {
+		for kind := KindPawn; kind <= KindRook; kind++ {
+			counts[kind] += b.hands[c][kind]
+		}
+	}
+	for kind := KindPawn; kind <= KindKing; kind++ {
+		if counts[kind] > pieceKindCounts[kind] {
+			problems = append(problems, fmt.Sprintf("more than %d pieces of %s", pieceKindCounts[kind], kind.Name()))
+		}
+	}
+
+	for c := Sente; c <= Gote; c++ {
+		if b.pieces[c][KindKing].Count() > 1 {
+			problems = append(problems, fmt.Sprintf("%s has more than one king", c))
+		}
+		for x := Axis(1); x <= 9; x++ {
+			if b.pieces[c][KindPawn].And(fileTable[x]).Count() > 1 {
+				problems = append(problems, fmt.Sprintf("%s has two pawns on the same column: %d", c, x))
+			}
+		}
+		for _, kind := range []PieceKind{KindPawn, KindLance, KindKnight} {
+			for dead := b.pieces[c][kind].And(deadEndTable[c][kind]); !dead.IsZero(); {
+				problems = append(problems, fmt.Sprintf("%s's %s can't move at %v", c, kind.Name(), dead.PopFirst().Position()))
+			}
+		}
+	}
+
+	if b.isKingAttacked(b.turn.Opponent()) {
+		problems = append(problems, fmt.Sprintf("%s is in check but it's not to move", b.turn.Opponent()))
+	}
+
+	if len(problems) > 0 {
+		return errors.Errorf("invalid position: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package shogi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBitboardBoard_PlacePiece(t *testing.T) {
+	tests := []struct {
+		name     string
+		pos      *Position
+		kind     PieceKind
+		color    Color
+		promoted bool
+		wantErr  bool
+	}{
+		{name: "sente's pawn", pos: &Position{X: 7, Y: 7}, kind: KindPawn, color: Sente},
+		{name: "gote's promoted rook", pos: &Position{X: 2, Y: 2}, kind: KindRook, color: Gote, promoted: true},
+		{name: "out of the board", pos: &Position{X: 0, Y: 5}, kind: KindPawn, color: Sente, wantErr: true},
+		{name: "no kind", pos: &Position{X: 5, Y: 5}, kind: KindNone, color: Sente, wantErr: true},
+		{name: "invalid color", pos: &Position{X: 5, Y: 5}, kind: KindPawn, color: 2, wantErr: true},
+		{name: "negative color", pos: &Position{X: 5, Y: 5}, kind: KindPawn, color: -1, wantErr: true},
+		{name: "promoted gold", pos: &Position{X: 5, Y: 5}, kind: KindGold, color: Sente, promoted: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		b := NewEmptyBitboardBoard()
+		err := b.PlacePiece(tt.pos, tt.kind, tt.color, tt.promoted)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: PlacePiece() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		want := tt.kind
+		if tt.promoted {
+			want = want.Promote()
+		}
+		if kind, color := b.PieceAt(SquareOf(tt.pos)); kind != want || color != tt.color {
+			t.Errorf("%s: PieceAt() = %s of %s, want %s of %s", tt.name, kind.Name(), color, want.Name(), tt.color)
+		}
+	}
+}
+
+func TestBitboardBoard_SetHand(t *testing.T) {
+	b := NewEmptyBitboardBoard()
+	if err := b.SetHand(Gote, KindGold, 2); err != nil {
+		t.Fatal(err)
+	}
+	if n := b.Hand(Gote, KindGold); n != 2 {
+		t.Errorf("Hand(Gote, KindGold) = %d, want 2", n)
+	}
+	for _, tt := range []struct {
+		c    Color
+		kind PieceKind
+		n    int
+	}{{2, KindGold, 1}, {Sente, KindKing, 1}, {Sente, KindPawn, -1}} {
+		if err := b.SetHand(tt.c, tt.kind, tt.n); err == nil {
+			t.Errorf("SetHand(%d, %s, %d) error = nil", tt.c, tt.kind.Name(), tt.n)
+		}
+	}
+	if err := b.SetSideToMove(Color(5)); err == nil || b.SideToMove() != Sente {
+		t.Errorf("SetSideToMove(5) error = %v and side to move %s, want an error with sente to move", err, b.SideToMove())
+	}
+}
+
+func TestBitboardBoard_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		sfen string
+		// want is a part of the error, which is empty for a valid position
+		want string
+	}{
+		{name: "initial position", sfen: "lnsgkgsnl/1r5b1/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL b - 1"},
+		{name: "tsume shogi without sente's king", sfen: "7kl/9/6PPp/9/9/9/9/9/9 b 2G 1"},
+		{name: "no kings", sfen: "9/9/9/9/4p4/9/9/9/9 b - 1"},
+		{name: "two kings", sfen: "4k4/9/9/9/9/9/9/9/3KK4 b - 1", want: "先手 has more than one king"},
+		{name: "two pawns", sfen: "4k4/9/9/9/9/4P4/4P4/9/4K4 b - 1", want: "two pawns on the same column: 5"},
+		{name: "dead knight", sfen: "4k3N/9/9/9/9/9/9/9/4K4 b - 1", want: "先手's 桂馬 can't move at x: 1, y: 1"},
+		{name: "too many rooks", sfen: "4k4/9/9/9/9/9/9/9/R3K4 b 2R 1", want: "more than 2 pieces of 飛車"},
+		{name: "opponent in check", sfen: "4k4/9/9/9/4R4/9/9/9/4K4 b - 1", want: "後手 is in check but it's not to move"},
+	}
+	for _, tt := range tests {
+		b, _, err := ParseSFEN(tt.sfen)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		err = b.Validate()
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%s: Validate() error = %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Validate() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package shogi
+
+// pieceValues are the material values of the pieces on the board
+var pieceValues = [numPieceKinds]int{
+	KindPawn:           90,
+	KindLance:          315,
+	KindKnight:         405,
+	KindSilver:         495,
+	KindGold:           540,
+	KindBishop:         855,
+	KindRook:           990,
+	KindKing:           15000,
+	KindPromotedPawn:   540,
+	KindPromotedLance:  540,
+	KindPromotedKnight: 540,
+	KindPromotedSilver: 540,
+	KindPromotedBishop: 945,
+	KindPromotedRook:   1395,
+}
+
+// handPieceValues are the material values of the pieces in hand, which are a bit more flexible than on the board
+var handPieceValues = [numPieceKinds]int{
+	KindPawn:   100,
+	KindLance:  350,
+	KindKnight: 450,
+	KindSilver: 550,
+	KindGold:   600,
+	KindBishop: 950,
+	KindRook:   1100,
+}
+
+// Evaluate returns the static score of the position from the side to move's perspective
+func (b *BitboardBoard) Evaluate() int {
+	var score int
+	for sq := Square(0); sq < NumSquares; sq++ {
+		kind := b.kinds[sq]
+		if kind == KindNone || kind == KindKing {
+			continue
+		}
+		if b.owners[sq] == Sente {
+			score += pieceValues[kind]
+		} else {
+			score -= pieceValues[kind]
+		}
+	}
+	for kind := KindPawn; kind <= KindRook; kind++ {
+		score += (b.hands[Sente][kind] - b.hands[Gote][kind]) * handPieceValues[kind]
+	}
+	if b.turn == Gote {
+		return -score
+	}
+	return score
+}
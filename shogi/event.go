@@ -0,0 +1,106 @@
+package shogi
+
+// EventType is the type of events of a game
+type EventType int
+
+const (
+	// EventMoveApplied is sent after a move is played
+	EventMoveApplied EventType = iota + 1
+	// EventPieceCaptured is sent after a move captured a piece, which is Move.Captured
+	EventPieceCaptured
+	// EventPromotion is sent after a move promoted the piece
+	EventPromotion
+	// EventCheck is sent after a move checked the king of Color
+	EventCheck
+	// EventGameOver is sent when the game ends with Result
+	EventGameOver
+	// EventUndo is sent after Move is taken back
+	EventUndo
+)
+
+// Event is an event of a game delivered to the handlers subscribing the game
+type Event struct {
+	Type EventType
+	// Ply is the number of moves played after the event
+	Ply int
+	// Move is the move played or taken back, which is the last move for the events caused by a move
+	Move Move
+	// Color is the player who played or took back the move, or the player checked for EventCheck
+	Color  Color
+	Result Result
+}
+
+// EventHandler handles events of a game. It's called synchronously when the game changes,
+// so it may read the game but mustn't modify it.
+type EventHandler func(e Event)
+
+type subscription struct {
+	handler EventHandler
+	types   []EventType
+}
+
+// Subscribe registers the handler called for the events of the types, or all the events if no type is given.
+// Handlers are called in the order they are subscribed. It returns the function to unsubscribe.
+func (g *Game) Subscribe(handler EventHandler, types ...EventType) (unsubscribe func()) {
+	sub := &subscription{handler: handler, types: types}
+	g.subscriptions = append(g.subscriptions, sub)
+	return func() {
+		for i, s := range g.subscriptions {
+			if s == sub {
+				g.subscriptions = append(g.subscriptions[:i:i], g.subscriptions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// emit sends the event to the handlers accepting it if all the conditions hold. The conditions are checked only if
+// there is such a handler, so that costly ones such as checkmates aren't looked for without subscribers.
+func (g *Game) emit(e Event, conditions ...func() bool) {
+	var subs []*subscription
+	for _, sub := range g.subscriptions {
+		if sub.accepts(e.Type) {
+			subs = append(subs, sub)
+		}
+	}
+	if len(subs) == 0 {
+		return
+	}
+	for _, holds := range conditions {
+		if !holds() {
+			return
+		}
+	}
+	// the handlers can unsubscribe while being called, and subs is a copy not affected by it
+	for _, sub := range subs {
+		sub.handler(e)
+	}
+}
+
+// emitMoveEvents sends the events of the move which has just been played
+func (g *Game) emitMoveEvents(move Move) {
+	ply := len(g.history)
+	mover := g.SideToMove().Opponent()
+	g.emit(Event{Type: EventMoveApplied, Ply: ply, Move: move, Color: mover})
+	if move.Captured != KindNone {
+		g.emit(Event{Type: EventPieceCaptured, Ply: ply, Move: move, Color: mover})
+	}
+	if move.Promote {
+		g.emit(Event{Type: EventPromotion, Ply: ply, Move: move, Color: mover})
+	}
+	if g.checks[ply-1] {
+		g.emit(Event{Type: EventCheck, Ply: ply, Move: move, Color: g.SideToMove()})
+	}
+}
+
+func (s *subscription) accepts(t EventType) bool {
+	if len(s.types) == 0 {
+		return true
+	}
+	for _, accepted := range s.types {
+		if accepted == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package shogi
+
+import (
+	"reflect"
+	"testing"
+)
+
+// recordEvents subscribes to the events of the types and returns the events received so far
+func recordEvents(g *Game, types ...EventType) *[]Event {
+	var events []Event
+	g.Subscribe(func(e Event) {
+		events = append(events, e)
+	}, types...)
+	return &events
+}
+
+// usiMove returns the move in USI on the board, failing the test if it's invalid
+func usiMove(t *testing.T, b *BitboardBoard, usi string) Move {
+	t.Helper()
+	m, err := b.ParseMove(usi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestGame_Subscribe(t *testing.T) {
+	g := NewGame()
+	playUSI(t, g, "7g7f", "3c3d")
+	last := g.History()[1]
+	events := recordEvents(g)
+	capture := usiMove(t, g.Board(), "8h2b+")
+	playUSI(t, g, "8h2b+")
+	if err := g.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Resign(Sente); err != nil {
+		t.Fatal(err)
+	}
+	want := []Event{
+		{Type: EventMoveApplied, Ply: 3, Move: capture, Color: Sente},
+		{Type: EventPieceCaptured, Ply: 3, Move: capture, Color: Sente},
+		{Type: EventPromotion, Ply: 3, Move: capture, Color: Sente},
+		{Type: EventUndo, Ply: 2, Move: capture, Color: Sente},
+		{Type: EventGameOver, Ply: 2, Move: last, Result: Result{Reason: ReasonResignation, Winner: Gote}},
+	}
+	if !reflect.DeepEqual(*events, want) {
+		t.Errorf("events = %+v, want %+v", *events, want)
+	}
+	if capture.Captured != KindBishop {
+		t.Errorf("captured = %v, want the bishop", capture.Captured)
+	}
+}
+
+func TestGame_Subscribe_Checkmate(t *testing.T) {
+	g, err := NewGameFromSFEN("7kl/9/6PPp/9/9/9/9/9/K8 b 2G 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := recordEvents(g)
+	filtered := recordEvents(g, EventCheck, EventGameOver)
+	mate := usiMove(t, g.Board(), "G*2b")
+	playUSI(t, g, "G*2b")
+
+	check := Event{Type: EventCheck, Ply: 1, Move: mate, Color: Gote}
+	gameOver := Event{Type: EventGameOver, Ply: 1, Move: mate, Result: Result{Reason: ReasonCheckmate, Winner: Sente}}
+	want := []Event{{Type: EventMoveApplied, Ply: 1, Move: mate, Color: Sente}, check, gameOver}
+	if !reflect.DeepEqual(*all, want) {
+		t.Errorf("events = %+v, want %+v", *all, want)
+	}
+	if want := []Event{check, gameOver}; !reflect.DeepEqual(*filtered, want) {
+		t.Errorf("filtered events = %+v, want %+v", *filtered, want)
+	}
+}
+
+func TestGame_Subscribe_Unsubscribe(t *testing.T) {
+	g := NewGame()
+	var calls []string
+	var unsubscribeFirst func()
+	unsubscribeFirst = g.Subscribe(func(e Event) {
+		calls = append(calls, "first")
+		// unsubscribing in the handler doesn't stop the event for the other handlers
+		unsubscribeFirst()
+	})
+	unsubscribeSecond := g.Subscribe(func(e Event) {
+		calls = append(calls, "second")
+	}, EventMoveApplied)
+	playUSI(t, g, "7g7f")
+	if want := []string{"first", "second"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("handlers called = %v, want %v", calls, want)
+	}
+
+	calls = nil
+	playUSI(t, g, "3c3d")
+	if want := []string{"second"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("handlers called = %v, want %v", calls, want)
+	}
+
+	calls = nil
+	unsubscribeSecond()
+	unsubscribeSecond()
+	unsubscribeFirst()
+	playUSI(t, g, "2g2f")
+	if len(calls) != 0 {
+		t.Errorf("handlers called = %v after unsubscribing, want none", calls)
+	}
+}
+
+func TestGame_emit_Conditions(t *testing.T) {
+	g := NewGame()
+	checked := false
+	condition := func() bool {
+		checked = true
+		return false
+	}
+
+	// the conditions aren't checked without the handlers accepting the event
+	g.emit(Event{Type: EventGameOver}, condition)
+	unsubscribe := g.Subscribe(func(e Event) {}, EventMoveApplied)
+	g.emit(Event{Type: EventGameOver}, condition)
+	if checked {
+		t.Error("the condition is checked without the handlers accepting the event")
+	}
+	unsubscribe()
+
+	events := recordEvents(g, EventGameOver)
+	g.emit(Event{Type: EventGameOver}, condition)
+	if !checked {
+		t.Error("the condition isn't checked with a handler accepting the event")
+	}
+	if len(*events) != 0 {
+		t.Errorf("events = %+v, want none as the condition doesn't hold", *events)
+	}
+	g.emit(Event{Type: EventGameOver}, func() bool { return true })
+	if len(*events) != 1 {
+		t.Errorf("events = %+v, want the game over event", *events)
+	}
+}
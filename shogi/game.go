@@ -2,64 +2,338 @@ package shogi
 
 import (
 	"fmt"
-	"github.com/pkg/errors"
 	"strings"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
 type Game struct {
 	currentPlayer Player
 	firstPlayer   Player
 	secondPlayer  Player
-	board         Board
+	board         *BitboardBoard
+	// initialBoard and initialMoveNumber are the position the game started from
+	initialBoard      *BitboardBoard
+	initialMoveNumber int
+	history           []Move
+	// times are the times spent on the moves in history, and timed is whether they are recorded
+	times []time.Duration
+	timed bool
+	clock *Clock
+	// positions are the positions after each move from the initial position, and checks are whether each move checked,
+	// which are used to detect the repetition
+	positions []string
+	checks    []bool
+	// result is set when the game ended other than by checkmate, and resultByMove is whether the last move ended it
+	result           *Result
+	resultByMove     bool
+	illegalMoveLoses bool
+	declarationRule  DeclarationRule
+	subscriptions    []*subscription
 }
 
 // NewGame starts new shogi game
 func NewGame() *Game {
 	firstPlayer := NewPlayer(true)
 	secondPlayer := NewPlayer(false)
-	board := NewBoard(firstPlayer, secondPlayer)
+	board := NewBitboardBoardFrom(NewBoard(firstPlayer, secondPlayer))
 	return &Game{
-		currentPlayer: firstPlayer,
-		firstPlayer:   firstPlayer,
-		secondPlayer:  secondPlayer,
-		board:         board,
+		currentPlayer:     firstPlayer,
+		firstPlayer:       firstPlayer,
+		secondPlayer:      secondPlayer,
+		board:             board,
+		initialBoard:      board.Clone(),
+		initialMoveNumber: 1,
+	}
+}
+
+// NewGameFromSFEN starts new shogi game from the position in SFEN
+func NewGameFromSFEN(sfen string) (*Game, error) {
+	board, moveNumber, err := ParseSFEN(sfen)
+	if err != nil {
+		return nil, err
+	}
+	game, err := NewGameFromBoard(board)
+	if err != nil {
+		return nil, err
+	}
+	game.initialMoveNumber = moveNumber
+	return game, nil
+}
+
+// NewGameFromBoard starts new shogi game from the position set up on the board.
+// It returns an error if the position is impossible, see BitboardBoard.Validate.
+func NewGameFromBoard(board *BitboardBoard) (*Game, error) {
+	if err := board.Validate(); err != nil {
+		return nil, err
+	}
+	board = board.Clone()
+	firstPlayer := NewPlayer(true)
+	secondPlayer := NewPlayer(false)
+	for _, p := range []Player{firstPlayer, secondPlayer} {
+		for _, kind := range HandKinds {
+			for i := 0; i < board.Hand(ColorOf(p), kind); i++ {
+				p.TakePiece(NewPiece(kind, p))
+			}
+		}
+	}
+	currentPlayer := Player(firstPlayer)
+	if board.SideToMove() == Gote {
+		currentPlayer = secondPlayer
 	}
+	return &Game{
+		currentPlayer:     currentPlayer,
+		firstPlayer:       firstPlayer,
+		secondPlayer:      secondPlayer,
+		board:             board,
+		initialBoard:      board.Clone(),
+		initialMoveNumber: 1,
+	}, nil
 }
 
 func (g *Game) FormatCurrentSituation() string {
 	return fmt.Sprintf(`
 -------------------------------
-| 先手: %s
+| 後手: %s
 -------------------------------
 %s
 -------------------------------
-| 後手: %s
+| 先手: %s
 -------------------------------
-`, g.FormatFirstPlayerPiecesInHand(), g.board.String(), g.FormatSecondPlayerPiecesInHand())
+`, g.FormatSecondPlayerPiecesInHand(), g.board.Board(g.firstPlayer, g.secondPlayer).String(), g.FormatFirstPlayerPiecesInHand())
 }
 
 func (g *Game) CurrentPlayerName() string {
 	return g.currentPlayer.Name()
 }
 
+// MovePiece moves the current player's piece. The piece is promoted only when it can't move any further otherwise.
 func (g *Game) MovePiece(curPos, nextPos *Position) error {
-	if err := g.board.MovePiece(g.currentPlayer, curPos, nextPos); err != nil {
+	if !isOnBoard(curPos) || !isOnBoard(nextPos) {
+		return errors.Errorf("move piece: position is out of the board")
+	}
+	move := g.board.NewMove(SquareOf(curPos), SquareOf(nextPos), false)
+	if deadEndTable[g.board.SideToMove()][move.Piece].Has(move.To) {
+		move.Promote = true
+	}
+	consumed, err := g.startMove(move)
+	if err != nil {
 		return errors.Wrap(err, "move piece")
 	}
-	g.switchPlayer()
+	g.doMove(move, consumed)
 	return nil
 }
 
+// DropPiece drops the piece in the current player's hand at the position
 func (g *Game) DropPiece(piece Piece, distPos *Position) error {
-	if err := g.board.DropPiece(piece, distPos); err != nil {
-		return err
+	if !isOnBoard(distPos) {
+		return errors.Errorf("drop piece: position is out of the board")
+	}
+	move := g.board.NewDrop(PieceKindOf(piece), SquareOf(distPos))
+	consumed, err := g.startMove(move)
+	if err != nil {
+		return errors.Wrap(err, "drop piece")
 	}
 	if err := g.currentPlayer.RemoveDroppedPiece(piece); err != nil {
-		return err
+		return errors.Wrap(err, "drop piece")
+	}
+	g.doMove(move, consumed)
+	return nil
+}
+
+// ApplyMove makes the move for the current player if it's legal, and returns ErrGameOver if the game has ended.
+// With the clock started, it returns ErrTimeUp instead if the player has run out of time, which loses the game.
+func (g *Game) ApplyMove(move Move) error {
+	consumed, err := g.startMove(move)
+	if err != nil {
+		return errors.Wrap(err, "apply move")
 	}
+	g.applyMove(move, consumed)
 	return nil
 }
 
+// ApplyMoveWithTime makes the move if it's legal, and records the time spent on it measured by an external clock
+func (g *Game) ApplyMoveWithTime(move Move, consumed time.Duration) error {
+	if g.result != nil {
+		return errors.Wrap(ErrGameOver, "apply move")
+	}
+	if err := g.board.ValidateMove(move); err != nil {
+		return errors.Wrap(g.rejectMove(err), "apply move")
+	}
+	g.timed = true
+	g.applyMove(move, consumed)
+	return nil
+}
+
+// StartClock attaches the clock to the game and starts it for the side to move.
+// The moves are timed by the clock from then on.
+func (g *Game) StartClock(clock *Clock) {
+	g.clock = clock
+	g.timed = true
+	clock.Start(g.SideToMove())
+}
+
+// Clock returns the clock of the game, which is nil if it hasn't been started
+func (g *Game) Clock() *Clock {
+	return g.clock
+}
+
+// MoveTimes returns the times spent on the moves in History, which is nil if they aren't recorded.
+// The times are zero for the moves played before the clock started.
+func (g *Game) MoveTimes() []time.Duration {
+	if !g.timed {
+		return nil
+	}
+	return append([]time.Duration(nil), g.times...)
+}
+
+// startMove checks the move can be made, and ends the turn on the clock if it's started.
+// It returns the time spent on the move.
+func (g *Game) startMove(move Move) (time.Duration, error) {
+	if g.result != nil {
+		return 0, ErrGameOver
+	}
+	if err := g.board.ValidateMove(move); err != nil {
+		return 0, g.rejectMove(err)
+	}
+	if g.clock == nil {
+		return 0, nil
+	}
+	consumed, err := g.clock.Press()
+	if err != nil {
+		g.end(Result{Reason: ReasonTimeUp, Winner: g.SideToMove().Opponent()})
+		return 0, err
+	}
+	return consumed, nil
+}
+
+// Undo takes back the last move, which also cancels the end of the game by the move such as the repetition.
+// The game which ended otherwise, e.g. by resignation, can't be taken back.
+// The clock restarts the turn of the player, but the time spent on the move isn't given back.
+func (g *Game) Undo() error {
+	if len(g.history) == 0 {
+		return errors.New("there is no move to undo")
+	}
+	if g.result != nil && !g.resultByMove {
+		return errors.Wrap(ErrGameOver, "undo")
+	}
+	move := g.history[len(g.history)-1]
+	g.history = g.history[:len(g.history)-1]
+	g.times = g.times[:len(g.times)-1]
+	g.positions = g.positions[:len(g.positions)-1]
+	g.checks = g.checks[:len(g.checks)-1]
+	g.result, g.resultByMove = nil, false
+	g.board.UndoMove(move)
+	g.switchPlayer()
+	if g.clock != nil {
+		running := g.clock.Running()
+		g.clock.Start(g.SideToMove())
+		if !running {
+			g.clock.Pause()
+		}
+	}
+	if move.Drop {
+		g.currentPlayer.TakePiece(NewPiece(move.Piece, g.currentPlayer))
+	}
+	if move.Captured != KindNone {
+		if err := removePieceInHand(g.currentPlayer, move.Captured.Demote()); err != nil {
+			return errors.Wrap(err, "undo")
+		}
+	}
+	g.emit(Event{Type: EventUndo, Ply: len(g.history), Move: move, Color: g.SideToMove()})
+	return nil
+}
+
+// LegalMoves returns every legal move of the current player
+func (g *Game) LegalMoves() []Move {
+	return g.board.LegalMoves()
+}
+
+// IsCheckmated reports if the current player is checkmated
+func (g *Game) IsCheckmated() bool {
+	return g.board.IsCheckmated()
+}
+
+// InCheck reports if the current player's king is checked
+func (g *Game) InCheck() bool {
+	return g.board.InCheck()
+}
+
+// Board returns a copy of the current position
+func (g *Game) Board() *BitboardBoard {
+	return g.board.Clone()
+}
+
+// InitialBoard returns a copy of the position the game started from
+func (g *Game) InitialBoard() *BitboardBoard {
+	return g.initialBoard.Clone()
+}
+
+// History returns the moves played from the initial position
+func (g *Game) History() []Move {
+	return append([]Move(nil), g.history...)
+}
+
+// LastMove returns the last move, ok is false if no move has been played yet
+func (g *Game) LastMove() (move Move, ok bool) {
+	if len(g.history) == 0 {
+		return Move{}, false
+	}
+	return g.history[len(g.history)-1], true
+}
+
+// MoveNumber returns the number of the next move, counting from the initial position's move number
+func (g *Game) MoveNumber() int {
+	return g.initialMoveNumber + len(g.history)
+}
+
+func (g *Game) SideToMove() Color {
+	return g.board.SideToMove()
+}
+
+// SFEN returns the current position in SFEN
+func (g *Game) SFEN() string {
+	return g.board.SFEN(g.MoveNumber())
+}
+
+func (g *Game) applyMove(move Move, consumed time.Duration) {
+	if move.Drop {
+		// the move is validated, so the piece must be in hand
+		_ = removePieceInHand(g.currentPlayer, move.Piece)
+	}
+	g.doMove(move, consumed)
+}
+
+func (g *Game) doMove(move Move, consumed time.Duration) {
+	if move.Captured != KindNone {
+		g.currentPlayer.TakePiece(NewPiece(move.Captured.Demote(), g.currentPlayer))
+	}
+	if len(g.positions) == 0 {
+		g.positions = append(g.positions, g.board.positionKey())
+	}
+	g.board.DoMove(move)
+	g.history = append(g.history, move)
+	g.times = append(g.times, consumed)
+	g.positions = append(g.positions, g.board.positionKey())
+	g.checks = append(g.checks, g.board.InCheck())
+	g.switchPlayer()
+	g.emitMoveEvents(move)
+	g.checkRepetition()
+	if g.result == nil && g.checks[len(g.checks)-1] {
+		g.emit(Event{Type: EventGameOver, Ply: len(g.history), Move: move, Result: Result{Reason: ReasonCheckmate, Winner: g.SideToMove().Opponent()}}, g.board.IsCheckmated)
+	}
+}
+
+func removePieceInHand(p Player, kind PieceKind) error {
+	for _, piece := range p.PiecesInHand() {
+		if PieceKindOf(piece) == kind {
+			return p.RemoveDroppedPiece(piece)
+		}
+	}
+	return errors.Errorf("%s is not in hand", kind.Name())
+}
+
 func (g *Game) CurrentPlayerPiecesInHand() []Piece {
 	return g.currentPlayer.PiecesInHand()
 }
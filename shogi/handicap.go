@@ -0,0 +1,87 @@
+package shogi
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Handicap is the set of pieces the stronger player (上手) removes before the game.
+// In handicap games the stronger player plays gote and moves first.
+type Handicap string
+
+const (
+	HandicapNone        Handicap = "even"
+	HandicapLance       Handicap = "lance"
+	HandicapRightLance  Handicap = "right-lance"
+	HandicapBishop      Handicap = "bishop"
+	HandicapRook        Handicap = "rook"
+	HandicapRookLance   Handicap = "rook-lance"
+	HandicapTwoPieces   Handicap = "two-pieces"
+	HandicapFourPieces  Handicap = "four-pieces"
+	HandicapSixPieces   Handicap = "six-pieces"
+	HandicapEightPieces Handicap = "eight-pieces"
+	HandicapTenPieces   Handicap = "ten-pieces"
+)
+
+// Handicaps are the supported handicaps, from the weakest to the strongest
+var Handicaps = []Handicap{
+	HandicapNone, HandicapLance, HandicapRightLance, HandicapBishop, HandicapRook, HandicapRookLance,
+	HandicapTwoPieces, HandicapFourPieces, HandicapSixPieces, HandicapEightPieces, HandicapTenPieces,
+}
+
+var handicapSFENs = map[Handicap]string{
+	HandicapNone:        InitialSFEN,
+	HandicapLance:       "lnsgkgsn1/1r5b1/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+	HandicapRightLance:  "1nsgkgsnl/1r5b1/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+	HandicapBishop:      "lnsgkgsnl/1r7/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+	HandicapRook:        "lnsgkgsnl/7b1/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+	HandicapRookLance:   "lnsgkgsn1/7b1/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+	HandicapTwoPieces:   "lnsgkgsnl/9/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+	HandicapFourPieces:  "1nsgkgsn1/9/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+	HandicapSixPieces:   "2sgkgs2/9/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+	HandicapEightPieces: "3gkg3/9/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+	HandicapTenPieces:   "4k4/9/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+}
+
+// handicapNames are the names used in the 手合割 header of KIF
+var handicapNames = map[Handicap]string{
+	HandicapNone:        "平手",
+	HandicapLance:       "香落ち",
+	HandicapRightLance:  "右香落ち",
+	HandicapBishop:      "角落ち",
+	HandicapRook:        "飛車落ち",
+	HandicapRookLance:   "飛香落ち",
+	HandicapTwoPieces:   "二枚落ち",
+	HandicapFourPieces:  "四枚落ち",
+	HandicapSixPieces:   "六枚落ち",
+	HandicapEightPieces: "八枚落ち",
+	HandicapTenPieces:   "十枚落ち",
+}
+
+// SFEN returns the initial position of the handicap game in SFEN
+func (h Handicap) SFEN() string {
+	return handicapSFENs[h]
+}
+
+// Name returns the Japanese name of the handicap, e.g. 角落ち
+func (h Handicap) Name() string {
+	return handicapNames[h]
+}
+
+// ParseHandicap returns the handicap by its identifier (e.g. "bishop") or Japanese name (e.g. 角落ち)
+func ParseHandicap(s string) (Handicap, error) {
+	for _, h := range Handicaps {
+		if s == string(h) || s == h.Name() {
+			return h, nil
+		}
+	}
+	return "", errors.Errorf("unknown handicap: %q", s)
+}
+
+// NewGameWithHandicap returns a new game starting from the initial position of the handicap
+func NewGameWithHandicap(h Handicap) (*Game, error) {
+	sfen := h.SFEN()
+	if sfen == "" {
+		return nil, errors.Errorf("unknown handicap: %q", h)
+	}
+	return NewGameFromSFEN(sfen)
+}
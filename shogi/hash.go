@@ -0,0 +1,50 @@
+package shogi
+
+import "math/rand"
+
+// zobrist keys of the pieces on the squares, a piece in hand and the side to move,
+// generated with a fixed seed so that hashes are the same across runs.
+// The hash is the sum of the keys, so that it's updated by adding and subtracting them as the pieces move,
+// and a key of a piece in hand is added as many times as the pieces of the kind in hand.
+var (
+	zobristPieces [2][numPieceKinds][NumSquares]uint64
+	zobristHands  [2][numPieceKinds]uint64
+	zobristGote   uint64
+)
+
+func init() {
+	r := rand.New(rand.NewSource(20200807))
+	for c := range zobristPieces {
+		for kind := range zobristPieces[c] {
+			for sq := range zobristPieces[c][kind] {
+				zobristPieces[c][kind][sq] = r.Uint64()
+			}
+			zobristHands[c][kind] = r.Uint64()
+		}
+	}
+	zobristGote = r.Uint64()
+}
+
+// Hash returns the Zobrist hash of the position, the pieces on the board and in hand and the side to move
+func (b *BitboardBoard) Hash() uint64 {
+	return b.hash
+}
+
+// addHand adds n pieces of the kind to the color's hand, or takes them if n is negative
+func (b *BitboardBoard) addHand(c Color, kind PieceKind, n int) {
+	b.hands[c][kind] += n
+	b.hash += uint64(n) * zobristHands[c][kind]
+}
+
+// setTurn sets the side to move
+func (b *BitboardBoard) setTurn(c Color) {
+	if b.turn == c {
+		return
+	}
+	if c == Gote {
+		b.hash += zobristGote
+	} else {
+		b.hash -= zobristGote
+	}
+	b.turn = c
+}
@@ -0,0 +1,92 @@
+package shogi
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestBitboardBoard_Hash(t *testing.T) {
+	// the hash updated by the moves is the same as the one of the position set up from scratch
+	rehash := func(b *BitboardBoard) uint64 {
+		parsed, _, err := ParseSFEN(b.SFEN(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed.Hash()
+	}
+	r := rand.New(rand.NewSource(1))
+	for game := 0; game < 20; game++ {
+		b := NewBitboardBoard()
+		initial := b.Hash()
+		if got := rehash(b); got != initial {
+			t.Fatalf("Hash() of the initial position = %x, want %x", initial, got)
+		}
+		var history []Move
+		for ply := 0; ply < 150; ply++ {
+			moves := b.LegalMoves()
+			if len(moves) == 0 {
+				break
+			}
+			m := moves[r.Intn(len(moves))]
+			b.DoMove(m)
+			history = append(history, m)
+			if got, want := b.Hash(), rehash(b); got != want {
+				t.Fatalf("Hash() after %v = %x, want %x of %s", history, got, want, b.SFEN(1))
+			}
+		}
+		for i := len(history) - 1; i >= 0; i-- {
+			b.UndoMove(history[i])
+		}
+		if got := b.Hash(); got != initial {
+			t.Fatalf("Hash() after undoing %v = %x, want %x", history, got, initial)
+		}
+	}
+}
+
+func TestBitboardBoard_Hash_Position(t *testing.T) {
+	hash := func(sfen string) uint64 {
+		b, _, err := ParseSFEN(sfen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b.Hash()
+	}
+	base := hash("4k4/9/9/9/9/9/9/9/4K4 b 2P 1")
+	tests := []struct {
+		name string
+		sfen string
+		same bool
+	}{
+		{name: "another move number", sfen: "4k4/9/9/9/9/9/9/9/4K4 b 2P 31", same: true},
+		{name: "the pieces in hand listed one by one", sfen: "4k4/9/9/9/9/9/9/9/4K4 b PP 1", same: true},
+		{name: "the other side to move", sfen: "4k4/9/9/9/9/9/9/9/4K4 w 2P 1"},
+		{name: "fewer pieces in hand", sfen: "4k4/9/9/9/9/9/9/9/4K4 b P 1"},
+		{name: "the opponent's pieces in hand", sfen: "4k4/9/9/9/9/9/9/9/4K4 b 2p 1"},
+		{name: "a piece moved", sfen: "4k4/9/9/9/9/9/9/4K4/9 b 2P 1"},
+	}
+	for _, tt := range tests {
+		if got := hash(tt.sfen) == base; got != tt.same {
+			t.Errorf("%s: same hash = %t, want %t", tt.name, got, tt.same)
+		}
+	}
+
+	// the same position reached by different orders of the moves
+	a, b := NewBitboardBoard(), NewBitboardBoard()
+	for _, usi := range []string{"7g7f", "3c3d", "2g2f"} {
+		m, err := a.ParseMove(usi)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.DoMove(m)
+	}
+	for _, usi := range []string{"2g2f", "3c3d", "7g7f"} {
+		m, err := b.ParseMove(usi)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.DoMove(m)
+	}
+	if a.Hash() != b.Hash() {
+		t.Errorf("Hash() of the transposition = %x, want %x", b.Hash(), a.Hash())
+	}
+}
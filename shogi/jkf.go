@@ -0,0 +1,200 @@
+package shogi
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// jkfPresets are the presets of JKF for the handicaps
+var jkfPresets = map[Handicap]string{
+	HandicapNone:        "HIRATE",
+	HandicapLance:       "KY",
+	HandicapRightLance:  "KY_R",
+	HandicapBishop:      "KA",
+	HandicapRook:        "HI",
+	HandicapRookLance:   "HIKY",
+	HandicapTwoPieces:   "2",
+	HandicapFourPieces:  "4",
+	HandicapSixPieces:   "6",
+	HandicapEightPieces: "8",
+	HandicapTenPieces:   "10",
+}
+
+// jkfRelatives are the letters of JKF for the characters of relative notation
+var jkfRelatives = strings.NewReplacer("左", "L", "直", "C", "右", "R", "上", "U", "寄", "M", "引", "D", "打", "H")
+
+// JKFInfo is the information written in a JKF record besides the position and the moves
+type JKFInfo struct {
+	SenteName string
+	GoteName  string
+	// StartTime and EndTime are omitted if they are zero
+	StartTime time.Time
+	EndTime   time.Time
+	// Special is the special move ending the game such as TORYO.
+	// The one for the result of the game is written if it's empty, and nothing if the game is in progress.
+	Special string
+}
+
+type jkfRecord struct {
+	Header  map[string]string `json:"header"`
+	Initial *jkfInitial       `json:"initial,omitempty"`
+	Moves   []jkfMoveFormat   `json:"moves"`
+}
+
+type jkfInitial struct {
+	Preset string        `json:"preset"`
+	Data   *jkfStateData `json:"data,omitempty"`
+}
+
+type jkfStateData struct {
+	Color int `json:"color"`
+	// Board is indexed by file and rank from 1, e.g. Board[6][6] is 7七
+	Board [9][9]jkfPiece    `json:"board"`
+	Hands [2]map[string]int `json:"hands"`
+}
+
+type jkfPiece struct {
+	Color *int   `json:"color,omitempty"`
+	Kind  string `json:"kind,omitempty"`
+}
+
+type jkfMoveFormat struct {
+	Move    *jkfMove `json:"move,omitempty"`
+	Time    *jkfTime `json:"time,omitempty"`
+	Special string   `json:"special,omitempty"`
+}
+
+type jkfMove struct {
+	From     *jkfPlace `json:"from,omitempty"`
+	To       jkfPlace  `json:"to"`
+	Color    int       `json:"color"`
+	Piece    string    `json:"piece"`
+	Same     bool      `json:"same,omitempty"`
+	Promote  *bool     `json:"promote,omitempty"`
+	Capture  string    `json:"capture,omitempty"`
+	Relative string    `json:"relative,omitempty"`
+}
+
+type jkfPlace struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type jkfTime struct {
+	Now   jkfDuration `json:"now"`
+	Total jkfDuration `json:"total"`
+}
+
+type jkfDuration struct {
+	H *int `json:"h,omitempty"`
+	M int  `json:"m"`
+	S int  `json:"s"`
+}
+
+// JKF returns the game record in JSON Kifu Format. The times spent on the moves are written if they are recorded in the game.
+func (g *Game) JKF(info JKFInfo) ([]byte, error) {
+	record := jkfRecord{Header: map[string]string{}}
+	if info.SenteName != "" {
+		record.Header["先手"] = info.SenteName
+	}
+	if info.GoteName != "" {
+		record.Header["後手"] = info.GoteName
+	}
+	if !info.StartTime.IsZero() {
+		record.Header["開始日時"] = info.StartTime.Format(kifTimeFormat)
+	}
+	if !info.EndTime.IsZero() {
+		record.Header["終了日時"] = info.EndTime.Format(kifTimeFormat)
+	}
+	if h, ok := g.handicap(); ok {
+		record.Initial = &jkfInitial{Preset: jkfPresets[h]}
+	} else {
+		record.Initial = &jkfInitial{Preset: "OTHER", Data: g.initialBoard.jkfStateData()}
+	}
+
+	// the first element is for the comments on the initial position
+	record.Moves = []jkfMoveFormat{{}}
+	board := g.initialBoard.Clone()
+	times := g.MoveTimes()
+	var totals [2]time.Duration
+	var last *Move
+	for i, m := range g.history {
+		format := jkfMoveFormat{Move: board.jkfMove(m, last)}
+		if times != nil {
+			totals[board.turn] += times[i]
+			now := int(times[i] / time.Second)
+			total := int(totals[board.turn] / time.Second)
+			hours := total / 3600
+			format.Time = &jkfTime{
+				Now:   jkfDuration{M: now / 60, S: now % 60},
+				Total: jkfDuration{H: &hours, M: total / 60 % 60, S: total % 60},
+			}
+		}
+		record.Moves = append(record.Moves, format)
+		board.DoMove(m)
+		last = &g.history[i]
+	}
+	special := info.Special
+	if result, ok := g.Result(); ok && special == "" {
+		special = strings.TrimPrefix(g.csaTerminator(result), "%")
+	}
+	if special != "" {
+		record.Moves = append(record.Moves, jkfMoveFormat{Special: special})
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, errors.Wrap(err, "jkf")
+	}
+	return data, nil
+}
+
+func (b *BitboardBoard) jkfStateData() *jkfStateData {
+	data := &jkfStateData{Color: int(b.turn)}
+	for x := Axis(1); x <= 9; x++ {
+		for y := Axis(1); y <= 9; y++ {
+			kind, color := b.PieceAt(NewSquare(x, y))
+			if kind == KindNone {
+				continue
+			}
+			c := int(color)
+			data.Board[x-1][y-1] = jkfPiece{Color: &c, Kind: csaPieceNames[kind]}
+		}
+	}
+	for _, c := range []Color{Sente, Gote} {
+		data.Hands[c] = map[string]int{}
+		for _, kind := range HandKinds {
+			data.Hands[c][csaPieceNames[kind]] = b.hands[c][kind]
+		}
+	}
+	return data
+}
+
+// jkfMove returns the move in JKF, which must be legal in the position of the board
+func (b *BitboardBoard) jkfMove(m Move, last *Move) *jkfMove {
+	move := &jkfMove{
+		To:    jkfPlace{X: int(m.To.X()), Y: int(m.To.Y())},
+		Color: int(b.turn),
+		Piece: csaPieceNames[m.Piece],
+		Same:  last != nil && last.To == m.To,
+	}
+	if m.Drop {
+		if len(b.movesTo(m.To, m.Piece)) > 0 {
+			move.Relative = "H"
+		}
+		return move
+	}
+	move.From = &jkfPlace{X: int(m.From.X()), Y: int(m.From.Y())}
+	if m.Piece.IsPromotable() && (promotionZoneTable[b.turn].Has(m.From) || promotionZoneTable[b.turn].Has(m.To)) {
+		promote := m.Promote
+		move.Promote = &promote
+	}
+	if m.Captured != KindNone {
+		move.Capture = csaPieceNames[m.Captured]
+	}
+	move.Relative = jkfRelatives.Replace(b.relativeNotation(m))
+	return move
+}
@@ -0,0 +1,250 @@
+package shogi
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// kifMovePattern matches a move line of KIF, e.g. "   1 ７六歩(77)   ( 0:01/00:00:01)" or "   2 同　歩(23)"
+var kifMovePattern = regexp.MustCompile(`^\s*(\d+)\s+(同　?\S+|\S+)`)
+
+// kifTimePattern matches the time spent on the move in a move line, e.g. "( 0:01/00:00:01)"
+var kifTimePattern = regexp.MustCompile(`\(\s*(\d+):(\d+)/`)
+
+// kifTimeFormat is the format of 開始日時 and 終了日時 in KIF
+const kifTimeFormat = "2006/01/02 15:04:05"
+
+// kifMoveWidth is the display width of the move column in KIF, where full-width characters are two columns wide
+const kifMoveWidth = 13
+
+// kifTerminators are the words ending the move list
+var kifTerminators = []string{"投了", "中断", "千日手", "詰み", "持将棋", "切れ負け", "反則勝ち", "反則負け", "入玉勝ち", "不戦勝", "不戦敗"}
+
+// ParseKIF parses the game record in KIF format and plays its moves.
+// The initial position is given by the BOD diagram or the handicap (手合割) in the header, and it's the even game (平手) without them.
+// Only the main line is read, and variations (変化) are ignored. The terminator such as 投了 ends the game with the result. The times spent on the moves are recorded if they are written.
+func ParseKIF(kif string) (*Game, error) {
+	lines := strings.Split(strings.Replace(kif, "\r\n", "\n", -1), "\n")
+
+	var header []string
+	var handicap string
+	hasBOD := false
+	i := 0
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "手数----") {
+			i++
+			break
+		}
+		if kifMovePattern.MatchString(line) {
+			break
+		}
+		if strings.HasPrefix(line, "|") {
+			hasBOD = true
+		}
+		if strings.HasPrefix(line, "手合割：") {
+			handicap = strings.TrimSpace(strings.Trim(strings.TrimPrefix(line, "手合割："), "　"))
+		}
+		header = append(header, line)
+	}
+
+	var game *Game
+	switch {
+	case hasBOD:
+		board, moves, err := ParseBOD(strings.Join(header, "\n"))
+		if err != nil {
+			return nil, errors.Wrap(err, "parse kif")
+		}
+		if game, err = NewGameFromBoard(board); err != nil {
+			return nil, errors.Wrap(err, "parse kif")
+		}
+		game.initialMoveNumber = moves + 1
+	case handicap == "" || handicap == HandicapNone.Name():
+		game = NewGame()
+	default:
+		h, err := ParseHandicap(handicap)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse kif")
+		}
+		if game, err = NewGameWithHandicap(h); err != nil {
+			return nil, errors.Wrap(err, "parse kif")
+		}
+	}
+
+	var last *Move
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "変化：") {
+			break
+		}
+		match := kifMovePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if isKIFTerminator(match[2]) {
+			if result, ok := game.parseKIFTerminator(match[2]); ok {
+				game.end(result)
+			}
+			break
+		}
+		move, err := game.board.ParseJapaneseMove(match[2], last)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse kif: move %s", match[1])
+		}
+		if t := kifTimePattern.FindStringSubmatch(line); t != nil {
+			minutes, _ := strconv.Atoi(t[1])
+			seconds, _ := strconv.Atoi(t[2])
+			err = game.ApplyMoveWithTime(move, time.Duration(minutes)*time.Minute+time.Duration(seconds)*time.Second)
+		} else {
+			err = game.ApplyMove(move)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse kif: move %s", match[1])
+		}
+		last = &move
+	}
+	return game, nil
+}
+
+func isKIFTerminator(s string) bool {
+	for _, t := range kifTerminators {
+		if strings.HasPrefix(s, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// KIFInfo is the information written in a KIF record besides the position and the moves
+type KIFInfo struct {
+	SenteName string
+	GoteName  string
+	// StartTime and EndTime are omitted if they are zero
+	StartTime time.Time
+	EndTime   time.Time
+	// Terminator is the last line of the moves such as 投了.
+	// The one for the result of the game is written if it's empty, and nothing if the game is in progress.
+	Terminator string
+	// Comments are written after the moves as the lines starting with *, keyed by the index of the move in the history.
+	// A comment of multiple lines is split at the newlines.
+	Comments map[int]string
+}
+
+// KIF returns the game record in KIF format. The initial position is written as the handicap (手合割) if it's one of them,
+// and as a BOD diagram otherwise. The times spent on the moves are written if they are recorded in the game.
+func (g *Game) KIF(info KIFInfo) string {
+	var sb strings.Builder
+	if !info.StartTime.IsZero() {
+		sb.WriteString("開始日時：" + info.StartTime.Format(kifTimeFormat) + "\n")
+	}
+	if !info.EndTime.IsZero() {
+		sb.WriteString("終了日時：" + info.EndTime.Format(kifTimeFormat) + "\n")
+	}
+	if h, ok := g.handicap(); ok {
+		sb.WriteString("手合割：" + h.Name() + "\n")
+	} else {
+		sb.WriteString(g.initialBoard.BOD(g.initialMoveNumber-1, ""))
+	}
+	sb.WriteString("先手：" + info.SenteName + "\n")
+	sb.WriteString("後手：" + info.GoteName + "\n")
+	sb.WriteString("手数----指手---------消費時間--\n")
+
+	board := g.initialBoard.Clone()
+	times := g.MoveTimes()
+	var totals [2]time.Duration
+	var last *Move
+	for i, m := range g.history {
+		line := fmt.Sprintf("%4d %s", g.initialMoveNumber+i, board.kifMove(m, last))
+		if times != nil {
+			totals[board.turn] += times[i]
+			line = padKIFMove(line) + fmt.Sprintf("(%s/%s)", formatKIFMoveTime(times[i]), formatKIFTotalTime(totals[board.turn]))
+		}
+		sb.WriteString(line + "\n")
+		if comment, ok := info.Comments[i]; ok {
+			for _, l := range strings.Split(comment, "\n") {
+				sb.WriteString("*" + l + "\n")
+			}
+		}
+		board.DoMove(m)
+		last = &g.history[i]
+	}
+	terminator := info.Terminator
+	if result, ok := g.Result(); ok && terminator == "" {
+		terminator = g.kifTerminator(result)
+	}
+	if terminator != "" {
+		sb.WriteString(fmt.Sprintf("%4d %s\n", g.MoveNumber(), terminator))
+	}
+	return sb.String()
+}
+
+// handicap returns the handicap of the initial position if it's one of them
+func (g *Game) handicap() (Handicap, bool) {
+	if g.initialMoveNumber != 1 {
+		return "", false
+	}
+	sfen := g.initialBoard.SFEN(1)
+	for _, h := range Handicaps {
+		if h.SFEN() == sfen {
+			return h, true
+		}
+	}
+	return "", false
+}
+
+// kifMove returns the move in KIF notation, e.g. "７六歩(77)", "同　歩成(24)", "５五角打"
+func (b *BitboardBoard) kifMove(m Move, last *Move) string {
+	var sb strings.Builder
+	if last != nil && last.To == m.To {
+		sb.WriteString("同　")
+	} else {
+		sb.WriteString(JapaneseSquare(m.To))
+	}
+	sb.WriteString(notationPieceNames[m.Piece])
+	if m.Drop {
+		sb.WriteString("打")
+		return sb.String()
+	}
+	if m.Promote {
+		sb.WriteString("成")
+	} else if m.Piece.IsPromotable() && (promotionZoneTable[b.turn].Has(m.From) || promotionZoneTable[b.turn].Has(m.To)) {
+		sb.WriteString("不成")
+	}
+	sb.WriteString(fmt.Sprintf("(%d%d)", m.From.X(), m.From.Y()))
+	return sb.String()
+}
+
+// padKIFMove pads the move line with spaces so that the times line up
+func padKIFMove(line string) string {
+	// the move number column is 5 characters wide
+	width := -5
+	for _, r := range line {
+		if utf8.RuneLen(r) > 1 {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	if width >= kifMoveWidth {
+		return line + " "
+	}
+	return line + strings.Repeat(" ", kifMoveWidth-width)
+}
+
+// formatKIFMoveTime formats the time spent on a move, e.g. " 0:01"
+func formatKIFMoveTime(d time.Duration) string {
+	seconds := int(d / time.Second)
+	return fmt.Sprintf("%2d:%02d", seconds/60, seconds%60)
+}
+
+// formatKIFTotalTime formats the total time spent by the player, e.g. "00:00:01"
+func formatKIFTotalTime(d time.Duration) string {
+	seconds := int(d / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
@@ -0,0 +1,188 @@
+package shogi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGame_KIF_RoundTrip(t *testing.T) {
+	type timedMove struct {
+		usi  string
+		time time.Duration
+	}
+	tests := []struct {
+		name     string
+		newGame  func() (*Game, error)
+		moves    []timedMove
+		timed    bool
+		end      func(g *Game)
+		contains string
+	}{
+		{
+			name:    "even game with times",
+			newGame: func() (*Game, error) { return NewGame(), nil },
+			moves: []timedMove{
+				{"7g7f", 3 * time.Second}, {"3c3d", 65 * time.Second}, {"8h2b+", time.Second}, {"3a2b", 2 * time.Second},
+				{"B*4e", 10 * time.Minute}, {"6a5b", 0},
+			},
+			timed:    true,
+			end:      func(g *Game) { _ = g.Resign(Sente) },
+			contains: "   4 同　銀(31)   ( 0:02/00:01:07)\n   5 ４五角打     (10:00/00:10:04)\n   6 ５二金(61)   ( 0:00/00:01:07)\n   7 投了\n",
+		},
+		{
+			name:     "handicap",
+			newGame:  func() (*Game, error) { return NewGameWithHandicap(HandicapBishop) },
+			moves:    []timedMove{{usi: "3c3d"}, {usi: "7g7f"}},
+			end:      func(g *Game) { _ = g.Abort() },
+			contains: "手合割：角落ち\n",
+		},
+		{
+			name: "BOD start",
+			newGame: func() (*Game, error) {
+				return NewGameFromSFEN("ln1g3nl/1r2gks2/p1sppp1pp/2p3p2/1p5P1/2P1P1P2/PPSP1P2P/2G1GS1R1/LN2K2NL b Bb 21")
+			},
+			moves:    []timedMove{{usi: "B*5e"}, {usi: "B*5d"}},
+			end:      func(g *Game) { g.end(Result{Reason: ReasonTimeUp, Winner: Gote}) },
+			contains: "手数＝20\n",
+		},
+		{
+			name:     "checkmate",
+			newGame:  func() (*Game, error) { return NewGameFromSFEN("7kl/9/6PPp/9/9/9/9/9/K8 b 2G 1") },
+			moves:    []timedMove{{usi: "G*2b"}},
+			contains: "   1 ２二金打\n*first move\n*second line\n   2 詰み\n",
+		},
+		{
+			name:     "in progress",
+			newGame:  func() (*Game, error) { return NewGame(), nil },
+			moves:    []timedMove{{usi: "2g2f"}},
+			contains: "   1 ２六歩(27)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := tt.newGame()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, tm := range tt.moves {
+				m, err := g.Board().ParseMove(tm.usi)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tt.timed {
+					err = g.ApplyMoveWithTime(m, tm.time)
+				} else {
+					err = g.ApplyMove(m)
+				}
+				if err != nil {
+					t.Fatalf("ApplyMove(%s) error = %v", tm.usi, err)
+				}
+			}
+			if tt.end != nil {
+				tt.end(g)
+			}
+			info := KIFInfo{
+				SenteName: "sente",
+				GoteName:  "gote",
+				StartTime: time.Date(2020, 8, 7, 12, 0, 0, 0, time.UTC),
+				Comments:  map[int]string{0: "first move\nsecond line"},
+			}
+			kif := g.KIF(info)
+			if !strings.Contains(kif, tt.contains) {
+				t.Errorf("KIF() doesn't contain %q:\n%s", tt.contains, kif)
+			}
+
+			parsed, err := ParseKIF(kif)
+			if err != nil {
+				t.Fatalf("ParseKIF() error = %v\n%s", err, kif)
+			}
+			if parsed.SFEN() != g.SFEN() {
+				t.Errorf("SFEN() = %s, want %s", parsed.SFEN(), g.SFEN())
+			}
+			if !reflect.DeepEqual(parsed.History(), g.History()) {
+				t.Errorf("History() = %v, want %v", parsed.History(), g.History())
+			}
+			if !reflect.DeepEqual(parsed.MoveTimes(), g.MoveTimes()) {
+				t.Errorf("MoveTimes() = %v, want %v", parsed.MoveTimes(), g.MoveTimes())
+			}
+			gotResult, gotOK := parsed.Result()
+			wantResult, wantOK := g.Result()
+			if gotResult != wantResult || gotOK != wantOK {
+				t.Errorf("Result() = %v, %v, want %v, %v", gotResult, gotOK, wantResult, wantOK)
+			}
+			if got := parsed.KIF(info); got != kif {
+				t.Errorf("KIF() after ParseKIF() = \n%s\nwant\n%s", got, kif)
+			}
+		})
+	}
+}
+
+func TestParseKIF(t *testing.T) {
+	kif := strings.Join([]string{
+		"# ---- written by hand ----",
+		"開始日時：2020/08/07 12:00:00",
+		"手合割：平手　　",
+		"先手：sente",
+		"後手：gote",
+		"手数----指手---------消費時間--",
+		"   1 ７六歩(77)   ( 0:01/00:00:01)",
+		"   2 ３四歩(33)   ( 0:02/00:00:02)",
+		"*a comment",
+		"   3 ２二角成(88) ( 0:03/00:00:04)",
+		"   4 同　銀(31)   ( 1:04/00:01:06)",
+		"   5 ４五角打     ( 0:05/00:00:09)",
+		"   6 ５二金(61)   ( 0:06/00:01:12)",
+		"   7 ６三角不成(45) ( 0:07/00:00:16)",
+		"   8 投了         ( 0:08/00:01:20)",
+		"まで7手で先手の勝ち",
+		"",
+		"変化：7手",
+		"   7 ６三角成(45) ( 0:07/00:00:16)",
+		"   8 投了",
+	}, "\r\n")
+	g, err := ParseKIF(kif)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var usi []string
+	for _, m := range g.History() {
+		usi = append(usi, m.String())
+	}
+	wantUSI := []string{"7g7f", "3c3d", "8h2b+", "3a2b", "B*4e", "6a5b", "4e6c"}
+	if !reflect.DeepEqual(usi, wantUSI) {
+		t.Errorf("History() = %v, want %v", usi, wantUSI)
+	}
+	wantTimes := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 64 * time.Second, 5 * time.Second, 6 * time.Second, 7 * time.Second}
+	if !reflect.DeepEqual(g.MoveTimes(), wantTimes) {
+		t.Errorf("MoveTimes() = %v, want %v", g.MoveTimes(), wantTimes)
+	}
+	if result, ok := g.Result(); !ok || result != (Result{Reason: ReasonResignation, Winner: Sente}) {
+		t.Errorf("Result() = %v, %v, want 先手の勝ち（投了）", result, ok)
+	}
+
+	for name, invalid := range map[string]string{
+		"unknown handicap": strings.Replace(kif, "平手", "十枚落ち", 1),
+		"illegal move":     strings.Replace(kif, "５二金(61)", "５五金(61)", 1),
+		"unknown move":     strings.Replace(kif, "３四歩(33)", "３四象(33)", 1),
+	} {
+		if _, err := ParseKIF(invalid); err == nil {
+			t.Errorf("%s: ParseKIF() error = nil", name)
+		}
+	}
+}
+
+func TestParseKIF_Handicap(t *testing.T) {
+	g, err := ParseKIF("手合割：香落ち\n手数----指手---------消費時間--\n   1 ３四歩(33)\n   2 中断\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "lnsgkgsn1/1r5b1/pppppp1pp/6p2/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL b - 2"
+	if g.SFEN() != want {
+		t.Errorf("SFEN() = %s, want %s", g.SFEN(), want)
+	}
+	if result, ok := g.Result(); !ok || result != (Result{Reason: ReasonAbort}) {
+		t.Errorf("Result() = %v, %v, want 中断", result, ok)
+	}
+}
@@ -0,0 +1,187 @@
+package shogi
+
+// Color identifies the side a piece belongs to.
+type Color int8
+
+const (
+	// Sente is the first player (先手) who starts at the bottom of the board
+	Sente Color = iota
+	// Gote is the second player (後手) who starts at the top of the board
+	Gote
+)
+
+const numColors = 2
+
+func ColorOf(p Player) Color {
+	if p.IsFirstPlayer() {
+		return Sente
+	}
+	return Gote
+}
+
+func (c Color) Opponent() Color {
+	return c ^ 1
+}
+
+// IsValid reports if the color is Sente or Gote
+func (c Color) IsValid() bool {
+	return c == Sente || c == Gote
+}
+
+func (c Color) IsFirstPlayer() bool {
+	return c == Sente
+}
+
+func (c Color) String() string {
+	if c == Sente {
+		return "先手"
+	}
+	return "後手"
+}
+
+// Marker returns the side marker used in move notation, ▲ for sente and △ for gote
+func (c Color) Marker() string {
+	if c == Sente {
+		return "▲"
+	}
+	return "△"
+}
+
+// PieceKind is the kind of a piece independent of its owner.
+// Promoted kinds are distinct values so that a piece on the board can be described by a single kind.
+type PieceKind int8
+
+const (
+	KindNone PieceKind = iota
+	KindPawn
+	KindLance
+	KindKnight
+	KindSilver
+	KindGold
+	KindBishop
+	KindRook
+	KindKing
+	KindPromotedPawn
+	KindPromotedLance
+	KindPromotedKnight
+	KindPromotedSilver
+	KindPromotedBishop
+	KindPromotedRook
+)
+
+const numPieceKinds = 15
+
+// HandKinds are the kinds which can be held in hand, in the order they are usually listed.
+var HandKinds = []PieceKind{KindRook, KindBishop, KindGold, KindSilver, KindKnight, KindLance, KindPawn}
+
+var pieceKindShortNames = [numPieceKinds]string{
+	"", "歩", "香", "桂", "銀", "金", "角", "飛", "玉", "と", "杏", "圭", "全", "馬", "龍",
+}
+
+var pieceKindNames = [numPieceKinds]string{
+	"", "歩", "香車", "桂馬", "銀", "金", "角", "飛車", "玉", "と", "成香", "成桂", "成銀", "馬", "龍",
+}
+
+func (k PieceKind) IsPromotable() bool {
+	return k >= KindPawn && k <= KindRook && k != KindGold
+}
+
+func (k PieceKind) IsPromoted() bool {
+	return k >= KindPromotedPawn
+}
+
+// Promote returns the promoted kind, or the kind itself if it can't be promoted.
+func (k PieceKind) Promote() PieceKind {
+	if !k.IsPromotable() {
+		return k
+	}
+	if k == KindBishop || k == KindRook {
+		return k + KindPromotedBishop - KindBishop
+	}
+	return k + KindPromotedPawn - KindPawn
+}
+
+// Demote returns the original kind of a promoted kind, which is the kind the piece becomes when it's captured.
+func (k PieceKind) Demote() PieceKind {
+	switch {
+	case k == KindPromotedBishop || k == KindPromotedRook:
+		return k - KindPromotedBishop + KindBishop
+	case k.IsPromoted():
+		return k - KindPromotedPawn + KindPawn
+	default:
+		return k
+	}
+}
+
+func (k PieceKind) Name() string {
+	return pieceKindNames[k]
+}
+
+func (k PieceKind) ShortName() string {
+	return pieceKindShortNames[k]
+}
+
+// PieceKindOf returns the kind of the given piece
+func PieceKindOf(p Piece) PieceKind {
+	var kind PieceKind
+	switch p.(type) {
+	case *King:
+		kind = KindKing
+	case *Rook:
+		kind = KindRook
+	case *Bishop:
+		kind = KindBishop
+	case *Gold:
+		kind = KindGold
+	case *Silver:
+		kind = KindSilver
+	case *Knight:
+		kind = KindKnight
+	case *Lance:
+		kind = KindLance
+	case *Pawn:
+		kind = KindPawn
+	default:
+		return KindNone
+	}
+	if p.IsPromoted() {
+		return kind.Promote()
+	}
+	return kind
+}
+
+// NewPiece creates a piece of the given kind owned by the given player
+func NewPiece(kind PieceKind, p Player) Piece {
+	var piece Piece
+	var impl *pieceImpl
+	switch kind.Demote() {
+	case KindKing:
+		king := NewKing(p)
+		piece, impl = king, king.pieceImpl
+	case KindRook:
+		rook := NewRook(p)
+		piece, impl = rook, rook.pieceImpl
+	case KindBishop:
+		bishop := NewBishop(p)
+		piece, impl = bishop, bishop.pieceImpl
+	case KindGold:
+		gold := NewGold(p)
+		piece, impl = gold, gold.pieceImpl
+	case KindSilver:
+		silver := NewSilver(p)
+		piece, impl = silver, silver.pieceImpl
+	case KindKnight:
+		knight := NewKnight(p)
+		piece, impl = knight, knight.pieceImpl
+	case KindLance:
+		lance := NewLance(p)
+		piece, impl = lance, lance.pieceImpl
+	case KindPawn:
+		pawn := NewPawn(p)
+		piece, impl = pawn, pawn.pieceImpl
+	default:
+		return nil
+	}
+	impl.isPromoted = kind.IsPromoted()
+	return piece
+}
@@ -0,0 +1,30 @@
+package shogi
+
+// Move is a single move of a piece on the board, or a drop of a piece in hand.
+type Move struct {
+	// From is the square the piece moves from. It's NoSquare for drops.
+	From Square
+	To   Square
+	// Piece is the kind of the moving piece before promotion, or the dropped kind.
+	Piece PieceKind
+	// Captured is the kind of the piece at the destination, KindNone if it's empty.
+	Captured PieceKind
+	Promote  bool
+	Drop     bool
+}
+
+// String returns the move in USI notation, e.g. "7g7f", "8h2b+", "P*5e"
+func (m Move) String() string {
+	if m.Drop {
+		return usiPieceLetters[m.Piece] + "*" + m.To.String()
+	}
+	s := m.From.String() + m.To.String()
+	if m.Promote {
+		s += "+"
+	}
+	return s
+}
+
+var usiPieceLetters = [numPieceKinds]string{
+	"", "P", "L", "N", "S", "G", "B", "R", "K", "+P", "+L", "+N", "+S", "+B", "+R",
+}
@@ -0,0 +1,326 @@
+package shogi
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var fullWidthDigits = []string{"", "１", "２", "３", "４", "５", "６", "７", "８", "９"}
+var kanjiNumerals = []string{"", "一", "二", "三", "四", "五", "六", "七", "八", "九"}
+
+// notationPieceNames are the piece names used in move notation
+var notationPieceNames = [numPieceKinds]string{
+	"", "歩", "香", "桂", "銀", "金", "角", "飛", "玉", "と", "成香", "成桂", "成銀", "馬", "龍",
+}
+
+// notationPieceAliases are other names accepted when parsing, longer names first
+var notationPieceAliases = []struct {
+	name string
+	kind PieceKind
+}{
+	{name: "成香", kind: KindPromotedLance}, {name: "成桂", kind: KindPromotedKnight}, {name: "成銀", kind: KindPromotedSilver},
+	{name: "香車", kind: KindLance}, {name: "桂馬", kind: KindKnight}, {name: "飛車", kind: KindRook},
+	{name: "歩", kind: KindPawn}, {name: "香", kind: KindLance}, {name: "桂", kind: KindKnight}, {name: "銀", kind: KindSilver},
+	{name: "金", kind: KindGold}, {name: "角", kind: KindBishop}, {name: "飛", kind: KindRook},
+	{name: "玉", kind: KindKing}, {name: "王", kind: KindKing},
+	{name: "と", kind: KindPromotedPawn}, {name: "杏", kind: KindPromotedLance}, {name: "圭", kind: KindPromotedKnight},
+	{name: "全", kind: KindPromotedSilver}, {name: "馬", kind: KindPromotedBishop},
+	{name: "龍", kind: KindPromotedRook}, {name: "竜", kind: KindPromotedRook},
+}
+
+// JapaneseSquare returns the square in Japanese notation, e.g. "７六"
+func JapaneseSquare(sq Square) string {
+	return fullWidthDigits[sq.X()] + kanjiNumerals[sq.Y()]
+}
+
+// JapaneseMove returns the move in Japanese notation without the side marker, e.g. "７六歩", "同　歩成", "５八金右".
+// The move must be legal in the position of the board, and last is the previous move if any.
+func (b *BitboardBoard) JapaneseMove(m Move, last *Move) string {
+	var sb strings.Builder
+	if last != nil && last.To == m.To {
+		sb.WriteString("同　")
+	} else {
+		sb.WriteString(JapaneseSquare(m.To))
+	}
+	sb.WriteString(notationPieceNames[m.Piece])
+	if m.Drop {
+		if len(b.movesTo(m.To, m.Piece)) > 0 {
+			sb.WriteString("打")
+		}
+		return sb.String()
+	}
+	sb.WriteString(b.relativeNotation(m))
+	if m.Promote {
+		sb.WriteString("成")
+	} else if m.Piece.IsPromotable() && (promotionZoneTable[b.turn].Has(m.From) || promotionZoneTable[b.turn].Has(m.To)) {
+		sb.WriteString("不成")
+	}
+	return sb.String()
+}
+
+// JapaneseMoves returns the moves played in the game in Japanese notation without side markers
+func (g *Game) JapaneseMoves() []string {
+	board := g.initialBoard.Clone()
+	notations := make([]string, len(g.history))
+	var last *Move
+	for i, m := range g.history {
+		notations[i] = board.JapaneseMove(m, last)
+		board.DoMove(m)
+		last = &g.history[i]
+	}
+	return notations
+}
+
+// movesTo returns the legal moves of pieces of the kind on the board to the square, one for each source square.
+func (b *BitboardBoard) movesTo(to Square, kind PieceKind) []Move {
+	var moves []Move
+	var sources Bitboard
+	for _, m := range b.LegalMoves() {
+		if m.Drop || m.To != to || m.Piece != kind || sources.Has(m.From) {
+			continue
+		}
+		sources.Set(m.From)
+		moves = append(moves, m)
+	}
+	return moves
+}
+
+// moveDirection returns 上 (forward), 引 (backward) or 寄 (sideways) from the mover's perspective
+func (b *BitboardBoard) moveDirection(m Move) string {
+	forward := m.To.Y() < m.From.Y()
+	if b.turn == Gote {
+		forward = m.To.Y() > m.From.Y()
+	}
+	switch {
+	case m.To.Y() == m.From.Y():
+		return "寄"
+	case forward:
+		return "上"
+	default:
+		return "引"
+	}
+}
+
+// isRightOf reports if the square is on the right of the other square from the mover's perspective
+func (b *BitboardBoard) isRightOf(sq, other Square) bool {
+	if b.turn == Sente {
+		return sq.X() < other.X()
+	}
+	return sq.X() > other.X()
+}
+
+// relativeNotation returns the characters distinguishing the move from the other moves of the same kind to the same square
+func (b *BitboardBoard) relativeNotation(m Move) string {
+	var others []Move
+	for _, c := range b.movesTo(m.To, m.Piece) {
+		if c.From != m.From {
+			others = append(others, c)
+		}
+	}
+	if len(others) == 0 {
+		return ""
+	}
+
+	direction := b.moveDirection(m)
+	straight := m.To.X() == m.From.X() && direction == "上"
+	if straight && m.Piece != KindBishop && m.Piece != KindRook && m.Piece != KindPromotedBishop && m.Piece != KindPromotedRook {
+		return "直"
+	}
+	var sameDirection []Move
+	for _, o := range others {
+		if b.moveDirection(o) == direction {
+			sameDirection = append(sameDirection, o)
+		}
+	}
+	if len(sameDirection) == 0 {
+		return direction
+	}
+
+	rightmost, leftmost := true, true
+	for _, o := range sameDirection {
+		if b.isRightOf(o.From, m.From) {
+			rightmost = false
+		}
+		if b.isRightOf(m.From, o.From) {
+			leftmost = false
+		}
+	}
+	var side string
+	switch {
+	case rightmost:
+		side = "右"
+	case leftmost:
+		side = "左"
+	}
+	if len(sameDirection) < len(others) {
+		return side + direction
+	}
+	return side
+}
+
+// ParseJapaneseMove parses the move in Japanese notation for the side to move, e.g. "７六歩", "同歩", "55角打", "58金右", "２二角成(88)".
+// last is the previous move if any, which is needed for "同". If promotion isn't specified, the move isn't promoted unless it must be.
+func (b *BitboardBoard) ParseJapaneseMove(s string, last *Move) (Move, error) {
+	rest := strings.TrimSpace(s)
+	for _, marker := range []string{"▲", "△", "☗", "☖"} {
+		rest = strings.TrimPrefix(rest, marker)
+	}
+
+	var to Square
+	if strings.HasPrefix(rest, "同") {
+		if last == nil {
+			return Move{}, errors.Errorf("there is no previous move for 同: %q", s)
+		}
+		to = last.To
+		rest = strings.TrimLeft(strings.TrimPrefix(rest, "同"), "　 ")
+	} else {
+		x, r, ok := parseNotationNumber(rest)
+		if !ok {
+			return Move{}, errors.Errorf("invalid square: %q", s)
+		}
+		y, r, ok := parseNotationNumber(r)
+		if !ok {
+			return Move{}, errors.Errorf("invalid square: %q", s)
+		}
+		to = NewSquare(Axis(x), Axis(y))
+		rest = r
+	}
+
+	kind := KindNone
+	for _, alias := range notationPieceAliases {
+		if strings.HasPrefix(rest, alias.name) {
+			kind = alias.kind
+			rest = strings.TrimPrefix(rest, alias.name)
+			break
+		}
+	}
+	if kind == KindNone {
+		return Move{}, errors.Errorf("invalid piece: %q", s)
+	}
+
+	from := NoSquare
+	if i := strings.Index(rest, "("); i >= 0 && strings.HasSuffix(rest, ")") {
+		source := rest[i+1 : len(rest)-1]
+		if len(source) != 2 || source[0] < '1' || source[0] > '9' || source[1] < '1' || source[1] > '9' {
+			return Move{}, errors.Errorf("invalid source square: %q", s)
+		}
+		from = NewSquare(Axis(source[0]-'0'), Axis(source[1]-'0'))
+		rest = rest[:i]
+	}
+
+	var promote, noPromote, drop bool
+	switch {
+	case strings.HasSuffix(rest, "不成"):
+		noPromote, rest = true, strings.TrimSuffix(rest, "不成")
+	case strings.HasSuffix(rest, "生"):
+		noPromote, rest = true, strings.TrimSuffix(rest, "生")
+	case strings.HasSuffix(rest, "成"):
+		promote, rest = true, strings.TrimSuffix(rest, "成")
+	case strings.HasSuffix(rest, "打"):
+		drop, rest = true, strings.TrimSuffix(rest, "打")
+	}
+	markers := rest
+
+	var candidates []Move
+	for _, m := range b.LegalMoves() {
+		if m.To != to || m.Piece != kind || m.Promote != promote {
+			continue
+		}
+		if from != NoSquare && m.From != from {
+			continue
+		}
+		if drop && !m.Drop {
+			continue
+		}
+		candidates = append(candidates, m)
+	}
+	// drops are chosen only if no piece on the board can move there, unless 打 is specified
+	if !drop {
+		var boardMoves []Move
+		for _, m := range candidates {
+			if !m.Drop {
+				boardMoves = append(boardMoves, m)
+			}
+		}
+		if len(boardMoves) > 0 {
+			candidates = boardMoves
+		}
+	}
+	if !promote && !noPromote && len(candidates) == 0 {
+		// a move which must be promoted
+		for _, m := range b.LegalMoves() {
+			if m.To == to && m.Piece == kind && m.Promote && (from == NoSquare || m.From == from) {
+				candidates = append(candidates, m)
+			}
+		}
+	}
+	candidates = b.filterByRelativeNotation(candidates, markers)
+
+	switch len(candidates) {
+	case 0:
+		return Move{}, errors.Errorf("illegal move: %q", s)
+	case 1:
+		return candidates[0], nil
+	default:
+		return Move{}, errors.Errorf("ambiguous move: %q", s)
+	}
+}
+
+func (b *BitboardBoard) filterByRelativeNotation(candidates []Move, markers string) []Move {
+	for _, marker := range []string{"上", "引", "寄", "直", "右", "左"} {
+		if !strings.Contains(markers, marker) {
+			continue
+		}
+		var filtered []Move
+		for _, m := range candidates {
+			if m.Drop {
+				continue
+			}
+			switch marker {
+			case "上", "引", "寄":
+				if b.moveDirection(m) == marker {
+					filtered = append(filtered, m)
+				}
+			case "直":
+				if m.To.X() == m.From.X() && b.moveDirection(m) == "上" {
+					filtered = append(filtered, m)
+				}
+			case "右", "左":
+				extreme := true
+				for _, o := range candidates {
+					if o.Drop || o.From == m.From {
+						continue
+					}
+					if (marker == "右" && b.isRightOf(o.From, m.From)) || (marker == "左" && b.isRightOf(m.From, o.From)) {
+						extreme = false
+					}
+				}
+				if extreme {
+					filtered = append(filtered, m)
+				}
+			}
+		}
+		candidates = filtered
+	}
+	return candidates
+}
+
+// parseNotationNumber parses a leading number 1-9 written in ASCII, full-width or kanji
+func parseNotationNumber(s string) (n int, rest string, ok bool) {
+	if s == "" {
+		return 0, s, false
+	}
+	if s[0] >= '1' && s[0] <= '9' {
+		return int(s[0] - '0'), s[1:], true
+	}
+	for i := 1; i <= 9; i++ {
+		if strings.HasPrefix(s, fullWidthDigits[i]) {
+			return i, strings.TrimPrefix(s, fullWidthDigits[i]), true
+		}
+		if strings.HasPrefix(s, kanjiNumerals[i]) {
+			return i, strings.TrimPrefix(s, kanjiNumerals[i]), true
+		}
+	}
+	return 0, s, false
+}
@@ -0,0 +1,167 @@
+package shogi
+
+import (
+	"testing"
+)
+
+// notationBoard returns the board of the position after the moves in USI, and the last move if any
+func notationBoard(t *testing.T, sfen string, moves ...string) (*BitboardBoard, *Move) {
+	t.Helper()
+	b, _, err := ParseSFEN(sfen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last *Move
+	for _, usi := range moves {
+		m, err := b.ParseMove(usi)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.DoMove(m)
+		last = &m
+	}
+	return b, last
+}
+
+const (
+	// golds moving straight up and sideways
+	notationGolds = "4k4/9/9/9/9/9/9/3G5/4GK3 b - 1"
+	// silvers moving up and back
+	notationSilvers = "4k4/9/9/9/9/9/3S5/9/4KS3 b - 1"
+	// dragons on both sides, and ones moving straight up and back
+	notationDragons      = "4k4/9/9/9/9/9/9/4K4/+R7+R b - 1"
+	notationDragonsAbove = "4k4/9/9/9/9/9/5+R3/9/K3+R4 b - 1"
+	// a gold on the board and one in hand
+	notationDrop = "4k4/9/9/9/9/9/9/9/3G4K b G 1"
+	// a pawn which must be promoted
+	notationPawn = "4k4/8P/9/9/9/9/9/9/4K4 b - 1"
+)
+
+func TestBitboardBoard_JapaneseMove(t *testing.T) {
+	tests := []struct {
+		name  string
+		sfen  string
+		moves []string
+		move  string
+		want  string
+	}{
+		{name: "no other piece", sfen: InitialSFEN, move: "7g7f", want: "７六歩"},
+		{name: "other kinds to the same square", sfen: InitialSFEN, move: "6i7h", want: "７八金"},
+		{name: "right", sfen: InitialSFEN, move: "4i5h", want: "５八金右"},
+		{name: "left", sfen: InitialSFEN, move: "6i5h", want: "５八金左"},
+		{name: "right of gote", sfen: InitialSFEN, moves: []string{"7g7f"}, move: "6a5b", want: "５二金右"},
+		{name: "left of gote", sfen: InitialSFEN, moves: []string{"7g7f"}, move: "4a5b", want: "５二金左"},
+		{name: "straight", sfen: notationGolds, move: "5i5h", want: "５八金直"},
+		{name: "sideways", sfen: notationGolds, move: "6h5h", want: "５八金寄"},
+		{name: "up", sfen: notationSilvers, move: "4i5h", want: "５八銀上"},
+		{name: "back", sfen: notationSilvers, move: "6g5h", want: "５八銀引"},
+		{name: "dragon from right", sfen: notationDragons, move: "1i5i", want: "５九龍右"},
+		{name: "dragon from left", sfen: notationDragons, move: "9i5i", want: "５九龍左"},
+		{name: "dragon straight up isn't 直", sfen: notationDragonsAbove, move: "5i5h", want: "５八龍上"},
+		{name: "dragon back", sfen: notationDragonsAbove, move: "4g5h", want: "５八龍引"},
+		{name: "promotion", sfen: InitialSFEN, moves: []string{"7g7f", "3c3d"}, move: "8h2b+", want: "２二角成"},
+		{name: "no promotion", sfen: InitialSFEN, moves: []string{"7g7f", "3c3d"}, move: "8h2b", want: "２二角不成"},
+		{name: "same square", sfen: InitialSFEN, moves: []string{"7g7f", "3c3d", "8h2b+"}, move: "3a2b", want: "同　銀"},
+		{name: "drop where a piece can move", sfen: notationDrop, move: "G*5h", want: "５八金打"},
+		{name: "move where a piece can be dropped", sfen: notationDrop, move: "6i5h", want: "５八金"},
+		{name: "drop", sfen: notationDrop, move: "G*5e", want: "５五金"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, last := notationBoard(t, tt.sfen, tt.moves...)
+			m, err := b.ParseMove(tt.move)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := b.JapaneseMove(m, last); got != tt.want {
+				t.Errorf("JapaneseMove(%s) = %s, want %s", tt.move, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBitboardBoard_ParseJapaneseMove(t *testing.T) {
+	tests := []struct {
+		name     string
+		sfen     string
+		moves    []string
+		notation string
+		want     string
+		wantErr  bool
+	}{
+		{name: "full-width", sfen: InitialSFEN, notation: "７六歩", want: "7g7f"},
+		{name: "ascii", sfen: InitialSFEN, notation: "76歩", want: "7g7f"},
+		{name: "kanji with marker", sfen: InitialSFEN, notation: "▲七六歩", want: "7g7f"},
+		{name: "right", sfen: InitialSFEN, notation: "５八金右", want: "4i5h"},
+		{name: "left", sfen: InitialSFEN, notation: "58金左", want: "6i5h"},
+		{name: "right of gote", sfen: InitialSFEN, moves: []string{"7g7f"}, notation: "△５二金右", want: "6a5b"},
+		{name: "straight", sfen: notationGolds, notation: "５八金直", want: "5i5h"},
+		{name: "sideways", sfen: notationGolds, notation: "５八金寄", want: "6h5h"},
+		{name: "up", sfen: notationSilvers, notation: "５八銀上", want: "4i5h"},
+		{name: "back", sfen: notationSilvers, notation: "５八銀引", want: "6g5h"},
+		{name: "dragon", sfen: notationDragons, notation: "５九竜左", want: "9i5i"},
+		{name: "promotion", sfen: InitialSFEN, moves: []string{"7g7f", "3c3d"}, notation: "２二角成", want: "8h2b+"},
+		{name: "no promotion", sfen: InitialSFEN, moves: []string{"7g7f", "3c3d"}, notation: "２二角不成", want: "8h2b"},
+		{name: "no promotion by 生", sfen: InitialSFEN, moves: []string{"7g7f", "3c3d"}, notation: "２二角生", want: "8h2b"},
+		{name: "promotion not specified", sfen: InitialSFEN, moves: []string{"7g7f", "3c3d"}, notation: "２二角", want: "8h2b"},
+		{name: "source square", sfen: InitialSFEN, moves: []string{"7g7f", "3c3d"}, notation: "２二角成(88)", want: "8h2b+"},
+		{name: "same square", sfen: InitialSFEN, moves: []string{"7g7f", "3c3d", "8h2b+"}, notation: "同銀", want: "3a2b"},
+		{name: "same square with space", sfen: InitialSFEN, moves: []string{"7g7f", "3c3d", "8h2b+"}, notation: "同　銀(31)", want: "3a2b"},
+		{name: "board move before drop", sfen: notationDrop, notation: "５八金", want: "6i5h"},
+		{name: "drop", sfen: notationDrop, notation: "５八金打", want: "G*5h"},
+		{name: "drop without 打", sfen: notationDrop, notation: "５五金", want: "G*5e"},
+		{name: "promotion which is a must", sfen: notationPawn, notation: "１一歩", want: "1b1a+"},
+		{name: "ambiguous gold", sfen: InitialSFEN, notation: "５八金", wantErr: true},
+		{name: "ambiguous silver", sfen: notationSilvers, notation: "５八銀", wantErr: true},
+		{name: "marker matching no move", sfen: notationSilvers, notation: "５八銀寄", wantErr: true},
+		{name: "illegal", sfen: InitialSFEN, notation: "５五歩", wantErr: true},
+		{name: "illegal without promotion", sfen: notationPawn, notation: "１一歩不成", wantErr: true},
+		{name: "wrong source square", sfen: InitialSFEN, notation: "７六歩(99)", wantErr: true},
+		{name: "invalid source square", sfen: InitialSFEN, notation: "７六歩(7g)", wantErr: true},
+		{name: "no previous move", sfen: InitialSFEN, notation: "同歩", wantErr: true},
+		{name: "invalid piece", sfen: InitialSFEN, notation: "７六象", wantErr: true},
+		{name: "invalid square", sfen: InitialSFEN, notation: "０六歩", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, last := notationBoard(t, tt.sfen, tt.moves...)
+			m, err := b.ParseJapaneseMove(tt.notation, last)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseJapaneseMove(%s) error = %v, wantErr %v", tt.notation, err, tt.wantErr)
+			}
+			if err == nil && m.String() != tt.want {
+				t.Errorf("ParseJapaneseMove(%s) = %s, want %s", tt.notation, m, tt.want)
+			}
+		})
+	}
+}
+
+// every legal move must be parsed back from its notation
+func TestBitboardBoard_JapaneseMove_RoundTrip(t *testing.T) {
+	sfens := []string{
+		InitialSFEN,
+		notationGolds,
+		notationSilvers,
+		notationDragons,
+		notationDragonsAbove,
+		notationDrop,
+		// three golds and two silvers around 5h, and gote's silvers around 5b
+		"3sks3/4g4/9/9/9/9/3S1S3/3G1G3/4KG3 b - 1",
+		"3sks3/4g4/9/9/9/9/3S1S3/3G1G3/4KG3 w - 1",
+		"ln1g3nl/1r2gks2/p1sppp1pp/2p3p2/1p5P1/2P1P1P2/PPSP1P2P/2G1GS1R1/LN2K2NL b Bb 21",
+	}
+	for _, sfen := range sfens {
+		b, _ := notationBoard(t, sfen)
+		for _, m := range b.LegalMoves() {
+			notation := b.JapaneseMove(m, nil)
+			got, err := b.ParseJapaneseMove(notation, nil)
+			if err != nil {
+				t.Errorf("%s: ParseJapaneseMove(%s) for %s error = %v", sfen, notation, m, err)
+				continue
+			}
+			if got != m {
+				t.Errorf("%s: ParseJapaneseMove(%s) = %s, want %s", sfen, notation, got, m)
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package shogi
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PerftDivision is the number of leaf nodes under a root move
+type PerftDivision struct {
+	Move  Move
+	Nodes uint64
+}
+
+// Perft counts the leaf nodes of the legal move tree of the given depth.
+// It's used to verify move generation against known results.
+func (b *BitboardBoard) Perft(depth int) uint64 {
+	if depth <= 0 {
+		return 1
+	}
+	moves := b.LegalMoves()
+	if depth == 1 {
+		return uint64(len(moves))
+	}
+	var nodes uint64
+	for _, m := range moves {
+		b.DoMove(m)
+		nodes += b.Perft(depth - 1)
+		b.UndoMove(m)
+	}
+	return nodes
+}
+
+// PerftDivide counts the leaf nodes of the given depth for each root move, sorted by the move in USI notation.
+func (b *BitboardBoard) PerftDivide(depth int) []PerftDivision {
+	var divisions []PerftDivision
+	for _, m := range b.LegalMoves() {
+		b.DoMove(m)
+		divisions = append(divisions, PerftDivision{Move: m, Nodes: b.Perft(depth - 1)})
+		b.UndoMove(m)
+	}
+	sort.Slice(divisions, func(i, j int) bool {
+		return divisions[i].Move.String() < divisions[j].Move.String()
+	})
+	return divisions
+}
+
+// Perft counts the leaf nodes of the legal move tree of the given depth from the current position
+func (g *Game) Perft(depth int) uint64 {
+	return g.board.Clone().Perft(depth)
+}
+
+// PerftDivide counts the leaf nodes of the given depth for each legal move in the current position
+func (g *Game) PerftDivide(depth int) []PerftDivision {
+	return g.board.Clone().PerftDivide(depth)
+}
+
+// PerftResult is a known perft result of a position
+type PerftResult struct {
+	SFEN  string
+	Depth int
+	Nodes uint64
+	// Line is the line number of the result in the file
+	Line int
+}
+
+// ReadPerftResults reads the known perft results, which are a position and its results per line:
+//
+//	<sfen> ; <depth>:<nodes> <depth>:<nodes> ...
+//
+// Empty lines and the lines starting with # are ignored.
+func ReadPerftResults(r io.Reader) ([]PerftResult, error) {
+	var results []PerftResult
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ";", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("line %d: invalid line", lineNum)
+		}
+		sfen := strings.TrimSpace(parts[0])
+		for _, s := range strings.Fields(parts[1]) {
+			depth, nodes, err := parsePerftResult(s)
+			if err != nil {
+				return nil, errors.Wrapf(err, "line %d", lineNum)
+			}
+			results = append(results, PerftResult{SFEN: sfen, Depth: depth, Nodes: nodes, Line: lineNum})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// parsePerftResult parses a result of the form <depth>:<nodes>
+func parsePerftResult(s string) (depth int, nodes uint64, err error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return 0, 0, errors.Errorf("invalid result: %q", s)
+	}
+	if depth, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, 0, errors.Wrapf(err, "invalid depth: %q", s)
+	}
+	if nodes, err = strconv.ParseUint(parts[1], 10, 64); err != nil {
+		return 0, 0, errors.Wrapf(err, "invalid nodes: %q", s)
+	}
+	return depth, nodes, nil
+}
@@ -0,0 +1,86 @@
+package shogi
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+const (
+	// maxPerftNodes is the most nodes of the known results verified by go test, and the deeper ones are left to
+	// cmd/perft -verify
+	maxPerftNodes = 1000000
+	// maxShortPerftNodes is the most nodes verified with -short
+	maxShortPerftNodes = 100000
+)
+
+func TestGame_Perft(t *testing.T) {
+	f, err := os.Open("testdata/perft.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	results, err := ReadPerftResults(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	maxNodes := uint64(maxPerftNodes)
+	if testing.Short() {
+		maxNodes = maxShortPerftNodes
+	}
+	for _, r := range results {
+		r := r
+		t.Run(fmt.Sprintf("%s/depth %d", r.SFEN, r.Depth), func(t *testing.T) {
+			if r.Nodes > maxNodes {
+				t.Skipf("%d nodes are more than %d", r.Nodes, maxNodes)
+			}
+			game, err := NewGameFromSFEN(r.SFEN)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := game.Perft(r.Depth); got != r.Nodes {
+				t.Errorf("Perft(%d) = %d, want %d", r.Depth, got, r.Nodes)
+			}
+		})
+	}
+}
+
+func TestReadPerftResults(t *testing.T) {
+	input := "# comment\n\n4k4/9/9/9/9/9/9/9/4K4 b - 1 ; 1:5 2:25\n"
+	results, err := ReadPerftResults(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PerftResult{
+		{SFEN: "4k4/9/9/9/9/9/9/9/4K4 b - 1", Depth: 1, Nodes: 5, Line: 3},
+		{SFEN: "4k4/9/9/9/9/9/9/9/4K4 b - 1", Depth: 2, Nodes: 25, Line: 3},
+	}
+	if fmt.Sprint(results) != fmt.Sprint(want) {
+		t.Errorf("ReadPerftResults() = %v, want %v", results, want)
+	}
+	for _, invalid := range []string{"4k4/9/9/9/9/9/9/9/4K4 b - 1", "4k4/9/9/9/9/9/9/9/4K4 b - 1 ; 1", "4k4/9/9/9/9/9/9/9/4K4 b - 1 ; x:5"} {
+		if _, err := ReadPerftResults(strings.NewReader(invalid)); err == nil {
+			t.Errorf("ReadPerftResults(%q) error = nil", invalid)
+		}
+	}
+}
+
+func TestGame_PerftDivide(t *testing.T) {
+	game := NewGame()
+	var total uint64
+	for _, d := range game.PerftDivide(3) {
+		total += d.Nodes
+	}
+	if want := game.Perft(3); total != want {
+		t.Errorf("sum of PerftDivide(3) = %d, want %d", total, want)
+	}
+}
+
+func BenchmarkGamePerft3(b *testing.B) {
+	game := NewGame()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		game.Perft(3)
+	}
+}
@@ -126,11 +126,11 @@ func (r *Rook) PositionsOnTheWayTo(curPos, distPos *Position) (positionsOnTheWay
 	switch {
 	case curPos.X < distPos.X:
 		for x := curPos.X + 1; x < distPos.X; x++ {
-			positionsOnTheWay = append(positionsOnTheWay, &Position{X: x, Y: curPos.X})
+			positionsOnTheWay = append(positionsOnTheWay, &Position{X: x, Y: curPos.Y})
 		}
 	case curPos.X > distPos.X:
-		for x := curPos.X - 1; x < distPos.X; x-- {
-			positionsOnTheWay = append(positionsOnTheWay, &Position{X: x, Y: curPos.X})
+		for x := curPos.X - 1; x > distPos.X; x-- {
+			positionsOnTheWay = append(positionsOnTheWay, &Position{X: x, Y: curPos.Y})
 		}
 	case curPos.Y < distPos.Y:
 		for y := curPos.Y + 1; y < distPos.Y; y++ {
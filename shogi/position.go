@@ -31,3 +31,7 @@ func (pl PositionList) Contains(pos *Position) bool {
 func (p *Position) IsSamePosition(pos *Position) bool {
 	return p.X == pos.X && p.Y == pos.Y
 }
+
+func isOnBoard(pos *Position) bool {
+	return pos.X >= 1 && pos.X <= 9 && pos.Y >= 1 && pos.Y <= 9
+}
@@ -0,0 +1,317 @@
+package shogi
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrGameOver is returned when a move is made after the game has ended
+var ErrGameOver = errors.New("game is over")
+
+// Reason is how a game ended
+type Reason int
+
+const (
+	ReasonCheckmate Reason = iota + 1
+	// ReasonResignation is 投了
+	ReasonResignation
+	// ReasonTimeUp is the loss on time (切れ負け)
+	ReasonTimeUp
+	// ReasonIllegalMove is the loss by an illegal move (反則負け) in the illegal-move-loses mode
+	ReasonIllegalMove
+	// ReasonSennichite is the draw by the fourfold repetition (千日手)
+	ReasonSennichite
+	// ReasonPerpetualCheck is the loss of the player who checked on every move of the repetition (連続王手の千日手)
+	ReasonPerpetualCheck
+	// ReasonJishogi is the draw by impasse (持将棋) agreed by the players
+	ReasonJishogi
+	// ReasonAbort is the game aborted without result (中断)
+	ReasonAbort
+	// ReasonDeclaration is the win by the entering king declaration (入玉宣言)
+	ReasonDeclaration
+)
+
+var reasonNames = map[Reason]string{
+	ReasonCheckmate:      "詰み",
+	ReasonResignation:    "投了",
+	ReasonTimeUp:         "切れ負け",
+	ReasonIllegalMove:    "反則負け",
+	ReasonSennichite:     "千日手",
+	ReasonPerpetualCheck: "連続王手の千日手",
+	ReasonJishogi:        "持将棋",
+	ReasonAbort:          "中断",
+	ReasonDeclaration:    "入玉勝ち",
+}
+
+// String returns the Japanese name of the reason, e.g. 投了
+func (r Reason) String() string {
+	return reasonNames[r]
+}
+
+// Result is the result of a finished game
+type Result struct {
+	Reason Reason
+	// Winner is the color which won, which is meaningless if the result has no winner
+	Winner Color
+}
+
+// HasWinner reports if the game was won by a player, which is false for draws and aborted games
+func (r Result) HasWinner() bool {
+	switch r.Reason {
+	case ReasonSennichite, ReasonJishogi, ReasonAbort:
+		return false
+	default:
+		return true
+	}
+}
+
+// String returns the result in Japanese, e.g. "先手の勝ち（投了）", "千日手"
+func (r Result) String() string {
+	if r.Reason == 0 || !r.HasWinner() {
+		return r.Reason.String()
+	}
+	return r.Winner.String() + "の勝ち（" + r.Reason.String() + "）"
+}
+
+// Result returns the result of the game, ok is false if it's in progress.
+// It doesn't look at the clock, see CheckTime.
+func (g *Game) Result() (result Result, ok bool) {
+	if g.result != nil {
+		return *g.result, true
+	}
+	if g.board.IsCheckmated() {
+		return Result{Reason: ReasonCheckmate, Winner: g.SideToMove().Opponent()}, true
+	}
+	return Result{}, false
+}
+
+// IsOver reports if the game has ended
+func (g *Game) IsOver() bool {
+	_, ok := g.Result()
+	return ok
+}
+
+// CheckTime ends the game when the side to move has run out of time on the started clock, and reports if the game
+// has ended on time. A late move ends the game when it's made, so the runners call this while waiting for a move.
+func (g *Game) CheckTime() bool {
+	if g.result == nil && g.clock != nil && g.clock.TimeUp() {
+		g.end(Result{Reason: ReasonTimeUp, Winner: g.SideToMove().Opponent()})
+	}
+	return g.result != nil && g.result.Reason == ReasonTimeUp
+}
+
+// checkOver reports if the game has ended before the side to move acts, ending it first if the time is up
+func (g *Game) checkOver() bool {
+	g.CheckTime()
+	return g.IsOver()
+}
+
+// Resign ends the game with the resignation of the player
+func (g *Game) Resign(player Color) error {
+	if g.checkOver() {
+		return errors.Wrap(ErrGameOver, "resign")
+	}
+	g.end(Result{Reason: ReasonResignation, Winner: player.Opponent()})
+	return nil
+}
+
+// AgreeDraw ends the game with the draw by impasse (持将棋) agreed by the players
+func (g *Game) AgreeDraw() error {
+	if g.checkOver() {
+		return errors.Wrap(ErrGameOver, "agree draw")
+	}
+	g.end(Result{Reason: ReasonJishogi})
+	return nil
+}
+
+// Abort ends the game without result
+func (g *Game) Abort() error {
+	if g.checkOver() {
+		return errors.Wrap(ErrGameOver, "abort")
+	}
+	g.end(Result{Reason: ReasonAbort})
+	return nil
+}
+
+// SetIllegalMoveLoses sets the illegal-move-loses mode used in tournaments,
+// where the player making an illegal move loses the game instead of being asked for another move
+func (g *Game) SetIllegalMoveLoses(loses bool) {
+	g.illegalMoveLoses = loses
+}
+
+// end ends the game with the result and stops the clock
+func (g *Game) end(result Result) {
+	g.result = &result
+	g.resultByMove = false
+	if g.clock != nil {
+		g.clock.Pause()
+	}
+	var last Move
+	if len(g.history) > 0 {
+		last = g.history[len(g.history)-1]
+	}
+	g.emit(Event{Type: EventGameOver, Ply: len(g.history), Move: last, Result: result})
+}
+
+// rejectMove returns the error for the illegal move, which loses the game in the illegal-move-loses mode
+func (g *Game) rejectMove(err error) error {
+	if g.illegalMoveLoses {
+		g.end(Result{Reason: ReasonIllegalMove, Winner: g.SideToMove().Opponent()})
+	}
+	return err
+}
+
+// checkRepetition ends the game if the current position has appeared four times
+func (g *Game) checkRepetition() {
+	key := g.positions[len(g.positions)-1]
+	first, count := -1, 0
+	for i, k := range g.positions {
+		if k == key {
+			if first < 0 {
+				first = i
+			}
+			count++
+		}
+	}
+	if count < 4 {
+		return
+	}
+	// positions[i] is the position after i moves, so the repetition consists of history[first:]
+	result := Result{Reason: ReasonSennichite}
+	for _, c := range []Color{Sente, Gote} {
+		perpetual := true
+		for ply := first; ply < len(g.history); ply++ {
+			if g.colorOf(ply) == c && !g.checks[ply] {
+				perpetual = false
+				break
+			}
+		}
+		if perpetual {
+			result = Result{Reason: ReasonPerpetualCheck, Winner: c.Opponent()}
+			break
+		}
+	}
+	g.end(result)
+	// taking back the move cancels the repetition
+	g.resultByMove = true
+}
+
+// colorOf returns the color which played the move of the ply counted from 0
+func (g *Game) colorOf(ply int) Color {
+	if ply%2 == 0 {
+		return g.initialBoard.turn
+	}
+	return g.initialBoard.turn.Opponent()
+}
+
+// positionKey returns the key identifying the position, the pieces on the board and in hand and the side to move
+func (b *BitboardBoard) positionKey() string {
+	key := make([]byte, 0, NumSquares+2*len(HandKinds)+1)
+	for sq := Square(0); sq < NumSquares; sq++ {
+		kind, color := b.PieceAt(sq)
+		if kind == KindNone {
+			// the owner of an empty square is meaningless
+			color = Sente
+		}
+		key = append(key, byte(kind)|byte(color)<<4)
+	}
+	for _, c := range []Color{Sente, Gote} {
+		for _, kind := range HandKinds {
+			key = append(key, byte(b.hands[c][kind]))
+		}
+	}
+	return string(append(key, byte(b.turn)))
+}
+
+// kifTerminator returns the last line of the moves in KIF for the result of the game
+func (g *Game) kifTerminator(r Result) string {
+	switch r.Reason {
+	case ReasonIllegalMove, ReasonPerpetualCheck:
+		// the terminators are for the side to move
+		if r.Winner == g.SideToMove() {
+			return "反則勝ち"
+		}
+		return "反則負け"
+	default:
+		return r.Reason.String()
+	}
+}
+
+// csaTerminator returns the special move in CSA for the result of the game
+func (g *Game) csaTerminator(r Result) string {
+	switch r.Reason {
+	case ReasonCheckmate:
+		return "%TSUMI"
+	case ReasonResignation:
+		return "%TORYO"
+	case ReasonTimeUp:
+		return "%TIME_UP"
+	case ReasonIllegalMove, ReasonPerpetualCheck:
+		if r.Reason == ReasonIllegalMove && r.Winner != g.SideToMove() {
+			return "%ILLEGAL_MOVE"
+		}
+		return "%" + csaSign(r.Winner.Opponent()) + "ILLEGAL_ACTION"
+	case ReasonSennichite:
+		return "%SENNICHITE"
+	case ReasonJishogi:
+		return "%JISHOGI"
+	case ReasonAbort:
+		return "%CHUDAN"
+	case ReasonDeclaration:
+		return "%KACHI"
+	default:
+		return ""
+	}
+}
+
+// parseKIFTerminator returns the result for the last line of the moves in KIF, ok is false if the game isn't over by it
+func (g *Game) parseKIFTerminator(terminator string) (result Result, ok bool) {
+	turn := g.SideToMove()
+	switch {
+	case strings.HasPrefix(terminator, "反則勝ち"):
+		return Result{Reason: ReasonIllegalMove, Winner: turn}, true
+	case strings.HasPrefix(terminator, "反則負け"):
+		return Result{Reason: ReasonIllegalMove, Winner: turn.Opponent()}, true
+	case strings.HasPrefix(terminator, ReasonDeclaration.String()):
+		return Result{Reason: ReasonDeclaration, Winner: turn}, true
+	}
+	for _, reason := range []Reason{ReasonResignation, ReasonTimeUp} {
+		if strings.HasPrefix(terminator, reason.String()) {
+			return Result{Reason: reason, Winner: turn.Opponent()}, true
+		}
+	}
+	for _, reason := range []Reason{ReasonSennichite, ReasonJishogi, ReasonAbort} {
+		if strings.HasPrefix(terminator, reason.String()) {
+			return Result{Reason: reason}, true
+		}
+	}
+	return Result{}, false
+}
+
+// parseCSATerminator returns the result for the special move in CSA, ok is false if the game isn't over by it
+func (g *Game) parseCSATerminator(terminator string) (result Result, ok bool) {
+	turn := g.SideToMove()
+	switch terminator {
+	case "%TORYO":
+		return Result{Reason: ReasonResignation, Winner: turn.Opponent()}, true
+	case "%TIME_UP":
+		return Result{Reason: ReasonTimeUp, Winner: turn.Opponent()}, true
+	case "%ILLEGAL_MOVE":
+		return Result{Reason: ReasonIllegalMove, Winner: turn.Opponent()}, true
+	case "%+ILLEGAL_ACTION":
+		return Result{Reason: ReasonIllegalMove, Winner: Gote}, true
+	case "%-ILLEGAL_ACTION":
+		return Result{Reason: ReasonIllegalMove, Winner: Sente}, true
+	case "%SENNICHITE":
+		return Result{Reason: ReasonSennichite}, true
+	case "%JISHOGI":
+		return Result{Reason: ReasonJishogi}, true
+	case "%CHUDAN":
+		return Result{Reason: ReasonAbort}, true
+	case "%KACHI":
+		return Result{Reason: ReasonDeclaration, Winner: turn}, true
+	default:
+		return Result{}, false
+	}
+}
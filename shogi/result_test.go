@@ -0,0 +1,256 @@
+package shogi
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// playUSI plays the moves in USI, failing the test on an error
+func playUSI(t *testing.T, g *Game, moves ...string) {
+	t.Helper()
+	for _, usi := range moves {
+		m, err := g.Board().ParseMove(usi)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := g.ApplyMove(m); err != nil {
+			t.Fatalf("ApplyMove(%s) error = %v", usi, err)
+		}
+	}
+}
+
+func TestGame_CheckTime(t *testing.T) {
+	now := time.Date(2020, 8, 7, 12, 0, 0, 0, time.UTC)
+	g := NewGame()
+	g.StartClock(NewClock(TimeControl{MainTime: time.Minute}, func() time.Time { return now }))
+	var events []Event
+	g.Subscribe(func(e Event) { events = append(events, e) }, EventGameOver)
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := g.Result(); ok {
+		t.Fatal("Result() ended the game on time")
+	}
+	if len(events) > 0 || !g.Clock().Running() {
+		t.Fatalf("Result() changed the game: events %v, clock running %v", events, g.Clock().Running())
+	}
+	if !g.CheckTime() {
+		t.Fatal("CheckTime() = false after the time is up")
+	}
+	want := Result{Reason: ReasonTimeUp, Winner: Gote}
+	if result, ok := g.Result(); !ok || result != want {
+		t.Errorf("Result() = %v, %v, want %v", result, ok, want)
+	}
+	if len(events) != 1 || events[0].Result != want || g.Clock().Running() {
+		t.Errorf("events = %v, clock running %v, want one game over event with the clock stopped", events, g.Clock().Running())
+	}
+	if err := g.Resign(Sente); errors.Cause(err) != ErrGameOver {
+		t.Errorf("Resign() error = %v, want ErrGameOver", err)
+	}
+}
+
+func TestGame_Undo_Result(t *testing.T) {
+	tests := []struct {
+		name string
+		end  func(t *testing.T, g *Game)
+		// cancelled is whether taking back the last move cancels the end of the game
+		cancelled bool
+	}{
+		{
+			name: "resignation",
+			end: func(t *testing.T, g *Game) {
+				if err := g.Resign(Gote); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "abort",
+			end: func(t *testing.T, g *Game) {
+				if err := g.Abort(); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "agreed draw",
+			end: func(t *testing.T, g *Game) {
+				if err := g.AgreeDraw(); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "repetition",
+			end: func(t *testing.T, g *Game) {
+				for i := 0; i < 3; i++ {
+					playUSI(t, g, "5a5b", "5i5h", "5b5a", "5h5i")
+				}
+				if result, _ := g.Result(); result.Reason != ReasonSennichite {
+					t.Fatalf("Result() = %v, want 千日手", result)
+				}
+			},
+			cancelled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGame()
+			playUSI(t, g, "7g7f")
+			tt.end(t, g)
+			before, _ := g.Result()
+			plies := len(g.History())
+
+			err := g.Undo()
+			if tt.cancelled {
+				if err != nil || g.IsOver() || len(g.History()) != plies-1 {
+					t.Errorf("Undo() error = %v, over %v, %d moves, want the move taken back", err, g.IsOver(), len(g.History()))
+				}
+				return
+			}
+			if errors.Cause(err) != ErrGameOver {
+				t.Errorf("Undo() error = %v, want ErrGameOver", err)
+			}
+			if result, ok := g.Result(); !ok || result != before || len(g.History()) != plies {
+				t.Errorf("Result() after Undo() = %v, %v with %d moves, want %v with %d moves", result, ok, len(g.History()), before, plies)
+			}
+		})
+	}
+}
+
+func TestGame_Terminators(t *testing.T) {
+	sente := func(t *testing.T) *Game { return NewGame() }
+	gote := func(t *testing.T) *Game {
+		g := NewGame()
+		playUSI(t, g, "7g7f")
+		return g
+	}
+	checkmated := func(t *testing.T) *Game {
+		g, err := NewGameFromSFEN("7kl/9/6PPp/9/9/9/9/9/K8 b 2G 1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		playUSI(t, g, "G*2b")
+		return g
+	}
+	tests := []struct {
+		name    string
+		newGame func(t *testing.T) *Game
+		// result ends the game unless the game is over by the position
+		result *Result
+		kif    string
+		csa    string
+		// parsed is the result read back from the terminators, which is the result itself if it's nil
+		parsed *Result
+	}{
+		{name: "checkmate", newGame: checkmated, kif: "詰み", csa: "%TSUMI"},
+		{name: "resignation", newGame: sente, result: &Result{Reason: ReasonResignation, Winner: Gote}, kif: "投了", csa: "%TORYO"},
+		{name: "time up", newGame: gote, result: &Result{Reason: ReasonTimeUp, Winner: Sente}, kif: "切れ負け", csa: "%TIME_UP"},
+		{name: "illegal move by sente to move", newGame: sente, result: &Result{Reason: ReasonIllegalMove, Winner: Gote}, kif: "反則負け", csa: "%ILLEGAL_MOVE"},
+		{name: "illegal action by gote", newGame: sente, result: &Result{Reason: ReasonIllegalMove, Winner: Sente}, kif: "反則勝ち", csa: "%-ILLEGAL_ACTION"},
+		{name: "illegal move by gote to move", newGame: gote, result: &Result{Reason: ReasonIllegalMove, Winner: Sente}, kif: "反則負け", csa: "%ILLEGAL_MOVE"},
+		{name: "illegal action by sente", newGame: gote, result: &Result{Reason: ReasonIllegalMove, Winner: Gote}, kif: "反則勝ち", csa: "%+ILLEGAL_ACTION"},
+		{
+			name: "perpetual check by sente", newGame: sente, result: &Result{Reason: ReasonPerpetualCheck, Winner: Gote},
+			kif: "反則負け", csa: "%+ILLEGAL_ACTION", parsed: &Result{Reason: ReasonIllegalMove, Winner: Gote},
+		},
+		{
+			name: "perpetual check by gote", newGame: sente, result: &Result{Reason: ReasonPerpetualCheck, Winner: Sente},
+			kif: "反則勝ち", csa: "%-ILLEGAL_ACTION", parsed: &Result{Reason: ReasonIllegalMove, Winner: Sente},
+		},
+		{name: "sennichite", newGame: gote, result: &Result{Reason: ReasonSennichite}, kif: "千日手", csa: "%SENNICHITE"},
+		{name: "jishogi", newGame: sente, result: &Result{Reason: ReasonJishogi}, kif: "持将棋", csa: "%JISHOGI"},
+		{name: "abort", newGame: gote, result: &Result{Reason: ReasonAbort}, kif: "中断", csa: "%CHUDAN"},
+		{name: "declaration", newGame: gote, result: &Result{Reason: ReasonDeclaration, Winner: Gote}, kif: "入玉勝ち", csa: "%KACHI"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := tt.newGame(t)
+			if tt.result != nil {
+				g.end(*tt.result)
+			}
+			result, ok := g.Result()
+			if !ok {
+				t.Fatal("the game isn't over")
+			}
+			want := result
+			if tt.parsed != nil {
+				want = *tt.parsed
+			}
+
+			if got := g.kifTerminator(result); got != tt.kif {
+				t.Errorf("kifTerminator() = %s, want %s", got, tt.kif)
+			}
+			if !isKIFTerminator(tt.kif) {
+				t.Errorf("%s isn't a KIF terminator", tt.kif)
+			}
+			if got := g.csaTerminator(result); got != tt.csa {
+				t.Errorf("csaTerminator() = %s, want %s", got, tt.csa)
+			}
+			var record struct {
+				Moves []struct {
+					Special string `json:"special"`
+				} `json:"moves"`
+			}
+			data, err := g.JKF(JKFInfo{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(data, &record); err != nil {
+				t.Fatal(err)
+			}
+			if got := record.Moves[len(record.Moves)-1].Special; "%"+got != tt.csa {
+				t.Errorf("JKF special = %s, want %s", got, tt.csa[1:])
+			}
+
+			// the terminators are parsed back for the side to move, and the checkmate is left to the position
+			got, ok := g.parseKIFTerminator(tt.kif)
+			if tt.result == nil {
+				if ok {
+					t.Errorf("parseKIFTerminator(%s) = %v, want none", tt.kif, got)
+				}
+			} else if !ok || got != want {
+				t.Errorf("parseKIFTerminator(%s) = %v, %v, want %v", tt.kif, got, ok, want)
+			}
+			got, ok = g.parseCSATerminator(tt.csa)
+			if tt.result == nil {
+				if ok {
+					t.Errorf("parseCSATerminator(%s) = %v, want none", tt.csa, got)
+				}
+			} else if !ok || got != want {
+				t.Errorf("parseCSATerminator(%s) = %v, %v, want %v", tt.csa, got, ok, want)
+			}
+
+			kif, err := ParseKIF(g.KIF(KIFInfo{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, ok := kif.Result(); !ok || got != want {
+				t.Errorf("Result() of KIF = %v, %v, want %v", got, ok, want)
+			}
+			csa, err := ParseCSA(g.CSA(CSAInfo{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, ok := csa.Result(); !ok || got != want {
+				t.Errorf("Result() of CSA = %v, %v, want %v", got, ok, want)
+			}
+		})
+	}
+}
+
+func TestGame_Terminators_NoResult(t *testing.T) {
+	g := NewGame()
+	for _, terminator := range []string{"不戦勝", "不戦敗", "待った"} {
+		if result, ok := g.parseKIFTerminator(terminator); ok {
+			t.Errorf("parseKIFTerminator(%s) = %v, want none", terminator, result)
+		}
+	}
+	for _, terminator := range []string{"%TSUMI", "%MATTA", "%FUZUMI", "%ERROR"} {
+		if result, ok := g.parseCSATerminator(terminator); ok {
+			t.Errorf("parseCSATerminator(%s) = %v, want none", terminator, result)
+		}
+	}
+}
@@ -42,8 +42,9 @@ func BishopMovableRelativePositions() PositionList {
 
 func PromotedBishopMovableRelativePositions() PositionList {
 	additionalMovableRelativePositions := PositionList{
-		{X: -1, Y: 1}, {X: 1, Y: 1},
-		{X: -1, Y: -1}, {X: 1, Y: -1},
+		{X: 0, Y: 1},
+		{X: -1, Y: 0}, {X: 1, Y: 0},
+		{X: 0, Y: -1},
 	}
 	return append(BishopMovableRelativePositions(), additionalMovableRelativePositions...)
 }
@@ -0,0 +1,347 @@
+package shogi
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+const (
+	// MateScore is the score of checkmating the opponent at the root. Mates further away score less.
+	MateScore = 30000
+	// mateThreshold is the lowest score regarded as a mate
+	mateThreshold = MateScore - 1000
+	infiniteScore = MateScore + 1
+
+	defaultSearchDepth = 4
+	// nodesPerContextCheck is how often the search checks if it's cancelled
+	nodesPerContextCheck = 1024
+	// maxQuiescencePly is the deepest ply of the quiescence search, where it stands pat
+	maxQuiescencePly = 16
+)
+
+// SearchOptions configures the search
+type SearchOptions struct {
+	// Depth is the maximum depth in plies. It defaults to 4.
+	Depth int
+	// Threads is the number of workers searching in parallel sharing the transposition table (Lazy SMP).
+	// The search is deterministic with one thread, which is the default.
+	Threads int
+	// TableSize is the number of entries of the transposition table, 1<<18 if it's zero
+	TableSize int
+	// QuiescenceChecks makes the quiescence search try the checking moves at its first ply besides captures and promotions
+	QuiescenceChecks bool
+}
+
+// SearchResult is the result of the deepest completed iteration of the search
+type SearchResult struct {
+	Move Move
+	// Score is from the side to move's perspective in centipawn-like units, or near ±MateScore for mates
+	Score int
+	Depth int
+	// PV is the principal variation starting with Move
+	PV    []Move
+	Nodes uint64
+}
+
+// IsMate reports if the score means a forced mate for either side
+func (r SearchResult) IsMate() bool {
+	return r.Score >= mateThreshold || r.Score <= -mateThreshold
+}
+
+type searcher struct {
+	ctx     context.Context
+	board   *BitboardBoard
+	tt      *transpositionTable
+	checks  bool
+	nodes   uint64
+	stopped bool
+	// pv is the principal variation of the previous iteration, searched first
+	pv []Move
+	// rootMoves restricts the moves searched at the root if it's not nil
+	rootMoves []Move
+}
+
+// Search finds the best move with iterative deepening alpha-beta search until the depth or the context is done.
+// With multiple threads, the helper workers start from staggered depths and the result of the deepest completed
+// iteration of any worker is returned, preferring the main worker.
+// ok is false if the side to move has no legal move.
+func (b *BitboardBoard) Search(ctx context.Context, opts SearchOptions) (result SearchResult, ok bool) {
+	maxDepth := opts.Depth
+	if maxDepth <= 0 {
+		maxDepth = defaultSearchDepth
+	}
+	threads := opts.Threads
+	if threads <= 0 {
+		threads = 1
+	}
+	rootMoves := b.LegalMoves()
+	if len(rootMoves) == 0 {
+		return SearchResult{}, false
+	}
+
+	tt := newTranspositionTable(opts.TableSize)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	workers := make([]*searcher, threads)
+	results := make([]SearchResult, threads)
+	var wg sync.WaitGroup
+	for i := range workers {
+		workers[i] = &searcher{ctx: ctx, board: b.Clone(), tt: tt, checks: opts.QuiescenceChecks}
+		if i == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = workers[i].iterate(rootMoves, 1+i%2, maxDepth)
+		}(i)
+	}
+	results[0] = workers[0].iterate(rootMoves, 1, maxDepth)
+	// the helpers are only useful while the main worker searches
+	cancel()
+	wg.Wait()
+
+	result = results[0]
+	for i, r := range results {
+		if r.Depth > result.Depth {
+			result = r
+		}
+		if i > 0 {
+			result.Nodes += r.Nodes
+		}
+	}
+	return result, true
+}
+
+// Search finds the best move for the current player, see BitboardBoard.Search
+func (g *Game) Search(ctx context.Context, opts SearchOptions) (SearchResult, bool) {
+	return g.board.Search(ctx, opts)
+}
+
+// iterate searches deeper from the depth until maxDepth, and returns the result of the deepest completed iteration
+func (s *searcher) iterate(rootMoves []Move, depth, maxDepth int) SearchResult {
+	result := SearchResult{Move: rootMoves[0], PV: []Move{rootMoves[0]}}
+	for ; depth <= maxDepth; depth++ {
+		var pv []Move
+		score := s.alphaBeta(depth, 0, -infiniteScore, infiniteScore, &pv)
+		if s.stopped {
+			break
+		}
+		result = SearchResult{Move: pv[0], Score: score, Depth: depth, PV: pv}
+		s.pv = pv
+		if result.IsMate() {
+			break
+		}
+	}
+	result.Nodes = s.nodes
+	return result
+}
+
+func (s *searcher) alphaBeta(depth, ply, alpha, beta int, pv *[]Move) int {
+	if depth <= 0 {
+		return s.quiesce(ply, 0, alpha, beta)
+	}
+	s.nodes++
+	if s.nodes%nodesPerContextCheck == 0 && s.ctx.Err() != nil {
+		s.stopped = true
+	}
+	if s.stopped {
+		return 0
+	}
+
+	key := s.board.Hash()
+	ttMove, hasTTMove := Move{}, false
+	if move, score, ttDepth, b, ok := s.tt.probe(key); ok {
+		ttMove, hasTTMove = move, true
+		// the exact scores inside the window aren't used so that the principal variation is complete
+		score = scoreFromTT(score, ply)
+		if ply > 0 && ttDepth >= depth &&
+			(b != boundUpper && score >= beta || b != boundLower && score <= alpha) {
+			return score
+		}
+	}
+
+	moves := s.board.LegalMoves()
+	if ply == 0 && s.rootMoves != nil {
+		moves = append(moves[:0], s.rootMoves...)
+	}
+	if len(moves) == 0 {
+		return -MateScore + ply
+	}
+	// the move of the previous principal variation goes first, and the best move in the transposition table next
+	var first []Move
+	if ply < len(s.pv) {
+		first = append(first, s.pv[ply])
+	}
+	if hasTTMove {
+		first = append(first, ttMove)
+	}
+	s.orderMoves(moves, first...)
+
+	originalAlpha := alpha
+	best := -infiniteScore
+	var bestMove Move
+	var childPV []Move
+	for _, m := range moves {
+		s.board.DoMove(m)
+		childPV = childPV[:0]
+		score := -s.alphaBeta(depth-1, ply+1, -beta, -alpha, &childPV)
+		s.board.UndoMove(m)
+		if s.stopped {
+			return 0
+		}
+		if score > best {
+			best = score
+			bestMove = m
+			if score > alpha {
+				alpha = score
+				*pv = append(append((*pv)[:0], m), childPV...)
+			}
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+	b := boundExact
+	switch {
+	case best >= beta:
+		b = boundLower
+	case best <= originalAlpha:
+		b = boundUpper
+	}
+	// the score of the root with restricted moves isn't the score of the position
+	if ply > 0 || s.rootMoves == nil {
+		s.tt.store(key, bestMove, scoreToTT(best, ply), depth, b)
+	}
+	return best
+}
+
+// quiesce searches the captures and the promotions until the position is quiet so that the evaluation isn't made
+// in the middle of exchanges. The moves losing material by SEE are skipped unless the side to move is in check,
+// when every evasion is searched. qply is the ply from the start of the quiescence search.
+func (s *searcher) quiesce(ply, qply, alpha, beta int) int {
+	s.nodes++
+	if s.nodes%nodesPerContextCheck == 0 && s.ctx.Err() != nil {
+		s.stopped = true
+	}
+	if s.stopped {
+		return 0
+	}
+
+	inCheck := s.board.InCheck()
+	moves := s.board.LegalMoves()
+	if len(moves) == 0 {
+		return -MateScore + ply
+	}
+	best := -infiniteScore
+	if !inCheck {
+		// the side to move can stand pat unless it's in check
+		best = s.board.Evaluate()
+		if best >= beta || qply >= maxQuiescencePly {
+			return best
+		}
+		if best > alpha {
+			alpha = best
+		}
+		n := 0
+		for _, m := range moves {
+			if s.isQuiescenceMove(m, qply) {
+				moves[n] = m
+				n++
+			}
+		}
+		moves = moves[:n]
+	}
+	s.orderMoves(moves)
+
+	for _, m := range moves {
+		s.board.DoMove(m)
+		score := -s.quiesce(ply+1, qply+1, -beta, -alpha)
+		s.board.UndoMove(m)
+		if s.stopped {
+			return 0
+		}
+		if score > best {
+			best = score
+			if score > alpha {
+				alpha = score
+			}
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+	return best
+}
+
+// isQuiescenceMove reports if the quiescence search tries the move when not in check
+func (s *searcher) isQuiescenceMove(m Move, qply int) bool {
+	if m.Captured != KindNone || m.Promote {
+		return s.board.SEE(m) >= 0
+	}
+	if !s.checks || qply > 0 {
+		return false
+	}
+	s.board.DoMove(m)
+	check := s.board.InCheck()
+	s.board.UndoMove(m)
+	return check
+}
+
+// scoreToTT converts the mate score at the ply to the one relative to the node to store in the transposition table
+func scoreToTT(score, ply int) int {
+	switch {
+	case score >= mateThreshold:
+		return score + ply
+	case score <= -mateThreshold:
+		return score - ply
+	default:
+		return score
+	}
+}
+
+// scoreFromTT converts the mate score from the transposition table to the one at the ply
+func scoreFromTT(score, ply int) int {
+	switch {
+	case score >= mateThreshold:
+		return score - ply
+	case score <= -mateThreshold:
+		return score + ply
+	default:
+		return score
+	}
+}
+
+// orderMoves sorts the moves so that the ones likely to be good are searched first: the first moves in the order,
+// such as the move of the previous principal variation, then captures of valuable pieces by cheap pieces, then promotions.
+func (s *searcher) orderMoves(moves []Move, first ...Move) {
+	scored := make([]scoredMove, len(moves))
+	for i, m := range moves {
+		var score int
+		if m.Captured != KindNone {
+			score = pieceValues[m.Captured]*10 - pieceValues[m.Piece]
+		}
+		for j, f := range first {
+			if m == f {
+				score = infiniteScore - j
+				break
+			}
+		}
+		if m.Promote {
+			score += pieceValues[m.Piece.Promote()] - pieceValues[m.Piece]
+		}
+		scored[i] = scoredMove{move: m, score: score}
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+	for i := range scored {
+		moves[i] = scored[i].move
+	}
+}
+
+type scoredMove struct {
+	move  Move
+	score int
+}
@@ -0,0 +1,131 @@
+package shogi
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// middleGameSFEN is a position after the opening with pieces exchangeable on both sides
+const middleGameSFEN = "ln1g3nl/1r2gks2/p1sppp1pp/2p3p2/1p5P1/2P1P1P2/PPSP1P2P/2G1GS1R1/LN2K2NL b Bb 21"
+
+func TestBitboardBoard_Search_Deterministic(t *testing.T) {
+	board, _, err := ParseSFEN(middleGameSFEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := SearchOptions{Depth: 3, Threads: 1}
+	first, ok := board.Search(context.Background(), opts)
+	if !ok {
+		t.Fatal("Search() found no legal move")
+	}
+	for i := 0; i < 3; i++ {
+		got, _ := board.Search(context.Background(), opts)
+		if !reflect.DeepEqual(got, first) {
+			t.Fatalf("Search() = %+v, want the same result as the first run %+v", got, first)
+		}
+	}
+	if first.Depth != opts.Depth || len(first.PV) == 0 || first.PV[0] != first.Move {
+		t.Errorf("Search() = %+v, want the result of depth %d with the PV starting with the move", first, opts.Depth)
+	}
+}
+
+func TestBitboardBoard_Search_Mate(t *testing.T) {
+	tests := []struct {
+		name string
+		sfen string
+		// plies is the number of plies to the mate
+		plies int
+	}{
+		{name: "mate in 1", sfen: "7kl/9/6PPp/9/9/9/9/9/K8 b 2G 1", plies: 1},
+		{name: "mate in 3", sfen: "8k/8n/9/7N1/9/9/9/9/K8 b R 1", plies: 3},
+	}
+	for _, tt := range tests {
+		for _, threads := range []int{1, 4} {
+			board, _, err := ParseSFEN(tt.sfen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, ok := board.Search(context.Background(), SearchOptions{Depth: 4, Threads: threads})
+			if !ok {
+				t.Fatalf("%s with %d threads: Search() found no legal move", tt.name, threads)
+			}
+			if want := MateScore - tt.plies; result.Score != want || !result.IsMate() {
+				t.Errorf("%s with %d threads: Search() = %+v, want the score %d", tt.name, threads, result, want)
+			}
+			if tt.plies == 1 {
+				board.DoMove(result.Move)
+				if !board.InCheck() || len(board.LegalMoves()) > 0 {
+					t.Errorf("%s with %d threads: %s doesn't checkmate", tt.name, threads, result.Move)
+				}
+			}
+		}
+	}
+}
+
+func TestBitboardBoard_Search_Cancel(t *testing.T) {
+	board, _, err := ParseSFEN(middleGameSFEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	timeout, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	for name, ctx := range map[string]context.Context{"cancelled": cancelled, "timeout": timeout} {
+		result, ok := board.Search(ctx, SearchOptions{Depth: 30})
+		if !ok || board.ValidateMove(result.Move) != nil {
+			t.Fatalf("%s: Search() = %+v, %v, want a legal move", name, result, ok)
+		}
+		if result.Depth >= 30 {
+			t.Fatalf("%s: Search() completed depth %d", name, result.Depth)
+		}
+		if result.Depth == 0 {
+			continue
+		}
+		// the result is the one of the last completed depth, which is the same as searching to the depth
+		want, _ := board.Search(context.Background(), SearchOptions{Depth: result.Depth})
+		if result.Move != want.Move || result.Score != want.Score || !reflect.DeepEqual(result.PV, want.PV) {
+			t.Errorf("%s: Search() stopped at depth %d = %+v, want %+v", name, result.Depth, result, want)
+		}
+	}
+}
+
+// the quiescence search sees the recaptures beyond the depth, so that a shallow search doesn't stop in the middle of
+// an exchange and hang a piece
+func TestBitboardBoard_Search_Quiescence(t *testing.T) {
+	tests := []struct {
+		name string
+		sfen string
+		// bad are the moves losing the rook
+		bad []string
+	}{
+		// taking the pawn defended by the gold loses the rook
+		{name: "defended pawn", sfen: "4k4/9/4g4/4p4/9/9/9/4R4/K8 b - 1", bad: []string{"5h5d"}},
+		// the rook attacked by the pawn must escape, and taking the pawn defended by the gold doesn't save it
+		{name: "attacked rook", sfen: "4k4/9/9/4g4/4p4/4R4/9/9/K8 b - 1", bad: []string{"5f5e", "9i8h", "9i9h", "9i8i"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board, _, err := ParseSFEN(tt.sfen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, ok := board.Search(context.Background(), SearchOptions{Depth: 1})
+			if !ok {
+				t.Fatal("Search() found no legal move")
+			}
+			for _, bad := range tt.bad {
+				if result.Move.String() == bad {
+					t.Errorf("Search() = %s, which loses the rook", result.Move)
+				}
+			}
+			// sente is ahead by the rook against the gold and the pawn as long as it keeps the rook
+			if result.Score <= 0 {
+				t.Errorf("Search() score = %d, want the rook kept", result.Score)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package shogi
+
+import "sort"
+
+// seeOrder is the kinds of the pieces from the least valuable, in which the pieces recapture in the exchanges
+var seeOrder = func() []PieceKind {
+	kinds := make([]PieceKind, 0, numPieceKinds-1)
+	for kind := KindPawn; kind <= KindPromotedRook; kind++ {
+		kinds = append(kinds, kind)
+	}
+	sort.SliceStable(kinds, func(i, j int) bool {
+		return pieceValues[kinds[i]] < pieceValues[kinds[j]]
+	})
+	return kinds
+}()
+
+// SEE returns the static exchange evaluation of the move, the material the side to move gains when both sides
+// keep capturing on the destination with their least valuable piece as long as it pays.
+// Pins and the promotions of the recapturing pieces aren't considered.
+func (b *BitboardBoard) SEE(m Move) int {
+	us := b.turn
+	occupied := b.Occupied()
+	kind := m.Piece
+	if !m.Drop {
+		occupied.Clear(m.From)
+	}
+	// gains[i] is the material gained by the side making the i-th capture if the exchange stopped there
+	gains := []int{pieceValues[m.Captured]}
+	if m.Promote {
+		kind = kind.Promote()
+		gains[0] += pieceValues[kind] - pieceValues[m.Piece]
+	}
+	value := pieceValues[kind]
+	for side := us.Opponent(); ; side = side.Opponent() {
+		sq, attacker, ok := b.leastValuableAttacker(m.To, side, occupied)
+		if !ok {
+			break
+		}
+		if attacker == KindKing && !b.attackersTo(m.To, side.Opponent(), occupied).And(occupied).IsZero() {
+			// the king can't capture the defended piece
+			break
+		}
+		gains = append(gains, value-gains[len(gains)-1])
+		value = pieceValues[attacker]
+		occupied.Clear(sq)
+	}
+	// either side can stop capturing when it doesn't pay
+	for i := len(gains) - 1; i > 0; i-- {
+		if -gains[i] < gains[i-1] {
+			gains[i-1] = -gains[i]
+		}
+	}
+	return gains[0]
+}
+
+// leastValuableAttacker returns the square and the kind of the least valuable piece of the color attacking the square
+// among the pieces on occupied, ok is false if there is none
+func (b *BitboardBoard) leastValuableAttacker(sq Square, by Color, occupied Bitboard) (from Square, kind PieceKind, ok bool) {
+	attackers := b.attackersTo(sq, by, occupied).And(occupied)
+	if attackers.IsZero() {
+		return NoSquare, KindNone, false
+	}
+	for _, kind := range seeOrder {
+		if pieces := attackers.And(b.pieces[by][kind]); !pieces.IsZero() {
+			return pieces.First(), kind, true
+		}
+	}
+	return NoSquare, KindNone, false
+}
@@ -0,0 +1,42 @@
+package shogi
+
+import (
+	"testing"
+)
+
+func TestBitboardBoard_SEE(t *testing.T) {
+	tests := []struct {
+		name string
+		sfen string
+		move string
+		want int
+	}{
+		{name: "PxP defended by a pawn", sfen: "4k4/9/4p4/4p4/4P4/9/9/9/K8 b - 1", move: "5e5d", want: 0},
+		{name: "PxP undefended", sfen: "4k4/9/9/4p4/4P4/9/9/9/K8 b - 1", move: "5e5d", want: 90},
+		{name: "RxP defended by a gold", sfen: "4k4/9/4g4/4p4/9/9/9/4R4/K8 b - 1", move: "5h5d", want: -900},
+		{name: "x-ray lance behind the pawn", sfen: "4k4/9/4p4/4p4/4P4/9/4L4/9/K8 b - 1", move: "5e5d", want: 90},
+		{name: "king recaptures", sfen: "4k4/4p4/9/9/9/9/9/4R4/K8 b - 1", move: "5h5b", want: -900},
+		{name: "king can't recapture a defended piece", sfen: "4k4/4p4/9/9/9/9/9/4R4/K3L4 b - 1", move: "5h5b", want: 90},
+		{name: "promotion", sfen: "4k4/9/9/9/9/9/9/9/K7L b - 1", move: "1i1c+", want: 540 - 315},
+		{name: "quiet move to a defended square", sfen: "4k4/9/4g4/9/9/9/9/4R4/K8 b - 1", move: "5h5d", want: -990},
+		{name: "drop to a defended square", sfen: "4k4/9/4g4/9/9/9/9/9/K8 b P 1", move: "P*5d", want: -90},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _, err := ParseSFEN(tt.sfen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := b.ParseMove(tt.move)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := b.ValidateMove(m); err != nil {
+				t.Fatalf("%s is illegal: %v", tt.move, err)
+			}
+			if got := b.SEE(m); got != tt.want {
+				t.Errorf("SEE(%s) = %d, want %d", tt.move, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package shogi
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// InitialSFEN is the initial position in SFEN
+const InitialSFEN = "lnsgkgsnl/1r5b1/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL b - 1"
+
+func sfenPieceKind(letter byte) PieceKind {
+	for kind := KindPawn; kind <= KindKing; kind++ {
+		if usiPieceLetters[kind][0] == letter {
+			return kind
+		}
+	}
+	return KindNone
+}
+
+// ParseSFEN parses the position in SFEN, e.g. "lnsgkgsnl/1r5b1/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL b - 1".
+// It returns the board and the move number, which is 1 if it's omitted.
+func ParseSFEN(sfen string) (*BitboardBoard, int, error) {
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(sfen), "sfen "))
+	if len(fields) < 3 {
+		return nil, 0, errors.Errorf("invalid sfen: %q", sfen)
+	}
+
+	b := &BitboardBoard{}
+	ranks := strings.Split(fields[0], "/")
+	if len(ranks) != 9 {
+		return nil, 0, errors.Errorf("invalid sfen board, 9 ranks are expected: %q", fields[0])
+	}
+	for i, rank := range ranks {
+		y := Axis(i + 1)
+		x := Axis(9)
+		promoted := false
+		for j := 0; j < len(rank); j++ {
+			c := rank[j]
+			switch {
+			case c == '+':
+				promoted = true
+				continue
+			case c >= '1' && c <= '9':
+				x -= Axis(c - '0')
+			default:
+				kind := sfenPieceKind(upper(c))
+				if kind == KindNone || x < 1 {
+					return nil, 0, errors.Errorf("invalid sfen rank: %q", rank)
+				}
+				if promoted {
+					if !kind.IsPromotable() {
+						return nil, 0, errors.Errorf("invalid sfen rank, %c can't be promoted: %q", c, rank)
+					}
+					kind = kind.Promote()
+				}
+				color := Sente
+				if c != upper(c) {
+					color = Gote
+				}
+				b.put(NewSquare(x, y), kind, color)
+				x--
+			}
+			promoted = false
+		}
+		if x != 0 {
+			return nil, 0, errors.Errorf("invalid sfen rank, 9 squares are expected: %q", rank)
+		}
+	}
+
+	switch fields[1] {
+	case "b":
+		b.setTurn(Sente)
+	case "w":
+		b.setTurn(Gote)
+	default:
+		return nil, 0, errors.Errorf("invalid sfen side to move: %q", fields[1])
+	}
+
+	if fields[2] != "-" {
+		count, hasCount := 0, false
+		for j := 0; j < len(fields[2]); j++ {
+			c := fields[2][j]
+			if c >= '0' && c <= '9' {
+				count = count*10 + int(c-'0')
+				hasCount = true
+				continue
+			}
+			kind := sfenPieceKind(upper(c))
+			if kind == KindNone || kind == KindKing || (hasCount && count == 0) {
+				return nil, 0, errors.Errorf("invalid sfen hand: %q", fields[2])
+			}
+			if !hasCount {
+				count = 1
+			}
+			color := Sente
+			if c != upper(c) {
+				color = Gote
+			}
+			if b.hands[color][kind]+count > pieceKindCounts[kind] {
+				return nil, 0, errors.Errorf("invalid sfen hand, more than %d pieces of %s: %q", pieceKindCounts[kind], kind.Name(), fields[2])
+			}
+			b.addHand(color, kind, count)
+			count, hasCount = 0, false
+		}
+		// the count precedes the piece, e.g. 2P
+		if hasCount {
+			return nil, 0, errors.Errorf("invalid sfen hand, a count without a piece: %q", fields[2])
+		}
+	}
+
+	moveNumber := 1
+	if len(fields) >= 4 {
+		n, err := strconv.Atoi(fields[3])
+		if err != nil {
+			return nil, 0, errors.Wrapf(err, "invalid sfen move number: %q", fields[3])
+		}
+		moveNumber = n
+	}
+	return b, moveNumber, nil
+}
+
+// SFEN returns the position in SFEN with the given move number
+func (b *BitboardBoard) SFEN(moveNumber int) string {
+	var sb strings.Builder
+	for y := Axis(1); y <= 9; y++ {
+		if y > 1 {
+			sb.WriteByte('/')
+		}
+		empty := 0
+		for x := Axis(9); x >= 1; x-- {
+			kind, color := b.PieceAt(NewSquare(x, y))
+			if kind == KindNone {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteString(strconv.Itoa(empty))
+				empty = 0
+			}
+			letter := usiPieceLetters[kind]
+			if color == Gote {
+				letter = strings.ToLower(letter)
+			}
+			sb.WriteString(letter)
+		}
+		if empty > 0 {
+			sb.WriteString(strconv.Itoa(empty))
+		}
+	}
+
+	if b.turn == Sente {
+		sb.WriteString(" b ")
+	} else {
+		sb.WriteString(" w ")
+	}
+
+	hasHand := false
+	for c := Sente; c <= Gote; c++ {
+		for _, kind := range HandKinds {
+			n := b.hands[c][kind]
+			if n == 0 {
+				continue
+			}
+			if n > 1 {
+				sb.WriteString(strconv.Itoa(n))
+			}
+			letter := usiPieceLetters[kind]
+			if c == Gote {
+				letter = strings.ToLower(letter)
+			}
+			sb.WriteString(letter)
+			hasHand = true
+		}
+	}
+	if !hasHand {
+		sb.WriteByte('-')
+	}
+	sb.WriteByte(' ')
+	sb.WriteString(strconv.Itoa(moveNumber))
+	return sb.String()
+}
+
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
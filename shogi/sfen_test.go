@@ -0,0 +1,44 @@
+package shogi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSFEN_Hand(t *testing.T) {
+	tests := []struct {
+		name string
+		hand string
+		// want is a part of the error, which is empty for a valid hand
+		want string
+		// sente and gote are the pawns in hand of the valid hands
+		sente, gote int
+	}{
+		{name: "no pieces", hand: "-"},
+		{name: "counts", hand: "2P18p", sente: 2, gote: 18},
+		{name: "pieces listed one by one", hand: "PPp", sente: 2, gote: 1},
+		{name: "all the pawns", hand: "9P9P", sente: 18},
+		{name: "too many pawns", hand: "19P", want: "more than 18 pieces of 歩"},
+		{name: "too many pawns added up", hand: "10P9P", want: "more than 18 pieces of 歩"},
+		{name: "too many rooks", hand: "3R", want: "more than 2 pieces of 飛車"},
+		{name: "a trailing count", hand: "P19", want: "a count without a piece"},
+		{name: "a zero count", hand: "0P", want: "invalid sfen hand"},
+		{name: "a king", hand: "K", want: "invalid sfen hand"},
+	}
+	for _, tt := range tests {
+		b, _, err := ParseSFEN("4k4/9/9/9/9/9/9/9/4K4 b " + tt.hand + " 1")
+		if tt.want != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("%s: ParseSFEN() error = %v, want %q", tt.name, err, tt.want)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: ParseSFEN() error = %v", tt.name, err)
+			continue
+		}
+		if b.Hand(Sente, KindPawn) != tt.sente || b.Hand(Gote, KindPawn) != tt.gote {
+			t.Errorf("%s: pawns in hand = %d and %d, want %d and %d", tt.name, b.Hand(Sente, KindPawn), b.Hand(Gote, KindPawn), tt.sente, tt.gote)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package shogi
+
+import "sync/atomic"
+
+// defaultTableSize is the number of entries of the transposition table when SearchOptions.TableSize is zero
+const defaultTableSize = 1 << 18
+
+// bound is how the score of a table entry bounds the true score
+type bound uint64
+
+const (
+	boundExact bound = iota + 1
+	// boundLower is for the nodes failing high, whose true score is at least the score
+	boundLower
+	// boundUpper is for the nodes failing low, whose true score is at most the score
+	boundUpper
+)
+
+// transpositionTable stores the results of searched positions, and is shared by the search workers without locks.
+// Each entry is a pair of the key xor-ed with the data and the data, so a torn write of another worker only fails to match.
+type transpositionTable struct {
+	entries []ttEntry
+	mask    uint64
+}
+
+type ttEntry struct {
+	check uint64
+	data  uint64
+}
+
+// newTranspositionTable returns the table with the size rounded down to a power of two
+func newTranspositionTable(size int) *transpositionTable {
+	if size <= 0 {
+		size = defaultTableSize
+	}
+	n := 1
+	for n*2 <= size {
+		n *= 2
+	}
+	return &transpositionTable{entries: make([]ttEntry, n), mask: uint64(n - 1)}
+}
+
+// probe returns the best move, score, depth and bound stored for the position, ok is false if it's not found
+func (t *transpositionTable) probe(key uint64) (move Move, score, depth int, b bound, ok bool) {
+	e := &t.entries[key&t.mask]
+	data := atomic.LoadUint64(&e.data)
+	if atomic.LoadUint64(&e.check)^data != key || data == 0 {
+		return Move{}, 0, 0, 0, false
+	}
+	move = unpackMove(uint32(data & 0xffffff))
+	score = int(int16(data >> 24))
+	depth = int(uint8(data >> 40))
+	return move, score, depth, bound(data >> 48 & 3), true
+}
+
+// store saves the result of the search of the position, replacing the entry of another position or a shallower search
+func (t *transpositionTable) store(key uint64, move Move, score, depth int, b bound) {
+	e := &t.entries[key&t.mask]
+	if old := atomic.LoadUint64(&e.data); atomic.LoadUint64(&e.check)^old == key && int(uint8(old>>40)) > depth {
+		return
+	}
+	data := uint64(packMove(move)) | uint64(uint16(int16(score)))<<24 | uint64(uint8(depth))<<40 | uint64(b)<<48
+	atomic.StoreUint64(&e.check, key^data)
+	atomic.StoreUint64(&e.data, data)
+}
+
+// packMove packs the move into 24 bits, from and to squares shifted by one for NoSquare
+func packMove(m Move) uint32 {
+	packed := uint32(m.From+1) | uint32(m.To+1)<<7 | uint32(m.Piece)<<14 | uint32(m.Captured)<<18
+	if m.Promote {
+		packed |= 1 << 22
+	}
+	if m.Drop {
+		packed |= 1 << 23
+	}
+	return packed
+}
+
+func unpackMove(packed uint32) Move {
+	return Move{
+		From:     Square(packed&0x7f) - 1,
+		To:       Square(packed>>7&0x7f) - 1,
+		Piece:    PieceKind(packed >> 14 & 0xf),
+		Captured: PieceKind(packed >> 18 & 0xf),
+		Promote:  packed&(1<<22) != 0,
+		Drop:     packed&(1<<23) != 0,
+	}
+}
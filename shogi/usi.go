@@ -0,0 +1,110 @@
+package shogi
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ParseSquare parses the square in USI notation, e.g. "7g"
+func ParseSquare(s string) (Square, error) {
+	if len(s) != 2 || s[0] < '1' || s[0] > '9' || s[1] < 'a' || s[1] > 'i' {
+		return NoSquare, errors.Errorf("invalid square: %q", s)
+	}
+	return NewSquare(Axis(s[0]-'0'), Axis(s[1]-'a'+1)), nil
+}
+
+// ParseMove parses the move in USI notation for the side to move, e.g. "7g7f", "8h2b+", "P*5e".
+// It only checks the notation, see ValidateMove for legality.
+func (b *BitboardBoard) ParseMove(usi string) (Move, error) {
+	if len(usi) == 4 && usi[1] == '*' {
+		kind := sfenPieceKind(usi[0])
+		if kind == KindNone || kind == KindKing {
+			return Move{}, errors.Errorf("invalid drop: %q", usi)
+		}
+		to, err := ParseSquare(usi[2:])
+		if err != nil {
+			return Move{}, errors.Wrapf(err, "invalid drop: %q", usi)
+		}
+		return b.NewDrop(kind, to), nil
+	}
+	if len(usi) != 4 && !(len(usi) == 5 && usi[4] == '+') {
+		return Move{}, errors.Errorf("invalid move: %q", usi)
+	}
+	from, err := ParseSquare(usi[0:2])
+	if err != nil {
+		return Move{}, errors.Wrapf(err, "invalid move: %q", usi)
+	}
+	to, err := ParseSquare(usi[2:4])
+	if err != nil {
+		return Move{}, errors.Wrapf(err, "invalid move: %q", usi)
+	}
+	return b.NewMove(from, to, len(usi) == 5), nil
+}
+
+// USIPosition returns the game in the format of USI position command without "position" prefix,
+// e.g. "startpos moves 7g7f 3c3d"
+func (g *Game) USIPosition() string {
+	var sb strings.Builder
+	if sfen := g.initialBoard.SFEN(g.initialMoveNumber); sfen == InitialSFEN {
+		sb.WriteString("startpos")
+	} else {
+		sb.WriteString("sfen ")
+		sb.WriteString(sfen)
+	}
+	if len(g.history) > 0 {
+		sb.WriteString(" moves")
+		for _, m := range g.history {
+			sb.WriteByte(' ')
+			sb.WriteString(m.String())
+		}
+	}
+	return sb.String()
+}
+
+// NewGameFromUSIPosition starts new shogi game from the arguments of USI position command,
+// e.g. "startpos moves 7g7f 3c3d" or "sfen <sfen> moves 7g7f", and plays the moves.
+func NewGameFromUSIPosition(position string) (*Game, error) {
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(position), "position"))
+	if len(fields) == 0 {
+		return nil, errors.New("empty position")
+	}
+	var game *Game
+	var moves []string
+	switch fields[0] {
+	case "startpos":
+		game = NewGame()
+		moves = fields[1:]
+	case "sfen":
+		end := len(fields)
+		for i, f := range fields {
+			if f == "moves" {
+				end = i
+				break
+			}
+		}
+		var err error
+		if game, err = NewGameFromSFEN(strings.Join(fields[1:end], " ")); err != nil {
+			return nil, err
+		}
+		moves = fields[end:]
+	default:
+		return nil, errors.Errorf("invalid position: %q", position)
+	}
+	if len(moves) == 0 {
+		return game, nil
+	}
+	if moves[0] != "moves" {
+		return nil, errors.Errorf("invalid position: %q", position)
+	}
+	for _, usi := range moves[1:] {
+		m, err := game.board.ParseMove(usi)
+		if err != nil {
+			return nil, err
+		}
+		if err := game.ApplyMove(m); err != nil {
+			return nil, errors.Wrapf(err, "move %d %s", game.MoveNumber(), usi)
+		}
+	}
+	return game, nil
+}